@@ -4,13 +4,16 @@
 package main
 
 import (
+	"context"
 	"io/ioutil"
 	"log"
 	"math/rand"
 	"os"
+	"os/signal"
 	"time"
 
 	"github.com/cppforlife/go-cli-ui/ui"
+	"github.com/k14s/imgpkg/pkg/imgpkg/bundle"
 	"github.com/k14s/imgpkg/pkg/imgpkg/cmd"
 )
 
@@ -19,19 +22,40 @@ func main() {
 
 	log.SetOutput(ioutil.Discard)
 
+	if label := os.Getenv("IMGPKG_BUNDLE_LABEL"); label != "" {
+		bundle.BundleConfigLabel = label
+	}
+
 	// TODO logs
 	// TODO log flags used
 
 	confUI := ui.NewConfUI(ui.NewNoopLogger())
 	defer confUI.Flush()
 
+	// Abort registry operations on the first interrupt, a second one exits right away
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	go func() {
+		<-ctx.Done()
+		stop()
+	}()
+
 	command := cmd.NewDefaultImgpkgCmd(confUI)
 
-	err := command.Execute()
+	err := command.ExecuteContext(ctx)
 	if err != nil {
 		confUI.ErrorLinef("Error: %v", err)
 		os.Exit(1)
 	}
 
-	confUI.PrintLinef("Succeeded")
+	executedCommand, _, err := command.Find(os.Args[1:])
+	if err != nil {
+		executedCommand = command
+	}
+
+	// Keep stdout parsable when the command prints a machine readable result
+	if !cmd.IsMachineReadableOutput(executedCommand) {
+		confUI.PrintLinef("Succeeded")
+	}
 }
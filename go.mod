@@ -5,7 +5,9 @@ go 1.16
 require (
 	github.com/cppforlife/cobrautil v0.0.0-20200514214827-bb86e6965d72
 	github.com/cppforlife/go-cli-ui v0.0.0-20200506005011-4268990983cc
+	github.com/fatih/color v1.7.0
 	github.com/google/go-containerregistry v0.4.1
+	github.com/klauspost/compress v1.11.13
 	github.com/maxbrunsfeld/counterfeiter/v6 v6.3.0
 	github.com/onsi/gomega v1.10.4 // indirect
 	github.com/spf13/cobra v1.1.3
@@ -10,7 +10,7 @@ function cleanup {
 }
 trap cleanup EXIT
 
-docker run -d -p "$PORT":5000 -e REGISTRY_VALIDATION_MANIFESTS_URLS_ALLOW='- ^https?://' --restart always --name registry-"$PORT" registry:2
+docker run -d -p "$PORT":5000 -e REGISTRY_STORAGE_DELETE_ENABLED=true -e REGISTRY_VALIDATION_MANIFESTS_URLS_ALLOW='- ^https?://' --restart always --name registry-"$PORT" registry:2
 export IMGPKG_E2E_IMAGE="localhost:$PORT/local-tests/test-repo"
 export IMGPKG_E2E_RELOCATION_REPO="localhost:$PORT/local-tests/test-relocation-repo"
 ./hack/test-all.sh $@
@@ -4,6 +4,7 @@
 package bundle
 
 import (
+	"context"
 	"fmt"
 	"path/filepath"
 	"strings"
@@ -17,9 +18,30 @@ import (
 )
 
 const (
-	BundleConfigLabel = "dev.carvel.imgpkg.bundle"
+	// DefaultBundleConfigLabel is the label used to mark images as bundles
+	DefaultBundleConfigLabel = "dev.carvel.imgpkg.bundle"
+	// LegacyBundleConfigLabel was used by earlier releases, images marked with it are still bundles
+	LegacyBundleConfigLabel = "io.k14s.imgpkg.bundle"
 )
 
+// BundleConfigLabel is the label (or manifest annotation) added when pushing a bundle.
+// It can be overridden at build time via
+// -ldflags "-X github.com/k14s/imgpkg/pkg/imgpkg/bundle.BundleConfigLabel=<label>"
+// or at runtime via the IMGPKG_BUNDLE_LABEL env variable.
+var BundleConfigLabel = DefaultBundleConfigLabel
+
+// bundleConfigLabels returns every label (or manifest annotation)
+// that marks an image as a bundle, the configured one first
+func bundleConfigLabels() []string {
+	labels := []string{BundleConfigLabel}
+	for _, label := range []string{DefaultBundleConfigLabel, LegacyBundleConfigLabel} {
+		if label != BundleConfigLabel {
+			labels = append(labels, label)
+		}
+	}
+	return labels
+}
+
 //go:generate go run github.com/maxbrunsfeld/counterfeiter/v6 . ImagesLockReader
 type ImagesLockReader interface {
 	Read(img regv1.Image) (lockconfig.ImagesLock, error)
@@ -47,13 +69,13 @@ func (o *Bundle) DigestRef() string { return o.plainImg.DigestRef() }
 func (o *Bundle) Repo() string      { return o.plainImg.Repo() }
 func (o *Bundle) Tag() string       { return o.plainImg.Tag() }
 
-func (o *Bundle) Pull(outputPath string, ui goui.UI, pullNestedBundles bool) error {
-	return o.pull(outputPath, ui, pullNestedBundles, "", map[string]bool{}, 0)
+func (o *Bundle) Pull(ctx context.Context, outputPath string, ui goui.UI, pullNestedBundles bool) error {
+	return o.pull(ctx, outputPath, ui, pullNestedBundles, "", map[string]bool{}, 0)
 }
 
-func (o *Bundle) pull(baseOutputPath string, ui goui.UI, pullNestedBundles bool, bundlePath string,
+func (o *Bundle) pull(ctx context.Context, baseOutputPath string, ui goui.UI, pullNestedBundles bool, bundlePath string,
 	imagesProcessed map[string]bool, numSubBundles int) error {
-	img, err := o.checkedImage()
+	img, err := o.checkedImage(ctx)
 	if err != nil {
 		return err
 	}
@@ -74,7 +96,7 @@ func (o *Bundle) pull(baseOutputPath string, ui goui.UI, pullNestedBundles bool,
 		return err
 	}
 
-	localizedImagesLockToRepo, notLocalizedToBundle, err := NewImagesLock(imagesLock, o.imgRetriever, o.Repo()).LocalizeImagesLock()
+	localizedImagesLockToRepo, notLocalizedToBundle, err := NewImagesLock(imagesLock, o.imgRetriever, o.Repo()).LocalizeImagesLock(ctx)
 	if err != nil {
 		return err
 	}
@@ -90,7 +112,7 @@ func (o *Bundle) pull(baseOutputPath string, ui goui.UI, pullNestedBundles bool,
 			}
 
 			subBundle := NewBundle(image.Image, o.imgRetriever)
-			isBundle, err := subBundle.IsBundle()
+			isBundle, err := subBundle.IsBundle(ctx)
 			if err != nil {
 				return err
 			}
@@ -109,7 +131,7 @@ func (o *Bundle) pull(baseOutputPath string, ui goui.UI, pullNestedBundles bool,
 			if err != nil {
 				return err
 			}
-			err = subBundle.pull(baseOutputPath, goui.NewIndentingUI(ui), pullNestedBundles, o.subBundlePath(bundleDigest), imagesProcessed, numSubBundles)
+			err = subBundle.pull(ctx, baseOutputPath, goui.NewIndentingUI(ui), pullNestedBundles, o.subBundlePath(bundleDigest), imagesProcessed, numSubBundles)
 			if err != nil {
 				return err
 			}
@@ -148,8 +170,8 @@ func (o *Bundle) rootBundle(bundlePath string) bool {
 	return bundlePath == ""
 }
 
-func (o *Bundle) checkedImage() (regv1.Image, error) {
-	isBundle, err := o.IsBundle()
+func (o *Bundle) checkedImage(ctx context.Context) (regv1.Image, error) {
+	isBundle, err := o.IsBundle(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("Checking if image is bundle: %s", err)
 	}
@@ -157,7 +179,7 @@ func (o *Bundle) checkedImage() (regv1.Image, error) {
 		return nil, notABundleError{}
 	}
 
-	img, err := o.plainImg.Fetch()
+	img, err := o.plainImg.Fetch(ctx)
 	if err == nil && img == nil {
 		panic("Unreachable")
 	}
@@ -5,6 +5,7 @@ package bundle
 
 import (
 	"archive/tar"
+	"context"
 	"fmt"
 	"io"
 	"io/ioutil"
@@ -13,24 +14,51 @@ import (
 
 	regv1 "github.com/google/go-containerregistry/pkg/v1"
 	"github.com/google/go-containerregistry/pkg/v1/types"
+	ctlimg "github.com/k14s/imgpkg/pkg/imgpkg/image"
 	"github.com/k14s/imgpkg/pkg/imgpkg/lockconfig"
 	"github.com/k14s/imgpkg/pkg/imgpkg/util"
 )
 
-func (o *Bundle) AllImagesLock(concurrency int) (*ImagesLock, error) {
+func (o *Bundle) AllImagesLock(ctx context.Context, concurrency int) (*ImagesLock, error) {
 	throttleReq := util.NewThrottle(concurrency)
-	return o.buildAllImagesLock(&throttleReq, &processedImages{processedImgs: map[string]struct{}{}})
+	imagesLock, _, err := o.buildAllImagesLock(ctx, &throttleReq, &processedImages{processedImgs: map[string]struct{}{}}, true)
+	return imagesLock, err
 }
 
-func (o *Bundle) buildAllImagesLock(throttleReq *util.Throttle, processedImgs *processedImages) (*ImagesLock, error) {
-	img, err := o.checkedImage()
+// ImageError records why an image referenced by a bundle could not be reached
+type ImageError struct {
+	Image string
+	Err   error
+}
+
+func (e ImageError) Error() string {
+	return fmt.Sprintf("Unable to reach image '%s': %s", e.Image, e.Err)
+}
+
+// AllReachableImagesLock behaves like AllImagesLock, but images that cannot be reached
+// are left out of the returned lock and reported instead of failing on the first one.
+// Nested bundles referencing unreachable images are reported (and left out) as well.
+func (o *Bundle) AllReachableImagesLock(ctx context.Context, concurrency int) (*ImagesLock, []ImageError, error) {
+	throttleReq := util.NewThrottle(concurrency)
+	return o.buildAllImagesLock(ctx, &throttleReq, &processedImages{processedImgs: map[string]struct{}{}}, false)
+}
+
+// buildAllImagesLock resolves tag refs of the Images Lock of the bundle
+// and of every nested bundle, so that they can be pinned when copying
+func (o *Bundle) buildAllImagesLock(ctx context.Context, throttleReq *util.Throttle, processedImgs *processedImages, failFast bool) (*ImagesLock, []ImageError, error) {
+	img, err := o.checkedImage(ctx)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	imagesLock, err := o.imagesLockReader.Read(img)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
+	}
+
+	imagesLock, imgErrs, err := o.resolveTagRefs(ctx, imagesLock, failFast)
+	if err != nil {
+		return nil, nil, err
 	}
 
 	allImagesLock := NewImagesLock(imagesLock, o.imgRetriever, o.Repo())
@@ -46,14 +74,17 @@ func (o *Bundle) buildAllImagesLock(throttleReq *util.Throttle, processedImgs *p
 
 		image := image.DeepCopy()
 		go func() {
-			imgsLock, err := o.imagesLockIfIsBundle(throttleReq, image, processedImgs)
+			imgsLock, nestedImgErrs, err := o.imagesLockIfIsBundle(ctx, throttleReq, image, processedImgs, failFast)
 			if err != nil {
 				errChan <- err
 				return
 			}
+
+			mutex.Lock()
+			defer mutex.Unlock()
+
+			imgErrs = append(imgErrs, nestedImgErrs...)
 			if imgsLock != nil {
-				mutex.Lock()
-				defer mutex.Unlock()
 				err = allImagesLock.Merge(imgsLock)
 				if err != nil {
 					errChan <- fmt.Errorf("Merging images for bundle '%s': %s", image.Image, err)
@@ -66,36 +97,50 @@ func (o *Bundle) buildAllImagesLock(throttleReq *util.Throttle, processedImgs *p
 
 	for range imagesLock.Images {
 		if err := <-errChan; err != nil {
-			return nil, err
+			return nil, nil, err
 		}
 	}
 
+	allImagesLock.removeImageRefs(imgErrs)
+
 	err = allImagesLock.GenerateImagesLocations()
 	if err != nil {
-		return nil, fmt.Errorf("Generating locations list for images in bundle %s: %s", o.DigestRef(), err)
+		return nil, nil, fmt.Errorf("Generating locations list for images in bundle %s: %s", o.DigestRef(), err)
 	}
 
-	return allImagesLock, nil
+	return allImagesLock, imgErrs, nil
 }
 
-func (o *Bundle) imagesLockIfIsBundle(throttleReq *util.Throttle, image lockconfig.ImageRef, processedImgs *processedImages) (*ImagesLock, error) {
+func (o *Bundle) imagesLockIfIsBundle(ctx context.Context, throttleReq *util.Throttle, image lockconfig.ImageRef, processedImgs *processedImages, failFast bool) (*ImagesLock, []ImageError, error) {
 	throttleReq.Take()
 	bundle := NewBundleWithReader(image.Image, o.imgRetriever, o.imagesLockReader)
 
-	isBundle, err := bundle.IsBundle()
+	isBundle, err := bundle.IsBundle(ctx)
 	throttleReq.Done()
 	if err != nil {
-		return nil, fmt.Errorf("Checking if '%s' is a bundle: %s", image.Image, err)
+		if !failFast {
+			return nil, []ImageError{{Image: image.Image, Err: err}}, nil
+		}
+		return nil, nil, fmt.Errorf("Checking if '%s' is a bundle: %s", image.Image, err)
 	}
 
-	var imgLock *ImagesLock
-	if isBundle {
-		imgLock, err = bundle.buildAllImagesLock(throttleReq, processedImgs)
-		if err != nil {
-			return nil, fmt.Errorf("Retrieving images for bundle '%s': %s", image.Image, err)
-		}
+	if !isBundle {
+		return nil, nil, nil
 	}
-	return imgLock, nil
+
+	imgLock, imgErrs, err := bundle.buildAllImagesLock(ctx, throttleReq, processedImgs, failFast)
+	if err != nil {
+		return nil, nil, fmt.Errorf("Retrieving images for bundle '%s': %s", image.Image, err)
+	}
+
+	if len(imgErrs) > 0 {
+		imgErrs = append(imgErrs, ImageError{
+			Image: image.Image,
+			Err:   fmt.Errorf("Bundle references images that cannot be reached"),
+		})
+	}
+
+	return imgLock, imgErrs, nil
 }
 
 type processedImages struct {
@@ -132,12 +177,12 @@ func (o *singleLayerReader) Read(img regv1.Image) (lockconfig.ImagesLock, error)
 		return conf, err
 	}
 
-	if mediaType != types.DockerLayer {
-		return conf, fmt.Errorf("Expected layer to have docker layer media type, was %s", mediaType)
+	if mediaType != types.DockerLayer && mediaType != ctlimg.OCIZstdLayer {
+		return conf, fmt.Errorf("Expected layer to have docker layer or zstd layer media type, was %s", mediaType)
 	}
 
-	// here we know layer is .tgz so decompress and read tar headers
-	unzippedReader, err := layer.Uncompressed()
+	// here we know layer is .tgz or .tar.zst so decompress and read tar headers
+	unzippedReader, err := ctlimg.UncompressedLayer(layer)
 	if err != nil {
 		return conf, fmt.Errorf("Could not read bundle image layer contents: %v", err)
 	}
@@ -164,5 +209,6 @@ func (o *singleLayerReader) Read(img regv1.Image) (lockconfig.ImagesLock, error)
 		return conf, fmt.Errorf("Reading images.yml from layer: %s", err)
 	}
 
-	return lockconfig.NewImagesLockFromBytes(bs)
+	// Tag refs are resolved (or rejected) by the caller
+	return lockconfig.NewImagesLockFromBytesWithOpts(bs, lockconfig.ReadOpts{AllowTags: true})
 }
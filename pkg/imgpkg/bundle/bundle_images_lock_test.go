@@ -4,6 +4,7 @@
 package bundle_test
 
 import (
+	"context"
 	"testing"
 
 	regname "github.com/google/go-containerregistry/pkg/name"
@@ -41,7 +42,7 @@ func TestBundle_AllImagesLock(t *testing.T) {
 		})
 
 		subject := bundle.NewBundleWithReader(bundle1.RefDigest, fakeRegistry.Build(), fakeImagesLockReader)
-		resultImagesLock, err := subject.AllImagesLock(1)
+		resultImagesLock, err := subject.AllImagesLock(context.Background(), 1)
 		require.NoError(t, err)
 
 		require.Equal(t, 1, fakeImagesLockReader.ReadCallCount())
@@ -94,7 +95,7 @@ func TestBundle_AllImagesLock(t *testing.T) {
 		})
 
 		subject := bundle.NewBundleWithReader(bundle2.RefDigest, fakeRegistry.Build(), fakeImagesLockReader)
-		resultImagesLock, err := subject.AllImagesLock(1)
+		resultImagesLock, err := subject.AllImagesLock(context.Background(), 1)
 		require.NoError(t, err)
 
 		require.Equal(t, 2, fakeImagesLockReader.ReadCallCount())
@@ -173,7 +174,7 @@ func TestBundle_AllImagesLock(t *testing.T) {
 
 		fakeImagesReaderWriter := fakeRegistry.Build()
 		subject := bundle.NewBundleWithReader(bundle3.RefDigest, fakeImagesReaderWriter, fakeImagesLockReader)
-		resultImagesLock, err := subject.AllImagesLock(1)
+		resultImagesLock, err := subject.AllImagesLock(context.Background(), 1)
 		require.NoError(t, err)
 
 		require.Equal(t, 3, fakeImagesLockReader.ReadCallCount())
@@ -258,9 +259,116 @@ func TestBundle_AllImagesLock(t *testing.T) {
 
 		fakeImagesReaderWriter := fakeRegistry.Build()
 		subject := bundle.NewBundleWithReader(bundle3.RefDigest, fakeImagesReaderWriter, fakeImagesLockReader)
-		_, err := subject.AllImagesLock(1)
+		_, err := subject.AllImagesLock(context.Background(), 1)
 		require.NoError(t, err)
 
 		require.Equal(t, 3, fakeImagesLockReader.ReadCallCount())
 	})
+
+	t.Run("when the bundle references an image by tag it resolves the tag to a digest", func(t *testing.T) {
+		fakeImagesLockReader := &bundlefakes.FakeImagesLockReader{}
+		fakeImagesLockReader.ReadReturns(lockconfig.ImagesLock{
+			Images: []lockconfig.ImageRef{{Image: fakeRegistry.ReferenceOnTestServer("library/img1:latest")}},
+		}, nil)
+
+		subject := bundle.NewBundleWithReader(bundle1.RefDigest, fakeRegistry.Build(), fakeImagesLockReader)
+		resultImagesLock, err := subject.AllImagesLock(context.Background(), 1)
+		require.NoError(t, err)
+
+		imgRefs := resultImagesLock.ImageRefs()
+		require.Len(t, imgRefs, 1)
+		assert.Equal(t, img1.RefDigest, imgRefs[0].Image)
+		assert.Equal(t, "latest", imgRefs[0].Tag)
+	})
+
+	t.Run("when a nested bundle references an image by tag it resolves the tag to a digest", func(t *testing.T) {
+		fakeImagesLockReader := &bundlefakes.FakeImagesLockReader{}
+		fakeImagesLockReader.ReadReturnsOnCall(0, lockconfig.ImagesLock{
+			Images: []lockconfig.ImageRef{{Image: bundle1.RefDigest}},
+		}, nil)
+		fakeImagesLockReader.ReadReturnsOnCall(1, lockconfig.ImagesLock{
+			Images: []lockconfig.ImageRef{{Image: fakeRegistry.ReferenceOnTestServer("library/img1:latest")}},
+		}, nil)
+
+		subject := bundle.NewBundleWithReader(bundle2.RefDigest, fakeRegistry.Build(), fakeImagesLockReader)
+		resultImagesLock, err := subject.AllImagesLock(context.Background(), 1)
+		require.NoError(t, err)
+
+		var images []string
+		for _, imgRef := range resultImagesLock.ImageRefs() {
+			images = append(images, imgRef.Image)
+		}
+		assert.ElementsMatch(t, []string{bundle1.RefDigest, img1.RefDigest}, images)
+	})
+}
+
+func TestBundle_AllReachableImagesLock(t *testing.T) {
+	fakeRegistry := helpers.NewFakeRegistry(t)
+	defer fakeRegistry.CleanUp()
+	logger := &helpers.Logger{}
+	img1 := fakeRegistry.WithRandomImage("library/img1")
+	img2 := fakeRegistry.WithRandomImage("library/img2")
+	bundle1 := fakeRegistry.WithRandomBundle("library/bundle1")
+	bundle2 := fakeRegistry.WithRandomBundle("library/bundle2")
+	missingImgRef := fakeRegistry.ReferenceOnTestServer("library/missing@sha256:45f3926bca9fc42adb650fef2a41250d77841dde49afc8adc7c0c633b3d5f27a")
+
+	t.Run("when a nested bundle references an unreachable image it reports the image and the nested bundle", func(t *testing.T) {
+		fakeImagesLockReader := &bundlefakes.FakeImagesLockReader{}
+
+		logger.Section("bundle1 contains img1 and an unreachable image", func() {
+			bundle1ImagesLock := lockconfig.ImagesLock{
+				Images: []lockconfig.ImageRef{
+					{
+						Image: img1.RefDigest,
+					},
+					{
+						Image: missingImgRef,
+					},
+				},
+			}
+			fakeImagesLockReader.ReadReturnsOnCall(1, bundle1ImagesLock, nil)
+		})
+
+		logger.Section("bundle2 contains bundle1 and img2", func() {
+			bundle2ImagesLock := lockconfig.ImagesLock{
+				Images: []lockconfig.ImageRef{
+					{
+						Image: bundle1.RefDigest,
+					},
+					{
+						Image: img2.RefDigest,
+					},
+				},
+			}
+			fakeImagesLockReader.ReadReturnsOnCall(0, bundle2ImagesLock, nil)
+		})
+
+		subject := bundle.NewBundleWithReader(bundle2.RefDigest, fakeRegistry.Build(), fakeImagesLockReader)
+		resultImagesLock, imgErrs, err := subject.AllReachableImagesLock(context.Background(), 1)
+		require.NoError(t, err)
+
+		var reachableImages []string
+		for _, imgRef := range resultImagesLock.ImageRefs() {
+			reachableImages = append(reachableImages, imgRef.Image)
+		}
+		assert.ElementsMatch(t, []string{img1.RefDigest, img2.RefDigest}, reachableImages)
+
+		var unreachableImages []string
+		for _, imgErr := range imgErrs {
+			unreachableImages = append(unreachableImages, imgErr.Image)
+		}
+		assert.ElementsMatch(t, []string{missingImgRef, bundle1.RefDigest}, unreachableImages)
+	})
+
+	t.Run("when AllImagesLock is used it fails on the unreachable image", func(t *testing.T) {
+		fakeImagesLockReader := &bundlefakes.FakeImagesLockReader{}
+		fakeImagesLockReader.ReadReturns(lockconfig.ImagesLock{
+			Images: []lockconfig.ImageRef{{Image: img1.RefDigest}, {Image: missingImgRef}},
+		}, nil)
+
+		subject := bundle.NewBundleWithReader(bundle1.RefDigest, fakeRegistry.Build(), fakeImagesLockReader)
+		_, err := subject.AllImagesLock(context.Background(), 1)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), missingImgRef)
+	})
 }
@@ -5,6 +5,7 @@ package bundle_test
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -33,7 +34,7 @@ func TestPullBundleWritingContentsToDisk(t *testing.T) {
 		assert.NoError(t, err)
 		defer os.Remove(outputPath)
 
-		err = subject.Pull(outputPath, fakeUI, pullNestedBundles)
+		err = subject.Pull(context.Background(), outputPath, fakeUI, pullNestedBundles)
 		assert.NoError(t, err)
 
 		assert.DirExists(t, outputPath)
@@ -62,7 +63,7 @@ func TestPullBundleWritingContentsToDisk(t *testing.T) {
 		defer os.Remove(outputPath)
 
 		// test subject
-		err = subject.Pull(outputPath, fakeUI, pullNestedBundles)
+		err = subject.Pull(context.Background(), outputPath, fakeUI, pullNestedBundles)
 		assert.NoError(t, err)
 		assert.DirExists(t, outputPath)
 
@@ -90,7 +91,7 @@ func TestPullNestedBundlesWritingContentsToDisk(t *testing.T) {
 		assert.NoError(t, err)
 		defer os.Remove(outputPath)
 
-		err = subject.Pull(outputPath, fakeUI, pullNestedBundles)
+		err = subject.Pull(context.Background(), outputPath, fakeUI, pullNestedBundles)
 		assert.NoError(t, err)
 
 		assert.DirExists(t, outputPath)
@@ -116,7 +117,7 @@ func TestPullNestedBundlesWritingContentsToDisk(t *testing.T) {
 		assert.NoError(t, err)
 		defer os.Remove(outputPath)
 
-		err = subject.Pull(outputPath, fakeUI, pullNestedBundles)
+		err = subject.Pull(context.Background(), outputPath, fakeUI, pullNestedBundles)
 		assert.NoError(t, err)
 
 		assert.DirExists(t, outputPath)
@@ -145,7 +146,7 @@ func TestPullNestedBundlesWritingContentsToDisk(t *testing.T) {
 		defer os.Remove(outputPath)
 
 		// test subject
-		err = subject.Pull(outputPath, fakeUI, pullNestedBundles)
+		err = subject.Pull(context.Background(), outputPath, fakeUI, pullNestedBundles)
 		assert.NoError(t, err)
 
 		// assert icecream bundle was recursively pulled onto disk
@@ -196,7 +197,7 @@ func TestPullNestedBundlesLocalizesImagesLockFile(t *testing.T) {
 		assert.NoError(t, err)
 		defer os.Remove(outputPath)
 
-		err = subject.Pull(outputPath, fakeUI, pullNestedBundles)
+		err = subject.Pull(context.Background(), outputPath, fakeUI, pullNestedBundles)
 		assert.NoError(t, err)
 
 		assert.DirExists(t, outputPath)
@@ -254,7 +255,7 @@ kind: ImagesLock
 		assert.NoError(t, err)
 		defer os.Remove(outputPath)
 
-		err = subject.Pull(outputPath, fakeUI, pullNestedBundles)
+		err = subject.Pull(context.Background(), outputPath, fakeUI, pullNestedBundles)
 		assert.NoError(t, err)
 
 		outputDirImagesYmlFile := filepath.Join(outputPath, ".imgpkg", "bundles", strings.ReplaceAll(icecreamBundle.Digest, "sha256:", "sha256-"), ".imgpkg", "images.yml")
@@ -301,7 +302,7 @@ func TestPullBundleOutputToUser(t *testing.T) {
 		assert.NoError(t, err)
 		defer os.Remove(outputPath)
 
-		err = subject.Pull(outputPath, writerUI, pullNestedBundles)
+		err = subject.Pull(context.Background(), outputPath, writerUI, pullNestedBundles)
 		assert.NoError(t, err)
 
 		assert.Regexp(t,
@@ -328,7 +329,7 @@ One or more images not found in bundle repo; skipping lock file update`, bundleN
 		assert.NoError(t, err)
 		defer os.Remove(outputPath)
 
-		err = subject.Pull(outputPath, writerUI, pullNestedBundles)
+		err = subject.Pull(context.Background(), outputPath, writerUI, pullNestedBundles)
 		assert.NoError(t, err)
 
 		assert.Regexp(t,
@@ -370,7 +371,7 @@ func TestPullAllNestedBundlesOutputToUser(t *testing.T) {
 		assert.NoError(t, err)
 		defer os.Remove(outputPath)
 
-		err = subject.Pull(outputPath, writerUI, pullNestedBundles)
+		err = subject.Pull(context.Background(), outputPath, writerUI, pullNestedBundles)
 		assert.NoError(t, err)
 
 		assert.Regexp(t,
@@ -402,7 +403,7 @@ The bundle repo \(%s\) is hosting every image specified in the bundle's Images L
 		assert.NoError(t, err)
 		defer os.Remove(outputPath)
 
-		err = subject.Pull(outputPath, writerUI, pullNestedBundles)
+		err = subject.Pull(context.Background(), outputPath, writerUI, pullNestedBundles)
 		assert.NoError(t, err)
 
 		icecreamBundleName := fakeRegistry.ReferenceOnTestServer("icecream/bundle")
@@ -446,7 +447,7 @@ One or more images not found in bundle repo; skipping lock file update`, bundleN
 		assert.NoError(t, err)
 		defer os.Remove(outputPath)
 
-		err = subject.Pull(outputPath, writerUI, pullNestedBundles)
+		err = subject.Pull(context.Background(), outputPath, writerUI, pullNestedBundles)
 		assert.NoError(t, err)
 
 		assert.DirExists(t, outputPath)
@@ -490,7 +491,7 @@ One or more images not found in bundle repo; skipping lock file update`, bundleW
 		defer os.Remove(outputPath)
 
 		// test subject
-		err = subject.Pull(outputPath, writerUI, pullNestedBundles)
+		err = subject.Pull(context.Background(), outputPath, writerUI, pullNestedBundles)
 		assert.NoError(t, err)
 
 		//assert log message
@@ -2,6 +2,7 @@
 package bundlefakes
 
 import (
+	"context"
 	"sync"
 
 	"github.com/google/go-containerregistry/pkg/name"
@@ -11,10 +12,11 @@ import (
 )
 
 type FakeImagesMetadataWriter struct {
-	DigestStub        func(name.Reference) (v1.Hash, error)
+	DigestStub        func(context.Context, name.Reference) (v1.Hash, error)
 	digestMutex       sync.RWMutex
 	digestArgsForCall []struct {
-		arg1 name.Reference
+		arg1 context.Context
+		arg2 name.Reference
 	}
 	digestReturns struct {
 		result1 v1.Hash
@@ -24,10 +26,11 @@ type FakeImagesMetadataWriter struct {
 		result1 v1.Hash
 		result2 error
 	}
-	GenericStub        func(name.Reference) (v1.Descriptor, error)
+	GenericStub        func(context.Context, name.Reference) (v1.Descriptor, error)
 	genericMutex       sync.RWMutex
 	genericArgsForCall []struct {
-		arg1 name.Reference
+		arg1 context.Context
+		arg2 name.Reference
 	}
 	genericReturns struct {
 		result1 v1.Descriptor
@@ -37,10 +40,11 @@ type FakeImagesMetadataWriter struct {
 		result1 v1.Descriptor
 		result2 error
 	}
-	GetStub        func(name.Reference) (*remote.Descriptor, error)
+	GetStub        func(context.Context, name.Reference) (*remote.Descriptor, error)
 	getMutex       sync.RWMutex
 	getArgsForCall []struct {
-		arg1 name.Reference
+		arg1 context.Context
+		arg2 name.Reference
 	}
 	getReturns struct {
 		result1 *remote.Descriptor
@@ -50,10 +54,11 @@ type FakeImagesMetadataWriter struct {
 		result1 *remote.Descriptor
 		result2 error
 	}
-	ImageStub        func(name.Reference) (v1.Image, error)
+	ImageStub        func(context.Context, name.Reference) (v1.Image, error)
 	imageMutex       sync.RWMutex
 	imageArgsForCall []struct {
-		arg1 name.Reference
+		arg1 context.Context
+		arg2 name.Reference
 	}
 	imageReturns struct {
 		result1 v1.Image
@@ -63,10 +68,11 @@ type FakeImagesMetadataWriter struct {
 		result1 v1.Image
 		result2 error
 	}
-	IndexStub        func(name.Reference) (v1.ImageIndex, error)
+	IndexStub        func(context.Context, name.Reference) (v1.ImageIndex, error)
 	indexMutex       sync.RWMutex
 	indexArgsForCall []struct {
-		arg1 name.Reference
+		arg1 context.Context
+		arg2 name.Reference
 	}
 	indexReturns struct {
 		result1 v1.ImageIndex
@@ -76,11 +82,12 @@ type FakeImagesMetadataWriter struct {
 		result1 v1.ImageIndex
 		result2 error
 	}
-	WriteImageStub        func(name.Reference, v1.Image) error
+	WriteImageStub        func(context.Context, name.Reference, v1.Image) error
 	writeImageMutex       sync.RWMutex
 	writeImageArgsForCall []struct {
-		arg1 name.Reference
-		arg2 v1.Image
+		arg1 context.Context
+		arg2 name.Reference
+		arg3 v1.Image
 	}
 	writeImageReturns struct {
 		result1 error
@@ -92,18 +99,19 @@ type FakeImagesMetadataWriter struct {
 	invocationsMutex sync.RWMutex
 }
 
-func (fake *FakeImagesMetadataWriter) Digest(arg1 name.Reference) (v1.Hash, error) {
+func (fake *FakeImagesMetadataWriter) Digest(arg1 context.Context, arg2 name.Reference) (v1.Hash, error) {
 	fake.digestMutex.Lock()
 	ret, specificReturn := fake.digestReturnsOnCall[len(fake.digestArgsForCall)]
 	fake.digestArgsForCall = append(fake.digestArgsForCall, struct {
-		arg1 name.Reference
-	}{arg1})
+		arg1 context.Context
+		arg2 name.Reference
+	}{arg1, arg2})
 	stub := fake.DigestStub
 	fakeReturns := fake.digestReturns
-	fake.recordInvocation("Digest", []interface{}{arg1})
+	fake.recordInvocation("Digest", []interface{}{arg1, arg2})
 	fake.digestMutex.Unlock()
 	if stub != nil {
-		return stub(arg1)
+		return stub(arg1, arg2)
 	}
 	if specificReturn {
 		return ret.result1, ret.result2
@@ -117,17 +125,17 @@ func (fake *FakeImagesMetadataWriter) DigestCallCount() int {
 	return len(fake.digestArgsForCall)
 }
 
-func (fake *FakeImagesMetadataWriter) DigestCalls(stub func(name.Reference) (v1.Hash, error)) {
+func (fake *FakeImagesMetadataWriter) DigestCalls(stub func(context.Context, name.Reference) (v1.Hash, error)) {
 	fake.digestMutex.Lock()
 	defer fake.digestMutex.Unlock()
 	fake.DigestStub = stub
 }
 
-func (fake *FakeImagesMetadataWriter) DigestArgsForCall(i int) name.Reference {
+func (fake *FakeImagesMetadataWriter) DigestArgsForCall(i int) (context.Context, name.Reference) {
 	fake.digestMutex.RLock()
 	defer fake.digestMutex.RUnlock()
 	argsForCall := fake.digestArgsForCall[i]
-	return argsForCall.arg1
+	return argsForCall.arg1, argsForCall.arg2
 }
 
 func (fake *FakeImagesMetadataWriter) DigestReturns(result1 v1.Hash, result2 error) {
@@ -156,18 +164,19 @@ func (fake *FakeImagesMetadataWriter) DigestReturnsOnCall(i int, result1 v1.Hash
 	}{result1, result2}
 }
 
-func (fake *FakeImagesMetadataWriter) Generic(arg1 name.Reference) (v1.Descriptor, error) {
+func (fake *FakeImagesMetadataWriter) Generic(arg1 context.Context, arg2 name.Reference) (v1.Descriptor, error) {
 	fake.genericMutex.Lock()
 	ret, specificReturn := fake.genericReturnsOnCall[len(fake.genericArgsForCall)]
 	fake.genericArgsForCall = append(fake.genericArgsForCall, struct {
-		arg1 name.Reference
-	}{arg1})
+		arg1 context.Context
+		arg2 name.Reference
+	}{arg1, arg2})
 	stub := fake.GenericStub
 	fakeReturns := fake.genericReturns
-	fake.recordInvocation("Generic", []interface{}{arg1})
+	fake.recordInvocation("Generic", []interface{}{arg1, arg2})
 	fake.genericMutex.Unlock()
 	if stub != nil {
-		return stub(arg1)
+		return stub(arg1, arg2)
 	}
 	if specificReturn {
 		return ret.result1, ret.result2
@@ -181,17 +190,17 @@ func (fake *FakeImagesMetadataWriter) GenericCallCount() int {
 	return len(fake.genericArgsForCall)
 }
 
-func (fake *FakeImagesMetadataWriter) GenericCalls(stub func(name.Reference) (v1.Descriptor, error)) {
+func (fake *FakeImagesMetadataWriter) GenericCalls(stub func(context.Context, name.Reference) (v1.Descriptor, error)) {
 	fake.genericMutex.Lock()
 	defer fake.genericMutex.Unlock()
 	fake.GenericStub = stub
 }
 
-func (fake *FakeImagesMetadataWriter) GenericArgsForCall(i int) name.Reference {
+func (fake *FakeImagesMetadataWriter) GenericArgsForCall(i int) (context.Context, name.Reference) {
 	fake.genericMutex.RLock()
 	defer fake.genericMutex.RUnlock()
 	argsForCall := fake.genericArgsForCall[i]
-	return argsForCall.arg1
+	return argsForCall.arg1, argsForCall.arg2
 }
 
 func (fake *FakeImagesMetadataWriter) GenericReturns(result1 v1.Descriptor, result2 error) {
@@ -220,18 +229,19 @@ func (fake *FakeImagesMetadataWriter) GenericReturnsOnCall(i int, result1 v1.Des
 	}{result1, result2}
 }
 
-func (fake *FakeImagesMetadataWriter) Get(arg1 name.Reference) (*remote.Descriptor, error) {
+func (fake *FakeImagesMetadataWriter) Get(arg1 context.Context, arg2 name.Reference) (*remote.Descriptor, error) {
 	fake.getMutex.Lock()
 	ret, specificReturn := fake.getReturnsOnCall[len(fake.getArgsForCall)]
 	fake.getArgsForCall = append(fake.getArgsForCall, struct {
-		arg1 name.Reference
-	}{arg1})
+		arg1 context.Context
+		arg2 name.Reference
+	}{arg1, arg2})
 	stub := fake.GetStub
 	fakeReturns := fake.getReturns
-	fake.recordInvocation("Get", []interface{}{arg1})
+	fake.recordInvocation("Get", []interface{}{arg1, arg2})
 	fake.getMutex.Unlock()
 	if stub != nil {
-		return stub(arg1)
+		return stub(arg1, arg2)
 	}
 	if specificReturn {
 		return ret.result1, ret.result2
@@ -245,17 +255,17 @@ func (fake *FakeImagesMetadataWriter) GetCallCount() int {
 	return len(fake.getArgsForCall)
 }
 
-func (fake *FakeImagesMetadataWriter) GetCalls(stub func(name.Reference) (*remote.Descriptor, error)) {
+func (fake *FakeImagesMetadataWriter) GetCalls(stub func(context.Context, name.Reference) (*remote.Descriptor, error)) {
 	fake.getMutex.Lock()
 	defer fake.getMutex.Unlock()
 	fake.GetStub = stub
 }
 
-func (fake *FakeImagesMetadataWriter) GetArgsForCall(i int) name.Reference {
+func (fake *FakeImagesMetadataWriter) GetArgsForCall(i int) (context.Context, name.Reference) {
 	fake.getMutex.RLock()
 	defer fake.getMutex.RUnlock()
 	argsForCall := fake.getArgsForCall[i]
-	return argsForCall.arg1
+	return argsForCall.arg1, argsForCall.arg2
 }
 
 func (fake *FakeImagesMetadataWriter) GetReturns(result1 *remote.Descriptor, result2 error) {
@@ -284,18 +294,19 @@ func (fake *FakeImagesMetadataWriter) GetReturnsOnCall(i int, result1 *remote.De
 	}{result1, result2}
 }
 
-func (fake *FakeImagesMetadataWriter) Image(arg1 name.Reference) (v1.Image, error) {
+func (fake *FakeImagesMetadataWriter) Image(arg1 context.Context, arg2 name.Reference) (v1.Image, error) {
 	fake.imageMutex.Lock()
 	ret, specificReturn := fake.imageReturnsOnCall[len(fake.imageArgsForCall)]
 	fake.imageArgsForCall = append(fake.imageArgsForCall, struct {
-		arg1 name.Reference
-	}{arg1})
+		arg1 context.Context
+		arg2 name.Reference
+	}{arg1, arg2})
 	stub := fake.ImageStub
 	fakeReturns := fake.imageReturns
-	fake.recordInvocation("Image", []interface{}{arg1})
+	fake.recordInvocation("Image", []interface{}{arg1, arg2})
 	fake.imageMutex.Unlock()
 	if stub != nil {
-		return stub(arg1)
+		return stub(arg1, arg2)
 	}
 	if specificReturn {
 		return ret.result1, ret.result2
@@ -309,17 +320,17 @@ func (fake *FakeImagesMetadataWriter) ImageCallCount() int {
 	return len(fake.imageArgsForCall)
 }
 
-func (fake *FakeImagesMetadataWriter) ImageCalls(stub func(name.Reference) (v1.Image, error)) {
+func (fake *FakeImagesMetadataWriter) ImageCalls(stub func(context.Context, name.Reference) (v1.Image, error)) {
 	fake.imageMutex.Lock()
 	defer fake.imageMutex.Unlock()
 	fake.ImageStub = stub
 }
 
-func (fake *FakeImagesMetadataWriter) ImageArgsForCall(i int) name.Reference {
+func (fake *FakeImagesMetadataWriter) ImageArgsForCall(i int) (context.Context, name.Reference) {
 	fake.imageMutex.RLock()
 	defer fake.imageMutex.RUnlock()
 	argsForCall := fake.imageArgsForCall[i]
-	return argsForCall.arg1
+	return argsForCall.arg1, argsForCall.arg2
 }
 
 func (fake *FakeImagesMetadataWriter) ImageReturns(result1 v1.Image, result2 error) {
@@ -348,18 +359,19 @@ func (fake *FakeImagesMetadataWriter) ImageReturnsOnCall(i int, result1 v1.Image
 	}{result1, result2}
 }
 
-func (fake *FakeImagesMetadataWriter) Index(arg1 name.Reference) (v1.ImageIndex, error) {
+func (fake *FakeImagesMetadataWriter) Index(arg1 context.Context, arg2 name.Reference) (v1.ImageIndex, error) {
 	fake.indexMutex.Lock()
 	ret, specificReturn := fake.indexReturnsOnCall[len(fake.indexArgsForCall)]
 	fake.indexArgsForCall = append(fake.indexArgsForCall, struct {
-		arg1 name.Reference
-	}{arg1})
+		arg1 context.Context
+		arg2 name.Reference
+	}{arg1, arg2})
 	stub := fake.IndexStub
 	fakeReturns := fake.indexReturns
-	fake.recordInvocation("Index", []interface{}{arg1})
+	fake.recordInvocation("Index", []interface{}{arg1, arg2})
 	fake.indexMutex.Unlock()
 	if stub != nil {
-		return stub(arg1)
+		return stub(arg1, arg2)
 	}
 	if specificReturn {
 		return ret.result1, ret.result2
@@ -373,17 +385,17 @@ func (fake *FakeImagesMetadataWriter) IndexCallCount() int {
 	return len(fake.indexArgsForCall)
 }
 
-func (fake *FakeImagesMetadataWriter) IndexCalls(stub func(name.Reference) (v1.ImageIndex, error)) {
+func (fake *FakeImagesMetadataWriter) IndexCalls(stub func(context.Context, name.Reference) (v1.ImageIndex, error)) {
 	fake.indexMutex.Lock()
 	defer fake.indexMutex.Unlock()
 	fake.IndexStub = stub
 }
 
-func (fake *FakeImagesMetadataWriter) IndexArgsForCall(i int) name.Reference {
+func (fake *FakeImagesMetadataWriter) IndexArgsForCall(i int) (context.Context, name.Reference) {
 	fake.indexMutex.RLock()
 	defer fake.indexMutex.RUnlock()
 	argsForCall := fake.indexArgsForCall[i]
-	return argsForCall.arg1
+	return argsForCall.arg1, argsForCall.arg2
 }
 
 func (fake *FakeImagesMetadataWriter) IndexReturns(result1 v1.ImageIndex, result2 error) {
@@ -412,19 +424,20 @@ func (fake *FakeImagesMetadataWriter) IndexReturnsOnCall(i int, result1 v1.Image
 	}{result1, result2}
 }
 
-func (fake *FakeImagesMetadataWriter) WriteImage(arg1 name.Reference, arg2 v1.Image) error {
+func (fake *FakeImagesMetadataWriter) WriteImage(arg1 context.Context, arg2 name.Reference, arg3 v1.Image) error {
 	fake.writeImageMutex.Lock()
 	ret, specificReturn := fake.writeImageReturnsOnCall[len(fake.writeImageArgsForCall)]
 	fake.writeImageArgsForCall = append(fake.writeImageArgsForCall, struct {
-		arg1 name.Reference
-		arg2 v1.Image
-	}{arg1, arg2})
+		arg1 context.Context
+		arg2 name.Reference
+		arg3 v1.Image
+	}{arg1, arg2, arg3})
 	stub := fake.WriteImageStub
 	fakeReturns := fake.writeImageReturns
-	fake.recordInvocation("WriteImage", []interface{}{arg1, arg2})
+	fake.recordInvocation("WriteImage", []interface{}{arg1, arg2, arg3})
 	fake.writeImageMutex.Unlock()
 	if stub != nil {
-		return stub(arg1, arg2)
+		return stub(arg1, arg2, arg3)
 	}
 	if specificReturn {
 		return ret.result1
@@ -438,17 +451,17 @@ func (fake *FakeImagesMetadataWriter) WriteImageCallCount() int {
 	return len(fake.writeImageArgsForCall)
 }
 
-func (fake *FakeImagesMetadataWriter) WriteImageCalls(stub func(name.Reference, v1.Image) error) {
+func (fake *FakeImagesMetadataWriter) WriteImageCalls(stub func(context.Context, name.Reference, v1.Image) error) {
 	fake.writeImageMutex.Lock()
 	defer fake.writeImageMutex.Unlock()
 	fake.WriteImageStub = stub
 }
 
-func (fake *FakeImagesMetadataWriter) WriteImageArgsForCall(i int) (name.Reference, v1.Image) {
+func (fake *FakeImagesMetadataWriter) WriteImageArgsForCall(i int) (context.Context, name.Reference, v1.Image) {
 	fake.writeImageMutex.RLock()
 	defer fake.writeImageMutex.RUnlock()
 	argsForCall := fake.writeImageArgsForCall[i]
-	return argsForCall.arg1, argsForCall.arg2
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
 }
 
 func (fake *FakeImagesMetadataWriter) WriteImageReturns(result1 error) {
@@ -0,0 +1,196 @@
+// Copyright 2021 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package bundle
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// ChecksumsFile lists the sha256 of every file extracted from a bundle,
+// it is located in the ImgpkgDir and excluded from its own checksums
+const ChecksumsFile = "checksums.txt"
+
+// ChecksumsDiff describes how the files of a directory differ from its checksums
+type ChecksumsDiff struct {
+	Added   []string
+	Removed []string
+	Changed []string
+}
+
+// Empty returns true when the files match their checksums
+func (d ChecksumsDiff) Empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Changed) == 0
+}
+
+// WriteChecksums records the sha256 of every file in dir (sorted by path)
+// into .imgpkg/checksums.txt, using the format of sha256sum
+func WriteChecksums(ctx context.Context, dir string) error {
+	checksums, err := computeChecksums(ctx, dir)
+	if err != nil {
+		return err
+	}
+
+	var paths []string
+	for path := range checksums {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	var contents strings.Builder
+	for _, path := range paths {
+		fmt.Fprintf(&contents, "%s  %s\n", checksums[path], path)
+	}
+
+	err = os.MkdirAll(filepath.Join(dir, ImgpkgDir), 0700)
+	if err != nil {
+		return err
+	}
+
+	err = ioutil.WriteFile(checksumsPath(dir), []byte(contents.String()), 0600)
+	if err != nil {
+		return fmt.Errorf("Writing checksums: %s", err)
+	}
+
+	return nil
+}
+
+// VerifyChecksums computes the checksums of the files in dir again
+// and compares them with the ones recorded by WriteChecksums,
+// it stops once ctx is done
+func VerifyChecksums(ctx context.Context, dir string) (ChecksumsDiff, error) {
+	recorded, err := readChecksums(checksumsPath(dir))
+	if err != nil {
+		return ChecksumsDiff{}, err
+	}
+
+	current, err := computeChecksums(ctx, dir)
+	if err != nil {
+		return ChecksumsDiff{}, err
+	}
+
+	diff := ChecksumsDiff{}
+	for path, checksum := range current {
+		recordedChecksum, found := recorded[path]
+		switch {
+		case !found:
+			diff.Added = append(diff.Added, path)
+		case recordedChecksum != checksum:
+			diff.Changed = append(diff.Changed, path)
+		}
+	}
+	for path := range recorded {
+		if _, found := current[path]; !found {
+			diff.Removed = append(diff.Removed, path)
+		}
+	}
+
+	sort.Strings(diff.Added)
+	sort.Strings(diff.Removed)
+	sort.Strings(diff.Changed)
+
+	return diff, nil
+}
+
+func checksumsPath(dir string) string {
+	return filepath.Join(dir, ImgpkgDir, ChecksumsFile)
+}
+
+// computeChecksums returns the sha256 of every regular file in dir keyed
+// by its slash separated path relative to dir, except the checksums file
+func computeChecksums(ctx context.Context, dir string) (map[string]string, error) {
+	checksums := map[string]string{}
+	excludedPath := filepath.ToSlash(filepath.Join(ImgpkgDir, ChecksumsFile))
+
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+
+		relPath, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		relPath = filepath.ToSlash(relPath)
+		if relPath == excludedPath {
+			return nil
+		}
+
+		checksum, err := fileChecksum(path)
+		if err != nil {
+			return err
+		}
+		checksums[relPath] = checksum
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("Computing checksums: %w", err)
+	}
+
+	return checksums, nil
+}
+
+func fileChecksum(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	_, err = io.Copy(hash, file)
+	if err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+func readChecksums(path string) (map[string]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("Expected to find checksums in '%s' (hint: Use --output-checksums when pulling)", path)
+		}
+		return nil, fmt.Errorf("Reading checksums: %s", err)
+	}
+	defer file.Close()
+
+	checksums := map[string]string{}
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			continue
+		}
+
+		parts := strings.SplitN(line, "  ", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("Expected checksums line to be '<sha256>  <path>', got '%s'", line)
+		}
+		checksums[parts[1]] = parts[0]
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("Reading checksums: %s", err)
+	}
+
+	return checksums, nil
+}
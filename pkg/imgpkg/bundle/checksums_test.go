@@ -0,0 +1,84 @@
+// Copyright 2021 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package bundle_test
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/k14s/imgpkg/pkg/imgpkg/bundle"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChecksums(t *testing.T) {
+	createDir := func(t *testing.T) string {
+		dir := t.TempDir()
+		for path, contents := range map[string]string{
+			"config.yml":         "key: value\n",
+			"nested/b.yml":       "b: value\n",
+			"nested/a.yml":       "a: value\n",
+			".imgpkg/images.yml": "images: []\n",
+		} {
+			require.NoError(t, os.MkdirAll(filepath.Dir(filepath.Join(dir, path)), 0700))
+			require.NoError(t, ioutil.WriteFile(filepath.Join(dir, path), []byte(contents), 0600))
+		}
+		return dir
+	}
+
+	t.Run("writes the checksum of every file sorted by path, except the checksums file", func(t *testing.T) {
+		dir := createDir(t)
+		require.NoError(t, bundle.WriteChecksums(context.Background(), dir))
+
+		contents, err := ioutil.ReadFile(filepath.Join(dir, ".imgpkg", "checksums.txt"))
+		require.NoError(t, err)
+		assert.Equal(t, `a0dc6ad63a88a0fc3f9c0c65a0c72b6099818b40d1623b32e9977381c1f0bc88  .imgpkg/images.yml
+0ddd3d77338ca222ab064e214bbec3a4547e9d33801912eaacc7b4b4e27e1a91  config.yml
+a47d07033ed5af03a3e4f8523bb94872bb92482e5ec91f5228b42ff272807948  nested/a.yml
+92f1f05426ab58dbe1c940be5337e602c377294694569d3ef20e636365092992  nested/b.yml
+`, string(contents))
+
+		diff, err := bundle.VerifyChecksums(context.Background(), dir)
+		require.NoError(t, err)
+		assert.True(t, diff.Empty(), "expected no differences, got: %#v", diff)
+	})
+
+	t.Run("reports added, removed and changed files", func(t *testing.T) {
+		dir := createDir(t)
+		require.NoError(t, bundle.WriteChecksums(context.Background(), dir))
+
+		require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "config.yml"), []byte("key: tampered\n"), 0600))
+		require.NoError(t, os.Remove(filepath.Join(dir, "nested", "a.yml")))
+		require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "nested", "c.yml"), []byte("c: value\n"), 0600))
+
+		diff, err := bundle.VerifyChecksums(context.Background(), dir)
+		require.NoError(t, err)
+		assert.False(t, diff.Empty())
+		assert.Equal(t, []string{"nested/c.yml"}, diff.Added)
+		assert.Equal(t, []string{"nested/a.yml"}, diff.Removed)
+		assert.Equal(t, []string{"config.yml"}, diff.Changed)
+	})
+
+	t.Run("when checksums were not written, it suggests how to write them", func(t *testing.T) {
+		_, err := bundle.VerifyChecksums(context.Background(), createDir(t))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "hint: Use --output-checksums when pulling")
+	})
+
+	t.Run("when the context is cancelled, it stops computing checksums", func(t *testing.T) {
+		dir := createDir(t)
+		require.NoError(t, bundle.WriteChecksums(context.Background(), dir))
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		_, err := bundle.VerifyChecksums(ctx, dir)
+		require.Error(t, err)
+		assert.True(t, errors.Is(err, context.Canceled), "expected context cancelled error, got: %s", err)
+	})
+}
@@ -4,6 +4,7 @@
 package bundle
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -13,6 +14,7 @@ import (
 	regname "github.com/google/go-containerregistry/pkg/name"
 	regv1 "github.com/google/go-containerregistry/pkg/v1"
 	ctlimg "github.com/k14s/imgpkg/pkg/imgpkg/image"
+	"github.com/k14s/imgpkg/pkg/imgpkg/lockconfig"
 	"github.com/k14s/imgpkg/pkg/imgpkg/plainimage"
 )
 
@@ -25,26 +27,79 @@ const (
 type Contents struct {
 	paths         []string
 	excludedPaths []string
+	compression   ctlimg.Compression
+	limits        plainimage.ContentsLimits
 }
 
 //go:generate go run github.com/maxbrunsfeld/counterfeiter/v6 . ImagesMetadataWriter
 type ImagesMetadataWriter interface {
 	ctlimg.ImagesMetadata
-	WriteImage(regname.Reference, regv1.Image) error
+	WriteImage(context.Context, regname.Reference, regv1.Image) error
 }
 
-func NewContents(paths []string, excludedPaths []string) Contents {
-	return Contents{paths: paths, excludedPaths: excludedPaths}
+func NewContents(paths []string, excludedPaths []string, compression ctlimg.Compression) Contents {
+	return Contents{paths: paths, excludedPaths: excludedPaths, compression: compression}
 }
 
-func (b Contents) Push(uploadRef regname.Tag, registry ImagesMetadataWriter, ui ui.UI) (string, error) {
+// WithLimits aborts the push before the bundle is built when
+// the files found in paths exceed any of the limits
+func (b Contents) WithLimits(limits plainimage.ContentsLimits) Contents {
+	b.limits = limits
+	return b
+}
+
+func (b Contents) Push(ctx context.Context, uploadRef regname.Tag, registry ImagesMetadataWriter, ui ui.UI) (string, error) {
 	err := b.validate()
 	if err != nil {
 		return "", err
 	}
 
 	labels := map[string]string{BundleConfigLabel: "true"}
-	return plainimage.NewContents(b.paths, b.excludedPaths).Push(uploadRef, labels, registry, ui)
+	return plainimage.NewContents(b.paths, b.excludedPaths, b.compression).WithLimits(b.limits).Push(ctx, uploadRef, labels, registry, ui)
+}
+
+// ValidateImagesExist checks that every image referenced in the bundle's
+// Images Lock file can be found in its registry without fetching the image
+func (b Contents) ValidateImagesExist(ctx context.Context, registry ctlimg.ImagesMetadata) error {
+	imagesLock, err := b.ImagesLock()
+	if err != nil {
+		return err
+	}
+
+	var missingImages []string
+	for _, imgRef := range imagesLock.Images {
+		ref, err := regname.NewDigest(imgRef.Image)
+		if err != nil {
+			return err
+		}
+
+		_, err = registry.Digest(ctx, ref)
+		if err != nil {
+			missingImages = append(missingImages, fmt.Sprintf("- %s (%s)", imgRef.Image, err))
+		}
+	}
+
+	if len(missingImages) > 0 {
+		return fmt.Errorf("Expected every image referenced in '%s' to exist, but unable to find:\n%s",
+			filepath.Join(ImgpkgDir, ImagesLockFile), strings.Join(missingImages, "\n"))
+	}
+
+	return nil
+}
+
+// ImagesLock returns the bundle's Images Lock file (.imgpkg/images.yml)
+func (b Contents) ImagesLock() (lockconfig.ImagesLock, error) {
+	imgpkgDirs, err := b.findImgpkgDirs()
+	if err != nil {
+		return lockconfig.ImagesLock{}, err
+	}
+
+	err = b.validateImgpkgDirs(imgpkgDirs)
+	if err != nil {
+		return lockconfig.ImagesLock{}, err
+	}
+
+	return lockconfig.NewImagesLockFromPath(filepath.Join(imgpkgDirs[0], ImagesLockFile))
 }
 
 func (b Contents) PresentsAsBundle() (bool, error) {
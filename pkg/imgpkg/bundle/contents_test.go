@@ -4,6 +4,7 @@
 package bundle_test
 
 import (
+	"context"
 	"testing"
 
 	"github.com/google/go-containerregistry/pkg/name"
@@ -11,6 +12,7 @@ import (
 	"github.com/google/go-containerregistry/pkg/v1/fake"
 	"github.com/k14s/imgpkg/pkg/imgpkg/bundle"
 	"github.com/k14s/imgpkg/pkg/imgpkg/bundle/bundlefakes"
+	ctlimg "github.com/k14s/imgpkg/pkg/imgpkg/image"
 	"github.com/k14s/imgpkg/test/helpers"
 )
 
@@ -38,13 +40,13 @@ images:
 	fakeRegistry.ImageReturns(bundleImg, nil)
 
 	t.Run("push is successful", func(t *testing.T) {
-		subject := bundle.NewContents([]string{bundleDir}, nil)
+		subject := bundle.NewContents([]string{bundleDir}, nil, ctlimg.CompressionGzip)
 		imgTag, err := name.NewTag("my.registry.io/new-bundle:tag")
 		if err != nil {
 			t.Fatalf("failed to read tag: %s", err)
 		}
 
-		_, err = subject.Push(imgTag, fakeRegistry, fakeUI)
+		_, err = subject.Push(context.Background(), imgTag, fakeRegistry, fakeUI)
 		if err != nil {
 			t.Fatalf("not expecting push to fail: %s", err)
 		}
@@ -73,13 +75,13 @@ images:
 	fakeRegistry.ImageReturns(bundleImg, nil)
 
 	t.Run("push is successful", func(t *testing.T) {
-		subject := bundle.NewContents([]string{bundleDir}, nil)
+		subject := bundle.NewContents([]string{bundleDir}, nil, ctlimg.CompressionGzip)
 		imgTag, err := name.NewTag("my.registry.io/new-bundle:tag")
 		if err != nil {
 			t.Fatalf("failed to read tag: %s", err)
 		}
 
-		_, err = subject.Push(imgTag, fakeRegistry, fakeUI)
+		_, err = subject.Push(context.Background(), imgTag, fakeRegistry, fakeUI)
 		if err != nil {
 			t.Fatalf("not expecting push to fail: %s", err)
 		}
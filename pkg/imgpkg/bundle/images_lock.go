@@ -4,6 +4,7 @@
 package bundle
 
 import (
+	"context"
 	"fmt"
 	"strings"
 	"sync"
@@ -55,8 +56,27 @@ func (o *ImagesLock) AddImageRef(ref lockconfig.ImageRef) {
 	o.imagesLock.AddImageRef(ref)
 }
 
+func (o *ImagesLock) removeImageRefs(imgErrs []ImageError) {
+	if len(imgErrs) == 0 {
+		return
+	}
+
+	toRemove := map[string]struct{}{}
+	for _, imgErr := range imgErrs {
+		toRemove[imgErr.Image] = struct{}{}
+	}
+
+	var imageRefs []lockconfig.ImageRef
+	for _, imgRef := range o.imagesLock.Images {
+		if _, found := toRemove[imgRef.Image]; !found {
+			imageRefs = append(imageRefs, imgRef)
+		}
+	}
+	o.imagesLock.Images = imageRefs
+}
+
 // TODO: we should use LocationPrunedImageRefs as part of this function
-func (o *ImagesLock) LocalizeImagesLock() (lockconfig.ImagesLock, bool, error) {
+func (o *ImagesLock) LocalizeImagesLock(ctx context.Context) (lockconfig.ImagesLock, bool, error) {
 	var imageRefs []lockconfig.ImageRef
 	imagesLock := lockconfig.ImagesLock{
 		LockVersion: o.imagesLock.LockVersion,
@@ -68,7 +88,7 @@ func (o *ImagesLock) LocalizeImagesLock() (lockconfig.ImagesLock, bool, error) {
 			return o.imagesLock, false, err
 		}
 
-		foundImg, err := o.checkImagesExist([]string{imageInBundleRepo, imgRef.Image})
+		foundImg, err := o.checkImagesExist(ctx, []string{imageInBundleRepo, imgRef.Image})
 		if err != nil {
 			return o.imagesLock, false, err
 		}
@@ -82,6 +102,8 @@ func (o *ImagesLock) LocalizeImagesLock() (lockconfig.ImagesLock, bool, error) {
 
 		imageRefs = append(imageRefs, lockconfig.ImageRef{
 			Image:       foundImg,
+			Name:        imgRef.Name,
+			Tag:         imgRef.Tag,
 			Annotations: imgRef.Annotations,
 		})
 	}
@@ -90,7 +112,7 @@ func (o *ImagesLock) LocalizeImagesLock() (lockconfig.ImagesLock, bool, error) {
 	return imagesLock, false, nil
 }
 
-func (o *ImagesLock) LocationPrunedImageRefs(concurrency int) ([]lockconfig.ImageRef, error) {
+func (o *ImagesLock) LocationPrunedImageRefs(ctx context.Context, concurrency int) ([]lockconfig.ImageRef, error) {
 	var imageRefs []lockconfig.ImageRef
 
 	errChan := make(chan error, len(o.ImageRefs()))
@@ -103,7 +125,7 @@ func (o *ImagesLock) LocationPrunedImageRefs(concurrency int) ([]lockconfig.Imag
 			throttle.Take()
 			defer throttle.Done()
 
-			foundImg, err := o.checkImagesExist(newImgRef.Locations())
+			foundImg, err := o.checkImagesExist(ctx, newImgRef.Locations())
 			if err != nil {
 				errChan <- err
 				return
@@ -129,14 +151,14 @@ func (o *ImagesLock) LocationPrunedImageRefs(concurrency int) ([]lockconfig.Imag
 	return imageRefs, nil
 }
 
-func (o *ImagesLock) checkImagesExist(urls []string) (string, error) {
+func (o *ImagesLock) checkImagesExist(ctx context.Context, urls []string) (string, error) {
 	var err error
 	for _, img := range urls {
 		ref, parseErr := regname.NewDigest(img)
 		if parseErr != nil {
 			return "", parseErr
 		}
-		_, err = o.imgRetriever.Digest(ref)
+		_, err = o.imgRetriever.Digest(ctx, ref)
 		if err == nil {
 			return img, nil
 		}
@@ -4,6 +4,7 @@
 package bundle_test
 
 import (
+	"context"
 	"errors"
 	"os"
 	"testing"
@@ -35,7 +36,7 @@ func TestImagesLock_LocalizeImagesLock(t *testing.T) {
 		fakeImagesMetadata := &imagefakes.FakeImagesMetadata{}
 		subject := ctlbundle.NewImagesLock(imagesLock, fakeImagesMetadata, "some.repo.io/bundle")
 
-		newImagesLock, skipped, err := subject.LocalizeImagesLock()
+		newImagesLock, skipped, err := subject.LocalizeImagesLock(context.Background())
 		require.NoError(t, err)
 		assert.False(t, skipped)
 
@@ -44,6 +45,31 @@ func TestImagesLock_LocalizeImagesLock(t *testing.T) {
 		assert.Equal(t, "some.repo.io/bundle@sha256:45f3926bca9fc42adb650fef2a41250d77841dde49afc8adc7c0c633b3d5f27a", newImagesLock.Images[1].Locations()[0])
 	})
 
+	t.Run("When images are localized, it keeps their name, tag and annotations", func(t *testing.T) {
+		imagesLock := lockconfig.ImagesLock{
+			Images: []lockconfig.ImageRef{
+				{
+					Image:       "some.repo.io/img1@sha256:27fde5fa39e3c97cb1e5dabfb664784b605a592d5d2df5482d744742efebba80",
+					Name:        "img1",
+					Tag:         "v1",
+					Annotations: map[string]string{"some.annotation": "value"},
+				},
+			},
+		}
+		fakeImagesMetadata := &imagefakes.FakeImagesMetadata{}
+		subject := ctlbundle.NewImagesLock(imagesLock, fakeImagesMetadata, "some.repo.io/bundle")
+
+		newImagesLock, skipped, err := subject.LocalizeImagesLock(context.Background())
+		require.NoError(t, err)
+		assert.False(t, skipped)
+
+		require.Len(t, newImagesLock.Images, 1)
+		assert.Equal(t, "some.repo.io/bundle@sha256:27fde5fa39e3c97cb1e5dabfb664784b605a592d5d2df5482d744742efebba80", newImagesLock.Images[0].Image)
+		assert.Equal(t, "img1", newImagesLock.Images[0].Name)
+		assert.Equal(t, "v1", newImagesLock.Images[0].Tag)
+		assert.Equal(t, map[string]string{"some.annotation": "value"}, newImagesLock.Images[0].Annotations)
+	})
+
 	t.Run("When one image cannot be found in the bundle repository, it returns the old image location and skipped == true", func(t *testing.T) {
 		imagesLock := lockconfig.ImagesLock{
 			Images: []lockconfig.ImageRef{
@@ -61,7 +87,7 @@ func TestImagesLock_LocalizeImagesLock(t *testing.T) {
 		// Other calls will return the default empty Hash and nil error
 		fakeImagesMetadata.DigestReturnsOnCall(1, regv1.Hash{}, errors.New("not found"))
 
-		newImagesLock, skipped, err := subject.LocalizeImagesLock()
+		newImagesLock, skipped, err := subject.LocalizeImagesLock(context.Background())
 		require.NoError(t, err)
 		assert.True(t, skipped)
 
@@ -89,7 +115,7 @@ func TestImagesLock_LocationPrunedImagesLock(t *testing.T) {
 		fakeImagesMetadata.DigestReturnsOnCall(0, regv1.Hash{}, errors.New("not found"))
 
 		subject := ctlbundle.NewImagesLock(imagesLock, fakeImagesMetadata, "some.repo.io/bundle")
-		imgRefs, err := subject.LocationPrunedImageRefs(5)
+		imgRefs, err := subject.LocationPrunedImageRefs(context.Background(), 5)
 		require.NoError(t, err)
 		assert.Equal(t, "second.repo.io/img1@sha256:27fde5fa39e3c97cb1e5dabfb664784b605a592d5d2df5482d744742efebba80", imgRefs[0].PrimaryLocation())
 	})
@@ -108,7 +134,7 @@ func TestImagesLock_LocationPrunedImagesLock(t *testing.T) {
 		fakeImagesMetadata := &imagefakes.FakeImagesMetadata{}
 
 		subject := ctlbundle.NewImagesLock(imagesLock, fakeImagesMetadata, "some.repo.io/bundle")
-		imgRefs, err := subject.LocationPrunedImageRefs(1)
+		imgRefs, err := subject.LocationPrunedImageRefs(context.Background(), 1)
 		require.NoError(t, err)
 		assert.Equal(t, "first.repo.io/img1@sha256:27fde5fa39e3c97cb1e5dabfb664784b605a592d5d2df5482d744742efebba80", imgRefs[0].PrimaryLocation())
 	})
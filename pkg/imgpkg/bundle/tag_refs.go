@@ -0,0 +1,116 @@
+// Copyright 2021 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package bundle
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+
+	regname "github.com/google/go-containerregistry/pkg/name"
+	ctlimg "github.com/k14s/imgpkg/pkg/imgpkg/image"
+	"github.com/k14s/imgpkg/pkg/imgpkg/lockconfig"
+)
+
+// ResolvedImagesLock returns the bundle's Images Lock file (.imgpkg/images.yml)
+// with refs that use tags replaced by the digest refs they currently point to.
+// It also returns true when any ref used a tag, which means that the bundle
+// needs to be rewritten (see ImageWithImagesLock) to be pinned to digests.
+func (o *Bundle) ResolvedImagesLock(ctx context.Context) (lockconfig.ImagesLock, bool, error) {
+	img, err := o.checkedImage(ctx)
+	if err != nil {
+		return lockconfig.ImagesLock{}, false, err
+	}
+
+	imagesLock, err := o.imagesLockReader.Read(img)
+	if err != nil {
+		return lockconfig.ImagesLock{}, false, err
+	}
+
+	if len(imagesLockTagRefs(imagesLock)) == 0 {
+		return imagesLock, false, nil
+	}
+
+	imagesLock, _, err = o.resolveTagRefs(ctx, imagesLock, true)
+	if err != nil {
+		return lockconfig.ImagesLock{}, false, err
+	}
+
+	return imagesLock, true, nil
+}
+
+// ImageWithImagesLock returns a copy of the bundle image whose Images Lock file
+// is replaced by imagesLock. Everything else (config, annotations, layer compression
+// and file metadata) is kept as is.
+// The returned image must be removed once it is not needed anymore.
+func (o *Bundle) ImageWithImagesLock(ctx context.Context, imagesLock lockconfig.ImagesLock) (*ctlimg.FileImage, error) {
+	img, err := o.checkedImage(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	imagesLockBytes, err := imagesLock.AsBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	replacedImg, err := ctlimg.NewReplacedFileImage(img, filepath.Join(ImgpkgDir, ImagesLockFile), imagesLockBytes)
+	if err != nil {
+		return nil, fmt.Errorf("Rewriting image lock file: %s", err)
+	}
+
+	return replacedImg, nil
+}
+
+// resolveTagRefs replaces refs that use tags by the digest refs they currently
+// point to, keeping the tag as a hint. Tags that cannot be resolved are
+// left out of the returned lock and reported, unless failFast is set.
+func (o *Bundle) resolveTagRefs(ctx context.Context, imagesLock lockconfig.ImagesLock, failFast bool) (lockconfig.ImagesLock, []ImageError, error) {
+	var imgErrs []ImageError
+	var imageRefs []lockconfig.ImageRef
+
+	for _, imageRef := range imagesLock.Images {
+		parsedRef, err := regname.ParseReference(imageRef.Image, regname.WeakValidation)
+		if err != nil {
+			return lockconfig.ImagesLock{}, nil, err
+		}
+
+		tagRef, isTag := parsedRef.(regname.Tag)
+		if !isTag {
+			imageRefs = append(imageRefs, imageRef)
+			continue
+		}
+
+		digest, err := o.imgRetriever.Digest(ctx, tagRef)
+		if err != nil {
+			if failFast {
+				return lockconfig.ImagesLock{}, nil, fmt.Errorf("Resolving tag '%s' to a digest: %s", imageRef.Image, err)
+			}
+			imgErrs = append(imgErrs, ImageError{Image: imageRef.Image, Err: err})
+			continue
+		}
+
+		resolvedRef := imageRef.DeepCopy()
+		resolvedRef.Image = tagRef.Context().Digest(digest.String()).Name()
+		if resolvedRef.Tag == "" {
+			resolvedRef.Tag = tagRef.TagStr()
+		}
+		imageRefs = append(imageRefs, resolvedRef)
+	}
+
+	imagesLock.Images = imageRefs
+
+	return imagesLock, imgErrs, nil
+}
+
+// imagesLockTagRefs returns (quoted) the refs of imagesLock that do not use digests
+func imagesLockTagRefs(imagesLock lockconfig.ImagesLock) []string {
+	var tagRefs []string
+	for _, imageRef := range imagesLock.Images {
+		if _, err := regname.NewDigest(imageRef.Image); err != nil {
+			tagRefs = append(tagRefs, fmt.Sprintf("'%s'", imageRef.Image))
+		}
+	}
+	return tagRefs
+}
@@ -3,6 +3,12 @@
 
 package bundle
 
+import (
+	"context"
+
+	"github.com/k14s/imgpkg/pkg/imgpkg/imagedesc"
+)
+
 type notABundleError struct {
 }
 
@@ -18,8 +24,11 @@ func IsNotBundleError(err error) bool {
 	return ok
 }
 
-func (o *Bundle) IsBundle() (bool, error) {
-	img, err := o.plainImg.Fetch()
+// IsBundle returns true when the image has the bundle label in its config
+// or the bundle annotation in its manifest (either the configured or the legacy one).
+// Image indexes are never bundles.
+func (o *Bundle) IsBundle(ctx context.Context) (bool, error) {
+	img, err := o.plainImg.Fetch(ctx)
 	if err != nil {
 		return false, err
 	}
@@ -28,10 +37,31 @@ func (o *Bundle) IsBundle() (bool, error) {
 		return false, nil
 	}
 
+	manifest, err := img.Manifest()
+	if err != nil {
+		return false, err
+	}
+	if hasBundleConfigLabel(manifest.Annotations) {
+		return true, nil
+	}
+
+	// Configs of OCI artifacts (e.g. Helm charts) cannot have labels
+	if !imagedesc.IsImageConfig(manifest.Config.MediaType) {
+		return false, nil
+	}
+
 	cfg, err := img.ConfigFile()
 	if err != nil {
 		return false, err
 	}
-	_, present := cfg.Config.Labels[BundleConfigLabel]
-	return present, nil
+	return hasBundleConfigLabel(cfg.Config.Labels), nil
+}
+
+func hasBundleConfigLabel(labels map[string]string) bool {
+	for _, label := range bundleConfigLabels() {
+		if _, present := labels[label]; present {
+			return true
+		}
+	}
+	return false
 }
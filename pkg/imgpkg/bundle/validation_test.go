@@ -0,0 +1,125 @@
+// Copyright 2021 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package bundle_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+
+	regv1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/k14s/imgpkg/pkg/imgpkg/bundle"
+	"github.com/k14s/imgpkg/test/helpers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsBundle(t *testing.T) {
+	fakeRegistry := helpers.NewFakeRegistry(t)
+	defer fakeRegistry.CleanUp()
+
+	randomImg, err := random.Image(500, 1)
+	require.NoError(t, err)
+
+	image := fakeRegistry.WithImage("library/image", randomImg)
+	labeledBundle := fakeRegistry.WithBundleFromPath("library/bundle", "test_assets/bundle")
+	artifact := fakeRegistry.WithArtifact("library/chart", "application/vnd.cncf.helm.config.v1+json", "application/vnd.cncf.helm.chart.content.v1.tar+gzip")
+	annotatedBundle := fakeRegistry.WithImage("library/annotated-bundle", newAnnotatedImage(t, randomImg, map[string]string{bundle.BundleConfigLabel: "true"}))
+	legacyLabeledBundle := fakeRegistry.WithImageFromPath("library/legacy-bundle", "test_assets/bundle", map[string]string{bundle.LegacyBundleConfigLabel: "true"})
+	legacyAnnotatedBundle := fakeRegistry.WithImage("library/legacy-annotated-bundle", newAnnotatedImage(t, randomImg, map[string]string{bundle.LegacyBundleConfigLabel: "true"}))
+	customLabeledBundle := fakeRegistry.WithImageFromPath("library/custom-bundle", "test_assets/bundle", map[string]string{"com.example.bundle": "true"})
+	reg := fakeRegistry.Build()
+
+	t.Run("when the image config has the bundle label, it is a bundle", func(t *testing.T) {
+		isBundle, err := bundle.NewBundle(labeledBundle.RefDigest, reg).IsBundle(context.Background())
+		require.NoError(t, err)
+		assert.True(t, isBundle)
+	})
+
+	t.Run("when the image manifest has the bundle annotation, it is a bundle", func(t *testing.T) {
+		isBundle, err := bundle.NewBundle(annotatedBundle.RefDigest, reg).IsBundle(context.Background())
+		require.NoError(t, err)
+		assert.True(t, isBundle)
+	})
+
+	t.Run("when the image config has the legacy bundle label, it is a bundle", func(t *testing.T) {
+		isBundle, err := bundle.NewBundle(legacyLabeledBundle.RefDigest, reg).IsBundle(context.Background())
+		require.NoError(t, err)
+		assert.True(t, isBundle)
+	})
+
+	t.Run("when the image manifest has the legacy bundle annotation, it is a bundle", func(t *testing.T) {
+		isBundle, err := bundle.NewBundle(legacyAnnotatedBundle.RefDigest, reg).IsBundle(context.Background())
+		require.NoError(t, err)
+		assert.True(t, isBundle)
+	})
+
+	t.Run("when the bundle label is overridden", func(t *testing.T) {
+		defer func() { bundle.BundleConfigLabel = bundle.DefaultBundleConfigLabel }()
+		bundle.BundleConfigLabel = "com.example.bundle"
+
+		t.Run("images with the overridden label are bundles", func(t *testing.T) {
+			isBundle, err := bundle.NewBundle(customLabeledBundle.RefDigest, reg).IsBundle(context.Background())
+			require.NoError(t, err)
+			assert.True(t, isBundle)
+		})
+
+		t.Run("images with the default or legacy label are still bundles", func(t *testing.T) {
+			for _, ref := range []string{labeledBundle.RefDigest, legacyLabeledBundle.RefDigest} {
+				isBundle, err := bundle.NewBundle(ref, reg).IsBundle(context.Background())
+				require.NoError(t, err)
+				assert.True(t, isBundle, "expected %s to be a bundle", ref)
+			}
+		})
+	})
+
+	t.Run("when the image has a label that is not the configured one, it is not a bundle", func(t *testing.T) {
+		isBundle, err := bundle.NewBundle(customLabeledBundle.RefDigest, reg).IsBundle(context.Background())
+		require.NoError(t, err)
+		assert.False(t, isBundle)
+	})
+
+	t.Run("when the image has neither, it is not a bundle", func(t *testing.T) {
+		isBundle, err := bundle.NewBundle(image.RefDigest, reg).IsBundle(context.Background())
+		require.NoError(t, err)
+		assert.False(t, isBundle)
+	})
+
+	t.Run("when the image is an OCI artifact, it is not a bundle", func(t *testing.T) {
+		isBundle, err := bundle.NewBundle(artifact.RefDigest, reg).IsBundle(context.Background())
+		require.NoError(t, err)
+		assert.False(t, isBundle)
+	})
+}
+
+// annotatedImage adds annotations to the manifest of an image
+type annotatedImage struct {
+	regv1.Image
+	manifest    *regv1.Manifest
+	rawManifest []byte
+}
+
+func newAnnotatedImage(t *testing.T, img regv1.Image, annotations map[string]string) regv1.Image {
+	manifest, err := img.Manifest()
+	require.NoError(t, err)
+
+	manifest = manifest.DeepCopy()
+	manifest.Annotations = annotations
+
+	rawManifest, err := json.Marshal(manifest)
+	require.NoError(t, err)
+
+	return annotatedImage{Image: img, manifest: manifest, rawManifest: rawManifest}
+}
+
+func (i annotatedImage) Manifest() (*regv1.Manifest, error) { return i.manifest, nil }
+
+func (i annotatedImage) RawManifest() ([]byte, error) { return i.rawManifest, nil }
+
+func (i annotatedImage) Digest() (regv1.Hash, error) {
+	hash, _, err := regv1.SHA256(bytes.NewReader(i.rawManifest))
+	return hash, err
+}
@@ -4,43 +4,47 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
 	"os"
 
+	"github.com/cppforlife/go-cli-ui/ui"
 	regname "github.com/google/go-containerregistry/pkg/name"
-	regv1 "github.com/google/go-containerregistry/pkg/v1"
-	"github.com/google/go-containerregistry/pkg/v1/types"
-	"github.com/k14s/imgpkg/pkg/imgpkg/bundle"
 	ctlimg "github.com/k14s/imgpkg/pkg/imgpkg/image"
-	ctlimgset "github.com/k14s/imgpkg/pkg/imgpkg/imageset"
 	"github.com/k14s/imgpkg/pkg/imgpkg/lockconfig"
-	"github.com/k14s/imgpkg/pkg/imgpkg/plainimage"
 	"github.com/k14s/imgpkg/pkg/imgpkg/registry"
+	v1 "github.com/k14s/imgpkg/pkg/imgpkg/v1"
 	"github.com/spf13/cobra"
 )
 
 type CopyOptions struct {
-	ImageFlags      ImageFlags
-	BundleFlags     BundleFlags
-	LockInputFlags  LockInputFlags
-	LockOutputFlags LockOutputFlags
-	TarFlags        TarFlags
-	RegistryFlags   RegistryFlags
-
-	RepoDst                 string
+	ImageFlags        ImageFlags
+	BundleFlags       BundleFlags
+	LockInputFlags    LockInputFlags
+	LockOutputFlags   LockOutputFlags
+	TarFlags          TarFlags
+	RegistryFlags     RegistryFlags
+	OutputFormatFlags OutputFormatFlags
+
+	RepoDsts                []string
 	Concurrency             int
 	IncludeNonDistributable bool
+	PreserveTags            bool
+	FailFast                bool
+
+	ui       ui.UI
+	logFlags *LogFlags
 }
 
-func NewCopyOptions() *CopyOptions {
-	return &CopyOptions{}
+func NewCopyOptions(ui ui.UI, logFlags *LogFlags) *CopyOptions {
+	return &CopyOptions{ui: ui, logFlags: logFlags}
 }
 
 func NewCopyCmd(o *CopyOptions) *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "copy",
 		Short: "Copy a bundle from one location to another",
-		RunE:  func(_ *cobra.Command, _ []string) error { return o.Run() },
+		RunE:  func(cmd *cobra.Command, _ []string) error { return o.RunContext(cmd.Context()) },
 		Example: `
     # Copy bundle dkalinin/app1-bundle to local tarball at /Volumes/app1-bundle.tar
     imgpkg copy -b dkalinin/app1-bundle --to-tar /Volumes/app1-bundle.tar
@@ -49,7 +53,16 @@ func NewCopyCmd(o *CopyOptions) *cobra.Command {
     imgpkg copy -b dkalinin/app1-bundle --to-repo internal-registry/app1-bundle
 
     # Copy image dkalinin/app1-image to another registry (or repository)
-    imgpkg copy -i dkalinin/app1-image --to-repo internal-registry/app1-image`,
+    imgpkg copy -i dkalinin/app1-image --to-repo internal-registry/app1-image
+
+    # Copy bundle dkalinin/app1-bundle to multiple registries (or repositories)
+    imgpkg copy -b dkalinin/app1-bundle --to-repo internal-registry1/app1-bundle --to-repo internal-registry2/app1-bundle
+
+    # Copy every bundle listed in bundles.yml to another registry (or repository)
+    imgpkg copy --lock bundles.yml --to-repo internal-registry/bundles --lock-output /tmp/relocated-bundles.yml
+
+    # Copy images listed in images.yml that are still referenced by tag (tags are resolved to digests)
+    imgpkg copy --lock images.yml --allow-tags --to-repo internal-registry/images --lock-output /tmp/relocated-images.yml`,
 	}
 
 	o.ImageFlags.SetCopy(cmd)
@@ -58,112 +71,244 @@ func NewCopyCmd(o *CopyOptions) *cobra.Command {
 	o.LockOutputFlags.Set(cmd)
 	o.TarFlags.Set(cmd)
 	o.RegistryFlags.Set(cmd)
-	cmd.Flags().StringVar(&o.RepoDst, "to-repo", "", "Location to upload assets")
+	o.OutputFormatFlags.Set(cmd)
+	cmd.Flags().StringSliceVar(&o.RepoDsts, "to-repo", nil, "Location to upload assets (can be specified multiple times)")
 	cmd.Flags().IntVar(&o.Concurrency, "concurrency", 5, "Concurrency")
 	cmd.Flags().BoolVar(&o.IncludeNonDistributable, "include-non-distributable-layers", false,
 		"Include non-distributable layers when copying an image/bundle")
+	cmd.Flags().BoolVar(&o.PreserveTags, "preserve-tags", false,
+		"Apply the source repository tags pointing to the copied image/bundle to the destination repository")
+	cmd.Flags().BoolVar(&o.FailFast, "fail-fast", false,
+		"Stop at the first image referenced by the bundle that cannot be copied (by default every image is attempted)")
 	return cmd
 }
 
 func (c *CopyOptions) Run() error {
+	return c.RunContext(context.Background())
+}
+
+// RunContext copies the same way as Run, but registry requests are aborted once ctx is done
+func (c *CopyOptions) RunContext(ctx context.Context) error {
 	if !c.hasOneSrc() {
 		return fmt.Errorf("Expected either --lock, --bundle (-b), --image (-i), or --tar as a source")
 	}
 	if !c.hasOneDst() {
 		return fmt.Errorf("Expected either --to-tar or --to-repo")
 	}
+	if c.isTarSrc() && c.isTarDst() {
+		return fmt.Errorf("Cannot use tar source (--tar) with tar destination (--to-tar)")
+	}
+	if c.isTarDst() && c.LockOutputFlags.LockFilePath != "" {
+		return fmt.Errorf("cannot output lock file with tar destination")
+	}
+	if c.PreserveTags && (!c.isRepoDst() || (c.ImageFlags.Image == "" && c.BundleFlags.Bundle == "")) {
+		return fmt.Errorf("Expected --preserve-tags to be used with --image (-i) or --bundle (-b) and --to-repo")
+	}
+	if len(c.RepoDsts) > 1 && c.LockOutputFlags.LockFilePath != "" {
+		return fmt.Errorf("Expected only one --to-repo when using --lock-output")
+	}
+	err := c.OutputFormatFlags.Validate()
+	if err != nil {
+		return err
+	}
+	if c.OutputFormatFlags.IsJSON() && !c.isRepoDst() {
+		return fmt.Errorf("Expected --output-format to be used with --to-repo")
+	}
 
-	logger := ctlimg.NewLogger(os.Stderr)
+	logger := c.logFlags.NewLogger(os.Stderr)
 	prefixedLogger := logger.NewPrefixedWriter("copy | ")
 
-	registryOpts := c.RegistryFlags.AsRegistryOpts()
+	registryOpts, err := c.RegistryFlags.AsRegistryOpts()
+	if err != nil {
+		return err
+	}
 	registryOpts.IncludeNonDistributableLayers = c.IncludeNonDistributable
+	registryOpts.Logger = c.logFlags.NewRegistryLogger(os.Stderr)
 
-	registry, err := registry.NewRegistry(registryOpts)
+	reg, err := registry.NewRegistry(registryOpts)
 	if err != nil {
 		return fmt.Errorf("Unable to create a registry with the options %v: %v", registryOpts, err)
 	}
 
-	switch {
-	case c.isTarSrc():
-		if c.isTarDst() {
-			return fmt.Errorf("Cannot use tar source (--tar) with tar destination (--to-tar)")
-		}
+	opts := v1.CopyOpts{
+		ImageRef:                c.ImageFlags.Image,
+		BundleRef:               c.BundleFlags.Bundle,
+		TarPath:                 c.TarFlags.TarSrc,
+		ToRepos:                 c.RepoDsts,
+		ToTar:                   c.TarFlags.TarDst,
+		Concurrency:             c.Concurrency,
+		IncludeNonDistributable: c.IncludeNonDistributable,
+		PreserveTags:            c.PreserveTags,
+		FailFast:                c.FailFast,
+	}
 
-		importRepo, err := regname.NewRepository(c.RepoDst)
+	if c.LockInputFlags.LockFilePath != "" {
+		opts.BundleLock, opts.ImagesLock, opts.BundlesLock, err = c.LockInputFlags.ReadLock(ctx, reg)
 		if err != nil {
-			return fmt.Errorf("Building import repository ref: %s", err)
+			return err
 		}
+	}
 
-		imageSet := ctlimgset.NewImageSet(c.Concurrency, prefixedLogger)
-		tarImageSet := ctlimgset.NewTarImageSet(imageSet, c.Concurrency, prefixedLogger)
+	result, err := v1.Copy(ctx, opts, reg, c.logFlags.ProgressLogger(writerLogger{prefixedLogger}))
+	if err != nil {
+		switch {
+		case v1.IsBundleError(err):
+			return imageFlagUsedForBundleErr("copying")
+		case v1.IsNotBundleError(err):
+			return bundleFlagUsedForImageErr()
+		}
+		return err
+	}
 
-		processedImages, err := tarImageSet.Import(c.TarFlags.TarSrc, importRepo, registry)
+	// Layers of a tar source are only known once it was imported into a repository
+	if !c.isTarSrc() || copiedToAnyRepo(result.Repos) {
+		informUserToUseTheNonDistributableFlag(prefixedLogger, c.IncludeNonDistributable, result.NonDistributableLayers)
+	}
+
+	if c.isTarDst() {
+		return nil
+	}
+	return c.finishCopyToRepos(opts, result.Repos, prefixedLogger)
+}
+
+// finishCopyToRepos writes lock output for a single destination, or
+// summarizes the outcome of copying to each of multiple destinations
+func (c *CopyOptions) finishCopyToRepos(opts v1.CopyOpts, results []v1.CopyRepoResult, logger *ctlimg.LoggerPrefixWriter) error {
+	if c.OutputFormatFlags.IsJSON() {
+		err := c.printCopyOutput(opts, results)
 		if err != nil {
 			return err
 		}
+	}
 
-		informUserToUseTheNonDistributableFlagWithDescriptors(prefixedLogger, c.IncludeNonDistributable, processedImagesMediaType(processedImages))
-		return c.writeLockOutput(processedImages, registry)
+	if len(results) == 1 {
+		if results[0].Err != nil {
+			return results[0].Err
+		}
+		return c.writeLockOutput(opts, results[0].Images)
+	}
 
-	case c.isRepoSrc():
-		imageSet := ctlimgset.NewImageSet(c.Concurrency, prefixedLogger)
+	var failed int
 
-		repoSrc := CopyRepoSrc{
-			logger:                  prefixedLogger,
-			ImageFlags:              c.ImageFlags,
-			BundleFlags:             c.BundleFlags,
-			LockInputFlags:          c.LockInputFlags,
-			IncludeNonDistributable: c.IncludeNonDistributable,
+	logger.Resultf("copy summary for %d destinations:\n", len(results))
 
-			registry:    registry,
-			imageSet:    imageSet,
-			tarImageSet: ctlimgset.NewTarImageSet(imageSet, c.Concurrency, prefixedLogger),
-			Concurrency: c.Concurrency,
+	for _, result := range results {
+		if result.Err != nil {
+			failed++
+			logger.Resultf("  %s: failed: %s\n", result.Repo, result.Err)
+		} else {
+			logger.Resultf("  %s: succeeded\n", result.Repo)
 		}
+	}
 
-		switch {
-		case c.isTarDst():
-			if c.LockOutputFlags.LockFilePath != "" {
-				return fmt.Errorf("cannot output lock file with tar destination")
-			}
+	if failed > 0 {
+		return fmt.Errorf("Copying to %d of %d destinations failed", failed, len(results))
+	}
+	return nil
+}
+
+// copyOutput is the machine readable description of a copy to repositories
+type copyOutput struct {
+	Source       string                  `json:"source"`
+	Destinations []copyDestinationOutput `json:"destinations"`
+}
+
+type copyDestinationOutput struct {
+	Repository string              `json:"repository"`
+	Digest     string              `json:"digest,omitempty"`
+	Tag        string              `json:"tag,omitempty"`
+	Images     []copiedImageOutput `json:"images"`
+	Error      string              `json:"error,omitempty"`
+}
 
-			return repoSrc.CopyToTar(c.TarFlags.TarDst)
+type copiedImageOutput struct {
+	Source      string `json:"source"`
+	Destination string `json:"destination"`
+}
+
+func (c *CopyOptions) printCopyOutput(opts v1.CopyOpts, results []v1.CopyRepoResult) error {
+	output := copyOutput{Source: c.srcRef()}
+
+	for _, result := range results {
+		destination := copyDestinationOutput{Repository: result.Repo, Images: []copiedImageOutput{}}
+
+		if result.Err != nil {
+			destination.Error = result.Err.Error()
+			output.Destinations = append(output.Destinations, destination)
+			continue
+		}
 
-		case c.isRepoDst():
-			processedImages, err := repoSrc.CopyToRepo(c.RepoDst)
+		for _, item := range result.Images {
+			destination.Images = append(destination.Images, copiedImageOutput{
+				Source:      item.SourceDigestRef,
+				Destination: item.DigestRef,
+			})
+		}
+
+		foundBundle := findBundle(result.Images)
+
+		// Digest and tag describe the copied bundle, or the copied image
+		// when a single one was copied (e.g. via --image). There is no
+		// single one to describe when copying multiple bundles.
+		var rootDigestRef string
+		switch {
+		case opts.BundlesLock != nil:
+		case foundBundle != nil:
+			rootDigestRef, destination.Tag = foundBundle.DigestRef, foundBundle.Tag
+		case len(result.Images) == 1:
+			rootDigestRef, destination.Tag = result.Images[0].DigestRef, result.Images[0].Tag
+		}
+
+		if rootDigestRef != "" {
+			parsedDigestRef, err := regname.NewDigest(rootDigestRef)
 			if err != nil {
 				return err
 			}
-
-			return c.writeLockOutput(processedImages, registry)
+			destination.Repository = parsedDigestRef.Context().Name()
+			destination.Digest = parsedDigestRef.DigestStr()
 		}
+
+		output.Destinations = append(output.Destinations, destination)
 	}
-	panic("Unreachable")
+
+	return c.OutputFormatFlags.PrintResult(c.ui, output)
 }
 
-func (c *CopyOptions) writeLockOutput(processedImages *ctlimgset.ProcessedImages, registry registry.Registry) error {
-	var foundBundle *bundle.Bundle
-	for _, item := range processedImages.All() {
-		plainImg := plainimage.NewFetchedPlainImageWithTag(item.DigestRef, item.UnprocessedImageRef.Tag, item.Image, item.ImageIndex)
-		bundle := bundle.NewBundleFromPlainImage(plainImg, registry)
+func (c *CopyOptions) writeLockOutput(opts v1.CopyOpts, copiedImages []v1.CopiedImage) error {
+	if c.LockOutputFlags.LockFilePath == "" {
+		return nil
+	}
 
-		ok, err := bundle.IsBundle()
-		if err != nil {
-			return fmt.Errorf("Check if '%s' is bundle: %s", item.DigestRef, err)
-		}
-		if ok {
-			foundBundle = bundle
+	if opts.BundlesLock != nil {
+		return c.writeBundlesLockOutput(*opts.BundlesLock, copiedImages)
+	}
+
+	foundBundle := findBundle(copiedImages)
+	if foundBundle != nil {
+		return c.writeBundleLockOutput(*foundBundle)
+	}
+	return c.writeImagesLockOutput(opts.ImagesLock, copiedImages)
+}
+
+// findBundle returns the bundle found among the copied images, if any
+func findBundle(copiedImages []v1.CopiedImage) *v1.CopiedImage {
+	var foundBundle *v1.CopiedImage
+	for i, item := range copiedImages {
+		if item.IsBundle {
+			foundBundle = &copiedImages[i]
 		}
 	}
+	return foundBundle
+}
 
-	if c.LockOutputFlags.LockFilePath != "" {
-		if foundBundle != nil {
-			return c.writeBundleLockOutput(foundBundle)
+// findCopiedImage returns the copied image with the provided source reference and tag
+func findCopiedImage(copiedImages []v1.CopiedImage, sourceDigestRef, tag string) (v1.CopiedImage, bool) {
+	for _, item := range copiedImages {
+		if item.SourceDigestRef == sourceDigestRef && item.Tag == tag {
+			return item, true
 		}
-		return c.writeImagesLockOutput(processedImages)
 	}
-	return nil
+	return v1.CopiedImage{}, false
 }
 
 func (c *CopyOptions) isTarSrc() bool { return c.TarFlags.TarSrc != "" }
@@ -173,7 +318,7 @@ func (c *CopyOptions) isRepoSrc() bool {
 }
 
 func (c *CopyOptions) isTarDst() bool  { return c.TarFlags.TarDst != "" }
-func (c *CopyOptions) isRepoDst() bool { return c.RepoDst != "" }
+func (c *CopyOptions) isRepoDst() bool { return len(c.RepoDsts) > 0 }
 
 func (c *CopyOptions) hasOneDst() bool {
 	repoSet := c.isRepoDst()
@@ -183,8 +328,7 @@ func (c *CopyOptions) hasOneDst() bool {
 
 func (c *CopyOptions) hasOneSrc() bool {
 	var seen bool
-	for _, ref := range []string{c.LockInputFlags.LockFilePath, c.TarFlags.TarSrc,
-		c.BundleFlags.Bundle, c.ImageFlags.Image} {
+	for _, ref := range c.srcRefs() {
 		if ref != "" {
 			if seen {
 				return false
@@ -195,7 +339,22 @@ func (c *CopyOptions) hasOneSrc() bool {
 	return seen
 }
 
-func (c *CopyOptions) writeImagesLockOutput(processedImages *ctlimgset.ProcessedImages) error {
+func (c *CopyOptions) srcRef() string {
+	for _, ref := range c.srcRefs() {
+		if ref != "" {
+			return ref
+		}
+	}
+	return ""
+}
+
+func (c *CopyOptions) srcRefs() []string {
+	return []string{c.LockInputFlags.LockFilePath, c.TarFlags.TarSrc, c.BundleFlags.Bundle, c.ImageFlags.Image}
+}
+
+// writeImagesLockOutput writes inputImagesLock (the lock provided via --lock, if any)
+// with every image pointing to its copy
+func (c *CopyOptions) writeImagesLockOutput(inputImagesLock *lockconfig.ImagesLock, copiedImages []v1.CopiedImage) error {
 	imagesLock := lockconfig.ImagesLock{
 		LockVersion: lockconfig.LockVersion{
 			APIVersion: lockconfig.ImagesLockAPIVersion,
@@ -204,20 +363,19 @@ func (c *CopyOptions) writeImagesLockOutput(processedImages *ctlimgset.Processed
 	}
 
 	if c.LockInputFlags.LockFilePath != "" {
-		var err error
-		imagesLock, err = lockconfig.NewImagesLockFromPath(c.LockInputFlags.LockFilePath)
-		if err != nil {
-			return err
+		if inputImagesLock == nil {
+			return fmt.Errorf("Expected --lock to be an %s", lockconfig.ImagesLockKind)
 		}
+		imagesLock = *inputImagesLock
 		for i, image := range imagesLock.Images {
-			img, found := processedImages.FindByURL(ctlimgset.UnprocessedImageRef{DigestRef: image.Image})
+			img, found := findCopiedImage(copiedImages, image.Image, "")
 			if !found {
 				return fmt.Errorf("Expected image '%s' to have been copied but was not", image.Image)
 			}
 			imagesLock.Images[i].Image = img.DigestRef
 		}
 	} else {
-		for _, img := range processedImages.All() {
+		for _, img := range copiedImages {
 			imagesLock.Images = append(imagesLock.Images, lockconfig.ImageRef{
 				Image: img.DigestRef,
 			})
@@ -227,91 +385,49 @@ func (c *CopyOptions) writeImagesLockOutput(processedImages *ctlimgset.Processed
 	return imagesLock.WriteToPath(c.LockOutputFlags.LockFilePath)
 }
 
-func (c *CopyOptions) writeBundleLockOutput(bundle *bundle.Bundle) error {
+func (c *CopyOptions) writeBundleLockOutput(bundle v1.CopiedImage) error {
 	bundleLock := lockconfig.BundleLock{
 		LockVersion: lockconfig.LockVersion{
 			APIVersion: lockconfig.BundleLockAPIVersion,
 			Kind:       lockconfig.BundleLockKind,
 		},
 		Bundle: lockconfig.BundleRef{
-			Image: bundle.DigestRef(),
-			Tag:   bundle.Tag(),
+			Image: bundle.DigestRef,
+			Tag:   bundle.Tag,
 		},
 	}
 
 	return bundleLock.WriteToPath(c.LockOutputFlags.LockFilePath)
 }
 
-func processedImagesMediaType(processedImages *ctlimgset.ProcessedImages) []string {
-	everyMediaType := []string{}
-	for _, image := range processedImages.All() {
-		if image.ImageIndex != nil {
-			mediaTypes := everyMediaTypeForAnImageIndex(image.ImageIndex)
-			everyMediaType = append(everyMediaType, mediaTypes...)
-		} else if image.Image != nil {
-			mediaTypes := everyMediaTypeForAnImage(image.Image)
-			everyMediaType = append(everyMediaType, mediaTypes...)
+func (c *CopyOptions) writeBundlesLockOutput(bundlesLock lockconfig.BundlesLock, copiedImages []v1.CopiedImage) error {
+	for i, bundleRef := range bundlesLock.Bundles {
+		img, found := findCopiedImage(copiedImages, bundleRef.Image, bundleRef.Tag)
+		if !found {
+			return fmt.Errorf("Expected bundle '%s' to have been copied but was not", bundleRef.Image)
 		}
+		bundlesLock.Bundles[i].Image = img.DigestRef
 	}
-	return everyMediaType
-}
 
-func everyMediaTypeForAnImageIndex(imageIndex regv1.ImageIndex) []string {
-	everyMediaType := []string{}
-	indexManifest, err := imageIndex.IndexManifest()
-	if err != nil {
-		return []string{}
-	}
-	for _, descriptor := range indexManifest.Manifests {
-		if descriptor.MediaType.IsIndex() {
-			imageIndex, err := imageIndex.ImageIndex(descriptor.Digest)
-			if err != nil {
-				continue
-			}
-			mediaTypesForImageIndex := everyMediaTypeForAnImageIndex(imageIndex)
-			everyMediaType = append(everyMediaType, mediaTypesForImageIndex...)
-		} else {
-			image, err := imageIndex.Image(descriptor.Digest)
-			if err != nil {
-				continue
-			}
-			mediaTypeForImage := everyMediaTypeForAnImage(image)
-			everyMediaType = append(everyMediaType, mediaTypeForImage...)
-		}
-	}
-	return everyMediaType
+	return bundlesLock.WriteToPath(c.LockOutputFlags.LockFilePath)
 }
 
-func everyMediaTypeForAnImage(image regv1.Image) []string {
-	var everyMediaType []string
-
-	layers, err := image.Layers()
-	if err != nil {
-		return everyMediaType
-	}
-
-	for _, layer := range layers {
-		mediaType, err := layer.MediaType()
-		if err != nil {
-			continue
+func copiedToAnyRepo(results []v1.CopyRepoResult) bool {
+	for _, result := range results {
+		if result.Err == nil {
+			return true
 		}
-		everyMediaType = append(everyMediaType, string(mediaType))
 	}
-	return everyMediaType
+	return false
 }
 
-func informUserToUseTheNonDistributableFlagWithDescriptors(logger *ctlimg.LoggerPrefixWriter, includeNonDistributableFlag bool, everyMediaType []string) {
-	noNonDistributableLayers := true
-
-	for _, mediaType := range everyMediaType {
-		if !types.MediaType(mediaType).IsDistributable() {
-			noNonDistributableLayers = false
+func informUserToUseTheNonDistributableFlag(logger *ctlimg.LoggerPrefixWriter, includeNonDistributableFlag bool, nonDistributableLayers []string) {
+	if includeNonDistributableFlag && len(nonDistributableLayers) == 0 {
+		logger.Warnf("Warning: '--include-non-distributable-layers' flag provided, but no images contained a non-distributable layer.")
+	} else if !includeNonDistributableFlag && len(nonDistributableLayers) > 0 {
+		for _, digest := range nonDistributableLayers {
+			logger.Warnf("Skipped layer '%s' due to it being non-distributable.", digest)
 		}
-	}
-
-	if includeNonDistributableFlag && noNonDistributableLayers {
-		logger.WriteStr("Warning: '--include-non-distributable-layers' flag provided, but no images contained a non-distributable layer.")
-	} else if !includeNonDistributableFlag && !noNonDistributableLayers {
-		logger.WriteStr("Skipped layer due to it being non-distributable. If you would like to include non-distributable layers, use the --include-non-distributable-layers flag")
+		logger.Warnf("If you would like to include non-distributable layers, use the --include-non-distributable-layers flag")
 	}
 }
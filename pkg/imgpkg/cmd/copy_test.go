@@ -4,12 +4,30 @@
 package cmd
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
+	"time"
+
+	"github.com/cppforlife/go-cli-ui/ui"
+	regname "github.com/google/go-containerregistry/pkg/name"
+	regv1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/k14s/imgpkg/pkg/imgpkg/lockconfig"
+	"github.com/k14s/imgpkg/test/helpers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"sigs.k8s.io/yaml"
 )
 
 func TestMultiDest(t *testing.T) {
-	err := (&CopyOptions{RepoDst: "foo", TarFlags: TarFlags{TarDst: "bar", TarSrc: "foo"}}).Run()
+	err := (&CopyOptions{RepoDsts: []string{"foo"}, TarFlags: TarFlags{TarDst: "bar", TarSrc: "foo"}}).Run()
 	if err == nil {
 		t.Fatalf("Expected Run() to err")
 	}
@@ -65,3 +83,617 @@ func TestTarSrcWithTarDst(t *testing.T) {
 		t.Fatalf("Expected error message related to destinations, got: %s", err)
 	}
 }
+
+func TestPreserveTagsWithTarDest(t *testing.T) {
+	err := (&CopyOptions{ImageFlags: ImageFlags{Image: "foo"}, TarFlags: TarFlags{TarDst: "bar"}, PreserveTags: true}).Run()
+	if err == nil {
+		t.Fatalf("Expected Run() to err")
+	}
+
+	if !strings.Contains(err.Error(), "Expected --preserve-tags to be used with --image (-i) or --bundle (-b) and --to-repo") {
+		t.Fatalf("Expected error message related to --preserve-tags, got: %s", err)
+	}
+}
+
+func TestCopyWithOutputFormatJSON(t *testing.T) {
+	fakeRegistry := helpers.NewFakeRegistry(t)
+	defer fakeRegistry.CleanUp()
+	image := fakeRegistry.WithImageFromPath("library/image", "test_assets/image_with_config", map[string]string{})
+	bundle := fakeRegistry.WithBundleFromPath("library/bundle", "test_assets/bundle").
+		WithImageRefs([]lockconfig.ImageRef{{Image: image.RefDigest}})
+	fakeRegistry.Build()
+
+	t.Run("when copying a bundle, it prints source and destination references as JSON", func(t *testing.T) {
+		stdout := &bytes.Buffer{}
+		dstRepo := fakeRegistry.ReferenceOnTestServer("copied/bundle")
+
+		subject := CopyOptions{
+			ui:                ui.NewWriterUI(stdout, ioutil.Discard, ui.NewNoopLogger()),
+			BundleFlags:       BundleFlags{Bundle: bundle.RefDigest},
+			RepoDsts:          []string{dstRepo},
+			Concurrency:       1,
+			OutputFormatFlags: OutputFormatFlags{OutputFormat: "json"},
+		}
+		require.NoError(t, subject.Run())
+
+		var output map[string]interface{}
+		require.NoError(t, json.Unmarshal(stdout.Bytes(), &output), "expected stdout to only contain JSON, got: %s", stdout)
+
+		assert.Equal(t, map[string]interface{}{
+			"source": bundle.RefDigest,
+			"destinations": []interface{}{
+				map[string]interface{}{
+					"repository": dstRepo,
+					"digest":     bundle.Digest,
+					"images": []interface{}{
+						map[string]interface{}{"source": bundle.RefDigest, "destination": dstRepo + "@" + bundle.Digest},
+						map[string]interface{}{"source": image.RefDigest, "destination": dstRepo + "@" + image.Digest},
+					},
+				},
+			},
+		}, output)
+	})
+
+	t.Run("when copying an image to multiple repositories, it prints every destination as JSON", func(t *testing.T) {
+		stdout := &bytes.Buffer{}
+		dstRepos := []string{fakeRegistry.ReferenceOnTestServer("copied/image1"), fakeRegistry.ReferenceOnTestServer("copied/image2")}
+
+		subject := CopyOptions{
+			ui:                ui.NewWriterUI(stdout, ioutil.Discard, ui.NewNoopLogger()),
+			ImageFlags:        ImageFlags{Image: image.RefDigest},
+			RepoDsts:          dstRepos,
+			Concurrency:       1,
+			OutputFormatFlags: OutputFormatFlags{OutputFormat: "json"},
+		}
+		require.NoError(t, subject.Run())
+
+		var output copyOutput
+		require.NoError(t, json.Unmarshal(stdout.Bytes(), &output), "expected stdout to only contain JSON, got: %s", stdout)
+
+		assert.Equal(t, image.RefDigest, output.Source)
+		require.Len(t, output.Destinations, 2)
+		for i, dstRepo := range dstRepos {
+			assert.Equal(t, copyDestinationOutput{
+				Repository: dstRepo,
+				Digest:     image.Digest,
+				Images:     []copiedImageOutput{{Source: image.RefDigest, Destination: dstRepo + "@" + image.Digest}},
+			}, output.Destinations[i])
+		}
+	})
+
+	t.Run("when copying to a tar, it errors", func(t *testing.T) {
+		subject := CopyOptions{
+			ImageFlags:        ImageFlags{Image: image.RefDigest},
+			TarFlags:          TarFlags{TarDst: "image.tar"},
+			OutputFormatFlags: OutputFormatFlags{OutputFormat: "json"},
+		}
+
+		err := subject.Run()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "Expected --output-format to be used with --to-repo")
+	})
+}
+
+func TestCopyWithWrongImageFlag(t *testing.T) {
+	fakeRegistry := helpers.NewFakeRegistry(t)
+	defer fakeRegistry.CleanUp()
+	image := fakeRegistry.WithImageFromPath("library/image", "test_assets/image_with_config", map[string]string{})
+	bundle := fakeRegistry.WithBundleFromPath("library/bundle", "test_assets/bundle").
+		WithImageRefs([]lockconfig.ImageRef{{Image: image.RefDigest}})
+	reg := fakeRegistry.Build()
+
+	t.Run("when a bundle is provided via --image, it errors before copying", func(t *testing.T) {
+		dstRepo := fakeRegistry.ReferenceOnTestServer("copied/bundle-as-image")
+
+		subject := CopyOptions{
+			ImageFlags:  ImageFlags{Image: bundle.RefDigest},
+			RepoDsts:    []string{dstRepo},
+			Concurrency: 1,
+		}
+
+		err := subject.Run()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "Expected bundle flag when copying a bundle (hint: Use -b instead of -i for bundles)")
+
+		dstRef, err := regname.ParseReference(dstRepo + "@" + bundle.Digest)
+		require.NoError(t, err)
+		_, err = reg.Digest(context.Background(), dstRef)
+		assert.Error(t, err, "expected nothing to be copied")
+	})
+
+	t.Run("when an image is provided via --bundle, it errors before copying", func(t *testing.T) {
+		dstRepo := fakeRegistry.ReferenceOnTestServer("copied/image-as-bundle")
+
+		subject := CopyOptions{
+			BundleFlags: BundleFlags{Bundle: image.RefDigest},
+			RepoDsts:    []string{dstRepo},
+			Concurrency: 1,
+		}
+
+		err := subject.Run()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "Expected bundle image but found plain image (hint: Did you use -i instead of -b?)")
+
+		dstRef, err := regname.ParseReference(dstRepo + "@" + image.Digest)
+		require.NoError(t, err)
+		_, err = reg.Digest(context.Background(), dstRef)
+		assert.Error(t, err, "expected nothing to be copied")
+	})
+}
+
+func TestCopyWithBundlesLock(t *testing.T) {
+	fakeRegistry := helpers.NewFakeRegistry(t)
+	defer fakeRegistry.CleanUp()
+	image1 := fakeRegistry.WithImageFromPath("library/image1", "test_assets/image_with_config", map[string]string{})
+	image2 := fakeRegistry.WithRandomImage("library/image2")
+	bundle1 := fakeRegistry.WithBundleFromPath("library/bundle1", "test_assets/bundle").
+		WithImageRefs([]lockconfig.ImageRef{{Image: image1.RefDigest}})
+	bundle2 := fakeRegistry.WithBundleFromPath("library/bundle2", "test_assets/bundle").
+		WithImageRefs([]lockconfig.ImageRef{{Image: image2.RefDigest}})
+	reg := fakeRegistry.Build()
+
+	tempDir := t.TempDir()
+	lockPath := filepath.Join(tempDir, "bundles.yml")
+	lockOutputPath := filepath.Join(tempDir, "relocated-bundles.yml")
+
+	bundlesLock := lockconfig.BundlesLock{
+		LockVersion: lockconfig.LockVersion{APIVersion: lockconfig.BundlesLockAPIVersion, Kind: lockconfig.BundlesLockKind},
+		Bundles: []lockconfig.NamedBundleRef{
+			{Name: "bundle1", Image: bundle1.RefDigest, Tag: "v1"},
+			{Name: "bundle2", Image: bundle2.RefDigest},
+		},
+	}
+	require.NoError(t, bundlesLock.WriteToPath(lockPath))
+
+	dstRepo := fakeRegistry.ReferenceOnTestServer("copied/bundles")
+
+	subject := CopyOptions{
+		LockInputFlags:  LockInputFlags{LockFilePath: lockPath},
+		LockOutputFlags: LockOutputFlags{LockFilePath: lockOutputPath},
+		RepoDsts:        []string{dstRepo},
+		Concurrency:     1,
+	}
+	require.NoError(t, subject.Run())
+
+	t.Run("it copies every bundle and the images they reference", func(t *testing.T) {
+		for _, digest := range []string{bundle1.Digest, bundle2.Digest, image1.Digest, image2.Digest} {
+			dstRef, err := regname.ParseReference(dstRepo + "@" + digest)
+			require.NoError(t, err)
+			_, err = reg.Digest(context.Background(), dstRef)
+			assert.NoError(t, err, "expected '%s' to be copied", dstRef)
+		}
+
+		dstTag, err := regname.ParseReference(dstRepo + ":v1")
+		require.NoError(t, err)
+		taggedDigest, err := reg.Digest(context.Background(), dstTag)
+		require.NoError(t, err)
+		assert.Equal(t, bundle1.Digest, taggedDigest.String())
+	})
+
+	t.Run("it writes a bundles lock pointing to the copied bundles", func(t *testing.T) {
+		relocatedLock, err := lockconfig.NewBundlesLockFromPath(lockOutputPath)
+		require.NoError(t, err)
+
+		assert.Equal(t, []lockconfig.NamedBundleRef{
+			{Name: "bundle1", Image: dstRepo + "@" + bundle1.Digest, Tag: "v1"},
+			{Name: "bundle2", Image: dstRepo + "@" + bundle2.Digest},
+		}, relocatedLock.Bundles)
+	})
+}
+
+func TestCopyWithTagsInLock(t *testing.T) {
+	fakeRegistry := helpers.NewFakeRegistry(t)
+	defer fakeRegistry.CleanUp()
+	image1 := fakeRegistry.WithImageFromPath("library/image1", "test_assets/image_with_config", map[string]string{})
+	image2 := fakeRegistry.WithRandomImage("library/image2")
+	reg := fakeRegistry.Build()
+
+	tempDir := t.TempDir()
+	image1TagRef := fakeRegistry.ReferenceOnTestServer("library/image1:latest")
+
+	writeImagesLock := func(t *testing.T, images ...lockconfig.ImageRef) string {
+		lockPath := filepath.Join(t.TempDir(), "images.yml")
+		imagesLock := lockconfig.ImagesLock{
+			LockVersion: lockconfig.LockVersion{APIVersion: lockconfig.ImagesLockAPIVersion, Kind: lockconfig.ImagesLockKind},
+			Images:      images,
+		}
+		bs, err := yaml.Marshal(imagesLock)
+		require.NoError(t, err)
+		require.NoError(t, ioutil.WriteFile(lockPath, bs, 0600))
+		return lockPath
+	}
+
+	t.Run("when every ref is pinned to a digest, it copies the images", func(t *testing.T) {
+		subject := CopyOptions{
+			LockInputFlags: LockInputFlags{LockFilePath: writeImagesLock(t, lockconfig.ImageRef{Image: image1.RefDigest}, lockconfig.ImageRef{Image: image2.RefDigest})},
+			RepoDsts:       []string{fakeRegistry.ReferenceOnTestServer("copied/pinned")},
+			Concurrency:    1,
+		}
+		require.NoError(t, subject.Run())
+	})
+
+	t.Run("when refs use tags, it errors listing every one of them", func(t *testing.T) {
+		image2TagRef := fakeRegistry.ReferenceOnTestServer("library/image2:latest")
+		subject := CopyOptions{
+			LockInputFlags: LockInputFlags{LockFilePath: writeImagesLock(t, lockconfig.ImageRef{Image: image1TagRef}, lockconfig.ImageRef{Image: image2TagRef})},
+			RepoDsts:       []string{fakeRegistry.ReferenceOnTestServer("copied/tags")},
+			Concurrency:    1,
+		}
+
+		err := subject.Run()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), fmt.Sprintf("Expected refs to be in digest form, got '%s', '%s'", image1TagRef, image2TagRef))
+	})
+
+	t.Run("when --allow-tags is provided, it copies the images the tags point to and writes digests", func(t *testing.T) {
+		dstRepo := fakeRegistry.ReferenceOnTestServer("copied/allowed-tags")
+		lockOutputPath := filepath.Join(tempDir, "relocated-images.yml")
+
+		subject := CopyOptions{
+			LockInputFlags:  LockInputFlags{LockFilePath: writeImagesLock(t, lockconfig.ImageRef{Image: image1TagRef}, lockconfig.ImageRef{Image: image2.RefDigest}), AllowTags: true},
+			LockOutputFlags: LockOutputFlags{LockFilePath: lockOutputPath},
+			RepoDsts:        []string{dstRepo},
+			Concurrency:     1,
+		}
+		require.NoError(t, subject.Run())
+
+		dstRef, err := regname.ParseReference(dstRepo + "@" + image1.Digest)
+		require.NoError(t, err)
+		_, err = reg.Digest(context.Background(), dstRef)
+		require.NoError(t, err)
+
+		relocatedLock, err := lockconfig.NewImagesLockFromPath(lockOutputPath)
+		require.NoError(t, err)
+		assert.Equal(t, []lockconfig.ImageRef{
+			{Image: dstRepo + "@" + image1.Digest, Tag: "latest"},
+			{Image: dstRepo + "@" + image2.Digest},
+		}, relocatedLock.Images)
+	})
+}
+
+func TestCopyWithinTheSameRegistry(t *testing.T) {
+	t.Run("when the registry supports mounting, it mounts blobs instead of uploading them", func(t *testing.T) {
+		fakeRegistry := helpers.NewFakeRegistry(t)
+		defer fakeRegistry.CleanUp()
+		tracker := fakeRegistry.WithBlobUploadsTracking(true)
+		image := fakeRegistry.WithImageFromPath("library/image", "test_assets/image_with_config", map[string]string{})
+		index := fakeRegistry.WithARandomImageIndex("library/index")
+		fakeRegistry.Build()
+		tracker.Reset()
+
+		for _, srcRef := range []string{image.RefDigest, index.RefDigest} {
+			subject := CopyOptions{
+				ImageFlags:  ImageFlags{Image: srcRef},
+				RepoDsts:    []string{fakeRegistry.ReferenceOnTestServer("copied/mounted")},
+				Concurrency: 1,
+			}
+			require.NoError(t, subject.Run())
+		}
+
+		layers := layerDigests(t, image.Image)
+		indexManifest, err := index.ImageIndex.IndexManifest()
+		require.NoError(t, err)
+		for _, manifest := range indexManifest.Manifests {
+			img, err := index.ImageIndex.Image(manifest.Digest)
+			require.NoError(t, err)
+			layers = append(layers, layerDigests(t, img)...)
+		}
+		require.NotEmpty(t, layers)
+
+		for _, layer := range layers {
+			assert.Contains(t, tracker.Mounted(), layer, "expected layer to be mounted")
+			assert.NotContains(t, tracker.Uploaded(), layer, "expected layer to not be uploaded")
+		}
+	})
+
+	t.Run("when the registry does not support mounting, it uploads blobs", func(t *testing.T) {
+		fakeRegistry := helpers.NewFakeRegistry(t)
+		defer fakeRegistry.CleanUp()
+		tracker := fakeRegistry.WithBlobUploadsTracking(false)
+		image := fakeRegistry.WithImageFromPath("library/image", "test_assets/image_with_config", map[string]string{})
+		reg := fakeRegistry.Build()
+		tracker.Reset()
+
+		dstRepo := fakeRegistry.ReferenceOnTestServer("copied/uploaded")
+		subject := CopyOptions{
+			ImageFlags:  ImageFlags{Image: image.RefDigest},
+			RepoDsts:    []string{dstRepo},
+			Concurrency: 1,
+		}
+		require.NoError(t, subject.Run())
+
+		assert.Empty(t, tracker.Mounted())
+		assert.NotEmpty(t, tracker.Uploaded())
+
+		dstRef, err := regname.ParseReference(dstRepo + "@" + image.Digest)
+		require.NoError(t, err)
+		_, err = reg.Digest(context.Background(), dstRef)
+		require.NoError(t, err)
+	})
+}
+
+func layerDigests(t *testing.T, img regv1.Image) []string {
+	layers, err := img.Layers()
+	require.NoError(t, err)
+
+	var digests []string
+	for _, layer := range layers {
+		digest, err := layer.Digest()
+		require.NoError(t, err)
+		digests = append(digests, digest.String())
+	}
+	return digests
+}
+
+func TestCopyOCIArtifact(t *testing.T) {
+	srcRegistry := helpers.NewFakeRegistry(t)
+	defer srcRegistry.CleanUp()
+	artifact := srcRegistry.WithArtifact("library/chart", "application/vnd.cncf.helm.config.v1+json", "application/vnd.cncf.helm.chart.content.v1.tar+gzip")
+	bundle := srcRegistry.WithBundleFromPath("library/bundle", "test_assets/bundle").
+		WithImageRefs([]lockconfig.ImageRef{{Image: artifact.RefDigest}})
+	reg := srcRegistry.Build()
+
+	dstRegistry := helpers.NewFakeRegistry(t)
+	defer dstRegistry.CleanUp()
+	dstRegistry.Build()
+
+	srcManifest, err := artifact.Image.RawManifest()
+	require.NoError(t, err)
+
+	assertManifestCopied := func(t *testing.T, dstRepo string) {
+		dstRef, err := regname.ParseReference(dstRepo + "@" + artifact.Digest)
+		require.NoError(t, err)
+		desc, err := reg.Get(context.Background(), dstRef)
+		require.NoError(t, err)
+
+		assert.Equal(t, string(srcManifest), string(desc.Manifest))
+	}
+
+	t.Run("when copying the artifact to another registry, it preserves the manifest", func(t *testing.T) {
+		dstRepo := dstRegistry.ReferenceOnTestServer("copied/chart")
+		subject := CopyOptions{
+			ImageFlags:  ImageFlags{Image: artifact.RefDigest},
+			RepoDsts:    []string{dstRepo},
+			Concurrency: 1,
+		}
+		require.NoError(t, subject.Run())
+
+		assertManifestCopied(t, dstRepo)
+	})
+
+	t.Run("when copying the artifact through a tar, it preserves the manifest", func(t *testing.T) {
+		tarPath := filepath.Join(t.TempDir(), "chart.tar")
+		subject := CopyOptions{
+			ImageFlags:  ImageFlags{Image: artifact.RefDigest},
+			TarFlags:    TarFlags{TarDst: tarPath},
+			Concurrency: 1,
+		}
+		require.NoError(t, subject.Run())
+
+		dstRepo := dstRegistry.ReferenceOnTestServer("copied/chart-from-tar")
+		subject = CopyOptions{
+			TarFlags:    TarFlags{TarSrc: tarPath},
+			RepoDsts:    []string{dstRepo},
+			Concurrency: 1,
+		}
+		require.NoError(t, subject.Run())
+
+		assertManifestCopied(t, dstRepo)
+	})
+
+	t.Run("when copying a bundle that references the artifact, it preserves the artifact manifest", func(t *testing.T) {
+		dstRepo := dstRegistry.ReferenceOnTestServer("copied/bundle")
+		subject := CopyOptions{
+			BundleFlags: BundleFlags{Bundle: bundle.RefDigest},
+			RepoDsts:    []string{dstRepo},
+			Concurrency: 1,
+		}
+		require.NoError(t, subject.Run())
+
+		assertManifestCopied(t, dstRepo)
+	})
+}
+
+func TestCopyBundleWithUnreachableImages(t *testing.T) {
+	fakeRegistry := helpers.NewFakeRegistry(t)
+	defer fakeRegistry.CleanUp()
+	image1 := fakeRegistry.WithImageFromPath("library/image1", "test_assets/image_with_config", map[string]string{})
+	image2 := fakeRegistry.WithRandomImage("library/image2")
+	missingImageRef := fakeRegistry.ReferenceOnTestServer("library/missing@sha256:45f3926bca9fc42adb650fef2a41250d77841dde49afc8adc7c0c633b3d5f27a")
+	bundle := fakeRegistry.WithBundleFromPath("library/bundle", "test_assets/bundle").
+		WithImageRefs([]lockconfig.ImageRef{{Image: image1.RefDigest}, {Image: missingImageRef}, {Image: image2.RefDigest}})
+	reg := fakeRegistry.Build()
+
+	t.Run("it copies every reachable image, reports the unreachable ones and does not copy the bundle", func(t *testing.T) {
+		dstRepo := fakeRegistry.ReferenceOnTestServer("copied/partial")
+		subject := CopyOptions{
+			BundleFlags: BundleFlags{Bundle: bundle.RefDigest},
+			RepoDsts:    []string{dstRepo},
+			Concurrency: 1,
+		}
+
+		err := subject.Run()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "Unable to copy 1 image(s) referenced by bundle(s), so the bundle(s) were not copied")
+		assert.Contains(t, err.Error(), fmt.Sprintf("Unable to reach image '%s'", missingImageRef))
+		assert.Contains(t, err.Error(), "Copied 2 image(s)")
+
+		for _, digest := range []string{image1.Digest, image2.Digest} {
+			dstRef, err := regname.ParseReference(dstRepo + "@" + digest)
+			require.NoError(t, err)
+			_, err = reg.Digest(context.Background(), dstRef)
+			assert.NoError(t, err, "expected reachable image to be copied")
+		}
+
+		dstRef, err := regname.ParseReference(dstRepo + "@" + bundle.Digest)
+		require.NoError(t, err)
+		_, err = reg.Digest(context.Background(), dstRef)
+		assert.Error(t, err, "expected bundle not to be copied")
+	})
+
+	t.Run("with --fail-fast it stops at the unreachable image", func(t *testing.T) {
+		dstRepo := fakeRegistry.ReferenceOnTestServer("copied/fail-fast")
+		subject := CopyOptions{
+			BundleFlags: BundleFlags{Bundle: bundle.RefDigest},
+			RepoDsts:    []string{dstRepo},
+			FailFast:    true,
+			Concurrency: 1,
+		}
+
+		err := subject.Run()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), fmt.Sprintf("Checking if '%s' is a bundle", missingImageRef))
+
+		dstRef, err := regname.ParseReference(dstRepo + "@" + bundle.Digest)
+		require.NoError(t, err)
+		_, err = reg.Digest(context.Background(), dstRef)
+		assert.Error(t, err, "expected bundle not to be copied")
+	})
+
+	t.Run("when copying to a tar it does not write the tarball", func(t *testing.T) {
+		tarPath := filepath.Join(t.TempDir(), "bundle.tar")
+		subject := CopyOptions{
+			BundleFlags: BundleFlags{Bundle: bundle.RefDigest},
+			TarFlags:    TarFlags{TarDst: tarPath},
+			Concurrency: 1,
+		}
+
+		err := subject.Run()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), fmt.Sprintf("Unable to reach image '%s'", missingImageRef))
+		assert.NoFileExists(t, tarPath)
+	})
+}
+
+func TestCopyBundleWithTagRefs(t *testing.T) {
+	fakeRegistry := helpers.NewFakeRegistry(t)
+	defer fakeRegistry.CleanUp()
+	image1 := fakeRegistry.WithImageFromPath("library/image1", "test_assets/image_with_config", map[string]string{})
+	image2 := fakeRegistry.WithRandomImage("library/image2")
+	image1TagRef := fakeRegistry.ReferenceOnTestServer("library/image1:latest")
+
+	bundleDir := t.TempDir()
+	imagesLock := lockconfig.ImagesLock{
+		LockVersion: lockconfig.LockVersion{APIVersion: lockconfig.ImagesLockAPIVersion, Kind: lockconfig.ImagesLockKind},
+		Images:      []lockconfig.ImageRef{{Image: image1TagRef, Name: "image1"}, {Image: image2.RefDigest}},
+	}
+	imagesLockBytes, err := yaml.Marshal(imagesLock)
+	require.NoError(t, err)
+	require.NoError(t, os.MkdirAll(filepath.Join(bundleDir, ".imgpkg"), 0700))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(bundleDir, ".imgpkg", "images.yml"), imagesLockBytes, 0600))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(bundleDir, "config.yml"), []byte("key: value\n"), 0600))
+
+	bundle := fakeRegistry.WithBundleFromPath("library/bundle", bundleDir)
+	reg := fakeRegistry.Build()
+
+	t.Run("it copies the images the tags point to and pins the copied bundle to them by digest", func(t *testing.T) {
+		dstRepo := fakeRegistry.ReferenceOnTestServer("copied/pinned-bundle")
+		lockOutputPath := filepath.Join(t.TempDir(), "bundle.yml")
+
+		subject := CopyOptions{
+			BundleFlags:     BundleFlags{Bundle: bundle.RefDigest},
+			LockOutputFlags: LockOutputFlags{LockFilePath: lockOutputPath},
+			RepoDsts:        []string{dstRepo},
+			Concurrency:     1,
+		}
+		require.NoError(t, subject.Run())
+
+		for _, digest := range []string{image1.Digest, image2.Digest} {
+			dstRef, err := regname.ParseReference(dstRepo + "@" + digest)
+			require.NoError(t, err)
+			_, err = reg.Digest(context.Background(), dstRef)
+			assert.NoError(t, err, "expected image to be copied")
+		}
+
+		bundleLock, err := lockconfig.NewBundleLockFromPath(lockOutputPath)
+		require.NoError(t, err)
+		assert.True(t, strings.HasPrefix(bundleLock.Bundle.Image, dstRepo+"@sha256:"))
+		assert.NotEqual(t, dstRepo+"@"+bundle.Digest, bundleLock.Bundle.Image, "expected bundle to be rewritten")
+
+		srcBundleRef, err := regname.ParseReference(bundle.RefDigest)
+		require.NoError(t, err)
+		srcBundleImg, err := reg.Image(context.Background(), srcBundleRef)
+		require.NoError(t, err)
+		srcConfigFile, err := srcBundleImg.ConfigFile()
+		require.NoError(t, err)
+
+		copiedBundleRef, err := regname.ParseReference(bundleLock.Bundle.Image)
+		require.NoError(t, err)
+		copiedBundleImg, err := reg.Image(context.Background(), copiedBundleRef)
+		require.NoError(t, err)
+		copiedConfigFile, err := copiedBundleImg.ConfigFile()
+		require.NoError(t, err)
+
+		assert.Equal(t, srcConfigFile.Config, copiedConfigFile.Config, "expected bundle config to be kept")
+		assert.Equal(t, srcConfigFile.Created, copiedConfigFile.Created)
+		assert.Equal(t, srcConfigFile.History, copiedConfigFile.History)
+
+		outputPath := filepath.Join(t.TempDir(), "pulled")
+		pull := PullOptions{
+			ui:          ui.NewWriterUI(ioutil.Discard, ioutil.Discard, ui.NewNoopLogger()),
+			BundleFlags: BundleFlags{Bundle: bundleLock.Bundle.Image},
+			OutputPath:  outputPath,
+		}
+		require.NoError(t, pull.Run())
+
+		pulledImagesLock, err := lockconfig.NewImagesLockFromPath(filepath.Join(outputPath, ".imgpkg", "images.yml"))
+		require.NoError(t, err)
+		assert.Equal(t, []lockconfig.ImageRef{
+			{Image: dstRepo + "@" + image1.Digest, Name: "image1", Tag: "latest"},
+			{Image: dstRepo + "@" + image2.Digest},
+		}, pulledImagesLock.Images)
+
+		config, err := ioutil.ReadFile(filepath.Join(outputPath, "config.yml"))
+		require.NoError(t, err)
+		assert.Equal(t, "key: value\n", string(config))
+	})
+
+	t.Run("when copying to a tar, it errors", func(t *testing.T) {
+		tarPath := filepath.Join(t.TempDir(), "bundle.tar")
+		subject := CopyOptions{
+			BundleFlags: BundleFlags{Bundle: bundle.RefDigest},
+			TarFlags:    TarFlags{TarDst: tarPath},
+			Concurrency: 1,
+		}
+
+		err := subject.Run()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), fmt.Sprintf("Expected images referenced by bundle '%s' to use digests when copying to a tar", bundle.RefDigest))
+		assert.NoFileExists(t, tarPath)
+	})
+}
+
+func TestCopyWithCancelledContext(t *testing.T) {
+	fakeRegistry := helpers.NewFakeRegistry(t)
+	defer fakeRegistry.CleanUp()
+	image := fakeRegistry.WithImageFromPath("library/image", "test_assets/image_with_config", map[string]string{})
+	fakeRegistry.Build()
+	uploadStarted := fakeRegistry.WithStalledUploads()
+
+	t.Run("when the context is cancelled mid-copy, it stops and returns a context cancelled error", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		go func() {
+			<-uploadStarted
+			cancel()
+		}()
+
+		subject := CopyOptions{
+			ImageFlags:  ImageFlags{Image: image.RefDigest},
+			RepoDsts:    []string{fakeRegistry.ReferenceOnTestServer("copied/image")},
+			Concurrency: 1,
+		}
+
+		errCh := make(chan error, 1)
+		go func() { errCh <- subject.RunContext(ctx) }()
+
+		select {
+		case err := <-errCh:
+			require.Error(t, err)
+			assert.True(t, errors.Is(err, context.Canceled), "Expected error to wrap context.Canceled, got: %s", err)
+		case <-time.After(5 * time.Second):
+			t.Fatalf("Expected copy to stop once the context was cancelled")
+		}
+	})
+}
@@ -0,0 +1,109 @@
+// Copyright 2021 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/cppforlife/go-cli-ui/ui"
+	regname "github.com/google/go-containerregistry/pkg/name"
+	"github.com/k14s/imgpkg/pkg/imgpkg/bundle"
+	"github.com/k14s/imgpkg/pkg/imgpkg/plainimage"
+	"github.com/k14s/imgpkg/pkg/imgpkg/registry"
+	"github.com/spf13/cobra"
+)
+
+type DeleteOptions struct {
+	ui       ui.UI
+	logFlags *LogFlags
+
+	ImageFlags    ImageFlags
+	RegistryFlags RegistryFlags
+	Force         bool
+}
+
+func NewDeleteOptions(ui ui.UI, logFlags *LogFlags) *DeleteOptions {
+	return &DeleteOptions{ui: ui, logFlags: logFlags}
+}
+
+func NewDeleteCmd(o *DeleteOptions) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "delete",
+		Short: "Delete image or bundle",
+		RunE:  func(cmd *cobra.Command, _ []string) error { return o.RunContext(cmd.Context()) },
+		Example: `
+  # Delete image repo/app1-image that tag v1 points to (also deletes every other tag pointing to it)
+  imgpkg delete -i repo/app1-image:v1
+
+  # Delete image repo/app1-image by digest
+  imgpkg delete -i repo/app1-image@sha256:...
+
+  # Delete bundle repo/app1-bundle by digest
+  imgpkg delete -i repo/app1-bundle@sha256:... --force`,
+	}
+	o.ImageFlags.Set(cmd)
+	o.RegistryFlags.Set(cmd)
+	cmd.Flags().BoolVar(&o.Force, "force", false, "Allow deleting bundles")
+	return cmd
+}
+
+func (o *DeleteOptions) Run() error {
+	return o.RunContext(context.Background())
+}
+
+// RunContext deletes the same way as Run, but registry requests are aborted once ctx is done
+func (o *DeleteOptions) RunContext(ctx context.Context) error {
+	if o.ImageFlags.Image == "" {
+		return fmt.Errorf("Expected image reference to delete")
+	}
+
+	ref, err := regname.ParseReference(o.ImageFlags.Image, regname.WeakValidation)
+	if err != nil {
+		return fmt.Errorf("Parsing '%s': %s", o.ImageFlags.Image, err)
+	}
+
+	registryOpts, err := o.RegistryFlags.AsRegistryOpts()
+	if err != nil {
+		return err
+	}
+	registryOpts.Logger = o.logFlags.NewRegistryLogger(os.Stderr)
+
+	reg, err := registry.NewRegistry(registryOpts)
+	if err != nil {
+		return fmt.Errorf("Unable to create a registry with the options %v: %v", registryOpts, err)
+	}
+
+	digest, err := reg.Digest(ctx, ref)
+	if err != nil {
+		return fmt.Errorf("Resolving '%s': %s", ref.Name(), err)
+	}
+
+	plainImg := plainimage.NewPlainImage(o.ImageFlags.Image, reg)
+
+	isBundle, err := bundle.NewBundleFromPlainImage(plainImg, reg).IsBundle(ctx)
+	if err != nil {
+		return err
+	}
+	if isBundle && !o.Force {
+		return fmt.Errorf("Expected image but found bundle '%s' (hint: Use --force to delete bundles)", ref.Name())
+	}
+
+	// Registries only delete manifests by digest, so a tag is resolved first
+	digestRef := ref.Context().Digest(digest.String())
+
+	if _, isTag := ref.(regname.Tag); isTag {
+		o.ui.BeginLinef("Warning: Deleting '%s' that '%s' points to also deletes every other tag pointing to it\n", digestRef.Name(), ref.Name())
+	}
+
+	err = reg.Delete(ctx, digestRef)
+	if err != nil {
+		return err
+	}
+
+	o.ui.BeginLinef("Deleted '%s'\n", digestRef.Name())
+
+	return nil
+}
@@ -0,0 +1,93 @@
+// Copyright 2021 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"context"
+	"testing"
+
+	"github.com/cppforlife/go-cli-ui/ui"
+	regname "github.com/google/go-containerregistry/pkg/name"
+	"github.com/k14s/imgpkg/test/helpers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDelete(t *testing.T) {
+	fakeRegistry := helpers.NewFakeRegistry(t)
+	defer fakeRegistry.CleanUp()
+	image := fakeRegistry.WithRandomImage("library/image")
+	bundle := fakeRegistry.WithRandomBundle("library/bundle")
+
+	confUI := ui.NewConfUI(ui.NewNoopLogger())
+	defer confUI.Flush()
+
+	t.Run("deletes an image by digest", func(t *testing.T) {
+		reg := fakeRegistry.Build()
+
+		subject := DeleteOptions{ui: confUI, ImageFlags: ImageFlags{image.RefDigest}}
+		require.NoError(t, subject.Run())
+
+		ref, err := regname.NewDigest(image.RefDigest)
+		require.NoError(t, err)
+		_, err = reg.Digest(context.Background(), ref)
+		assert.Error(t, err, "expected image to be deleted")
+	})
+
+	t.Run("deletes an image by tag by deleting the digest it points to", func(t *testing.T) {
+		reg := fakeRegistry.Build()
+		tagRef := fakeRegistry.ReferenceOnTestServer("library/image:latest")
+
+		subject := DeleteOptions{ui: confUI, ImageFlags: ImageFlags{tagRef}}
+		require.NoError(t, subject.Run())
+
+		ref, err := regname.NewDigest(image.RefDigest)
+		require.NoError(t, err)
+		_, err = reg.Digest(context.Background(), ref)
+		assert.Error(t, err, "expected image to be deleted")
+	})
+
+	t.Run("when the context is cancelled, it does not delete the image", func(t *testing.T) {
+		reg := fakeRegistry.Build()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		subject := DeleteOptions{ui: confUI, ImageFlags: ImageFlags{image.RefDigest}}
+		err := subject.RunContext(ctx)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), context.Canceled.Error())
+
+		ref, err := regname.NewDigest(image.RefDigest)
+		require.NoError(t, err)
+		_, err = reg.Digest(context.Background(), ref)
+		assert.NoError(t, err, "expected image to not be deleted")
+	})
+
+	t.Run("when reference is a bundle, it refuses to delete it without --force", func(t *testing.T) {
+		reg := fakeRegistry.Build()
+
+		subject := DeleteOptions{ui: confUI, ImageFlags: ImageFlags{bundle.RefDigest}}
+		err := subject.Run()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "(hint: Use --force to delete bundles)")
+
+		ref, err := regname.NewDigest(bundle.RefDigest)
+		require.NoError(t, err)
+		_, err = reg.Digest(context.Background(), ref)
+		assert.NoError(t, err, "expected bundle to not be deleted")
+	})
+
+	t.Run("when reference is a bundle and --force is provided, it deletes it", func(t *testing.T) {
+		reg := fakeRegistry.Build()
+
+		subject := DeleteOptions{ui: confUI, ImageFlags: ImageFlags{bundle.RefDigest}, Force: true}
+		require.NoError(t, subject.Run())
+
+		ref, err := regname.NewDigest(bundle.RefDigest)
+		require.NoError(t, err)
+		_, err = reg.Digest(context.Background(), ref)
+		assert.Error(t, err, "expected bundle to be deleted")
+	})
+}
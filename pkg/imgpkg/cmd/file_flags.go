@@ -4,11 +4,17 @@
 package cmd
 
 import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+
 	"github.com/spf13/cobra"
 )
 
 type FileFlags struct {
-	Files []string
+	Files   []string
+	FileTar string
 
 	ExcludedFilePaths []string
 }
@@ -16,8 +22,23 @@ type FileFlags struct {
 func (f *FileFlags) Set(cmd *cobra.Command) {
 	cmd.Flags().StringSliceVarP(&f.Files, "file", "f", nil, "Set file (format: /tmp/foo, -) (can be specified multiple times)")
 
+	cmd.Flags().StringVar(&f.FileTar, "file-tar", "", "Set tar used as is as the image layer instead of files (format: /tmp/foo.tar, -)")
+
 	cmd.Flags().StringSliceVar(&f.ExcludedFilePaths, "file-exclude-defaults", []string{".git"}, "Excluded file paths by default (can be specified multiple times)")
 	cmd.Flags().MarkDeprecated("file-exclude-defaults", "use '--file-exclusion' instead")
 
 	cmd.Flags().StringSliceVar(&f.ExcludedFilePaths, "file-exclusion", []string{".git"}, "Exclude file whose path, relative to the bundle root, matches (format: bar.yaml, nested-dir/baz.txt) (can be specified multiple times)")
 }
+
+// OpenFileTar opens the tar provided via --file-tar, reading stdin for '-'
+func (f *FileFlags) OpenFileTar() (io.ReadCloser, error) {
+	if f.FileTar == "-" {
+		return ioutil.NopCloser(os.Stdin), nil
+	}
+
+	file, err := os.Open(f.FileTar)
+	if err != nil {
+		return nil, fmt.Errorf("Opening tar: %s", err)
+	}
+	return file, nil
+}
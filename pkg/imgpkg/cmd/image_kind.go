@@ -0,0 +1,18 @@
+// Copyright 2021 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"fmt"
+)
+
+// imageFlagUsedForBundleErr is returned when a bundle is provided via --image
+func imageFlagUsedForBundleErr(action string) error {
+	return fmt.Errorf("Expected bundle flag when %s a bundle (hint: Use -b instead of -i for bundles)", action)
+}
+
+// bundleFlagUsedForImageErr is returned when a plain image is provided via --bundle
+func bundleFlagUsedForImageErr() error {
+	return fmt.Errorf("Expected bundle image but found plain image (hint: Did you use -i instead of -b?)")
+}
@@ -5,6 +5,7 @@ package cmd
 
 import (
 	"io"
+	"os"
 
 	"github.com/cppforlife/cobrautil"
 	"github.com/cppforlife/go-cli-ui/ui"
@@ -14,7 +15,8 @@ import (
 type ImgpkgOptions struct {
 	ui *ui.ConfUI
 
-	UIFlags UIFlags
+	UIFlags  UIFlags
+	LogFlags LogFlags
 }
 
 func NewImgpkgOptions(ui *ui.ConfUI) *ImgpkgOptions {
@@ -39,14 +41,17 @@ func NewImgpkgCmd(o *ImgpkgOptions) *cobra.Command {
 	cmd.SetOutput(uiBlockWriter{o.ui}) // setting output for cmd.Help()
 
 	o.UIFlags.Set(cmd)
+	o.LogFlags.Set(cmd)
 
-	cmd.AddCommand(NewPushCmd(NewPushOptions(o.ui)))
-	cmd.AddCommand(NewPullCmd(NewPullOptions(o.ui)))
+	cmd.AddCommand(NewPushCmd(NewPushOptions(o.ui, &o.LogFlags)))
+	cmd.AddCommand(NewPullCmd(NewPullOptions(o.ui, &o.LogFlags)))
 	cmd.AddCommand(NewVersionCmd(NewVersionOptions(o.ui)))
-	cmd.AddCommand(NewCopyCmd(NewCopyOptions()))
+	cmd.AddCommand(NewCopyCmd(NewCopyOptions(o.ui, &o.LogFlags)))
+	cmd.AddCommand(NewDeleteCmd(NewDeleteOptions(o.ui, &o.LogFlags)))
+	cmd.AddCommand(NewVerifyChecksumsCmd(NewVerifyChecksumsOptions(o.ui)))
 
 	tagCmd := NewTagCmd()
-	tagCmd.AddCommand(NewTagListCmd(NewTagListOptions(o.ui)))
+	tagCmd.AddCommand(NewTagListCmd(NewTagListOptions(o.ui, &o.LogFlags)))
 	cmd.AddCommand(tagCmd)
 
 	// Last one runs first
@@ -54,7 +59,18 @@ func NewImgpkgCmd(o *ImgpkgOptions) *cobra.Command {
 	cobrautil.VisitCommands(cmd, cobrautil.DisallowExtraArgs)
 
 	cobrautil.VisitCommands(cmd, cobrautil.WrapRunEForCmd(func(*cobra.Command, []string) error {
+		err := o.UIFlags.Validate()
+		if err != nil {
+			return err
+		}
+		err = o.LogFlags.Validate()
+		if err != nil {
+			return err
+		}
+
 		o.UIFlags.ConfigureUI(o.ui)
+		// Prefixed logs are written to stderr
+		o.LogFlags.Color = o.UIFlags.ColorEnabled(os.Stderr)
 		return nil
 	}))
 
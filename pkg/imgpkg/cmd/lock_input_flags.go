@@ -4,14 +4,88 @@
 package cmd
 
 import (
+	"context"
+	"fmt"
+
+	regname "github.com/google/go-containerregistry/pkg/name"
+	ctlimg "github.com/k14s/imgpkg/pkg/imgpkg/image"
+	"github.com/k14s/imgpkg/pkg/imgpkg/lockconfig"
 	"github.com/spf13/cobra"
 )
 
 type LockInputFlags struct {
 	LockFilePath string
+	AllowTags    bool
 }
 
 func (l *LockInputFlags) Set(cmd *cobra.Command) {
 	cmd.Flags().StringVar(&l.LockFilePath, "lock", "",
 		"Lock file with asset references to copy to destination")
+	cmd.Flags().BoolVar(&l.AllowTags, "allow-tags", false,
+		"Allow lock file references to use tags instead of digests (tags are resolved to digests when the lock is read)")
+}
+
+// ReadLock reads the lock file provided via --lock. References that use
+// tags (only accepted with --allow-tags) are resolved to digests so that
+// the rest of the command only deals with immutable references
+func (l LockInputFlags) ReadLock(ctx context.Context, reg ctlimg.ImagesMetadata) (*lockconfig.BundleLock, *lockconfig.ImagesLock, *lockconfig.BundlesLock, error) {
+	bundleLock, imagesLock, bundlesLock, err := lockconfig.NewLockFromPath(l.LockFilePath, lockconfig.ReadOpts{AllowTags: l.AllowTags})
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	if !l.AllowTags {
+		return bundleLock, imagesLock, bundlesLock, nil
+	}
+
+	switch {
+	case bundleLock != nil:
+		bundleLock.Bundle.Image, bundleLock.Bundle.Tag, err = resolveTagRef(ctx, bundleLock.Bundle.Image, bundleLock.Bundle.Tag, reg)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+
+	case imagesLock != nil:
+		for i, img := range imagesLock.Images {
+			imagesLock.Images[i].Image, imagesLock.Images[i].Tag, err = resolveTagRef(ctx, img.Image, img.Tag, reg)
+			if err != nil {
+				return nil, nil, nil, err
+			}
+		}
+
+	case bundlesLock != nil:
+		for i, bundleRef := range bundlesLock.Bundles {
+			bundlesLock.Bundles[i].Image, bundlesLock.Bundles[i].Tag, err = resolveTagRef(ctx, bundleRef.Image, bundleRef.Tag, reg)
+			if err != nil {
+				return nil, nil, nil, err
+			}
+		}
+	}
+
+	return bundleLock, imagesLock, bundlesLock, nil
+}
+
+// resolveTagRef returns the digest reference the provided ref currently points to.
+// When the ref is a tag and no tag was recorded, the tag is kept alongside the digest
+func resolveTagRef(ctx context.Context, ref, tag string, reg ctlimg.ImagesMetadata) (string, string, error) {
+	parsedRef, err := regname.ParseReference(ref, regname.WeakValidation)
+	if err != nil {
+		return "", "", err
+	}
+
+	parsedTag, ok := parsedRef.(regname.Tag)
+	if !ok {
+		return ref, tag, nil
+	}
+
+	digest, err := reg.Digest(ctx, parsedTag)
+	if err != nil {
+		return "", "", fmt.Errorf("Resolving tag '%s' to a digest: %s", ref, err)
+	}
+
+	if tag == "" {
+		tag = parsedTag.TagStr()
+	}
+
+	return parsedTag.Context().Digest(digest.String()).Name(), tag, nil
 }
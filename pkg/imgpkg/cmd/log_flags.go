@@ -0,0 +1,62 @@
+// Copyright 2021 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"fmt"
+	"io"
+
+	ctlimg "github.com/k14s/imgpkg/pkg/imgpkg/image"
+	v1 "github.com/k14s/imgpkg/pkg/imgpkg/v1"
+	"github.com/spf13/cobra"
+)
+
+type LogFlags struct {
+	Debug bool
+	Quiet bool
+
+	// Color is resolved from --color once the output is known
+	Color bool
+}
+
+func (f *LogFlags) Set(cmd *cobra.Command) {
+	cmd.PersistentFlags().BoolVar(&f.Debug, "debug", false, "Include debug output (e.g. requests sent to the registry)")
+	cmd.PersistentFlags().BoolVar(&f.Quiet, "quiet", false, "Only print errors and results")
+}
+
+func (f *LogFlags) Validate() error {
+	if f.Debug && f.Quiet {
+		return fmt.Errorf("Expected only one of --debug or --quiet")
+	}
+	return nil
+}
+
+func (f *LogFlags) NewLogger(writer io.Writer) ctlimg.KbldLogger {
+	level := ctlimg.LogLevelInfo
+	color := false
+	if f != nil {
+		switch {
+		case f.Debug:
+			level = ctlimg.LogLevelDebug
+		case f.Quiet:
+			level = ctlimg.LogLevelQuiet
+		}
+		color = f.Color
+	}
+	return ctlimg.NewLogger(writer).WithLevel(level).WithColor(color)
+}
+
+// NewRegistryLogger returns the logger used for requests sent to the registry
+func (f *LogFlags) NewRegistryLogger(writer io.Writer) *ctlimg.LoggerPrefixWriter {
+	return f.NewLogger(writer).NewPrefixedWriter("registry | ")
+}
+
+// ProgressLogger returns logger, which reports progress of the v1 API,
+// unless only results should be printed
+func (f *LogFlags) ProgressLogger(logger v1.Logger) v1.Logger {
+	if f != nil && f.Quiet {
+		return noopLogger{}
+	}
+	return logger
+}
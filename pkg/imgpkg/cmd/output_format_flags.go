@@ -0,0 +1,93 @@
+// Copyright 2021 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/cppforlife/go-cli-ui/ui"
+	regname "github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/k14s/imgpkg/pkg/imgpkg/v1"
+	"github.com/spf13/cobra"
+)
+
+const (
+	outputFormatFlagName = "output-format"
+	outputFormatJSON     = "json"
+)
+
+type OutputFormatFlags struct {
+	OutputFormat string
+}
+
+func (f *OutputFormatFlags) Set(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&f.OutputFormat, outputFormatFlagName, "",
+		"Print the result to stdout in the given format (json), progress is printed to stderr")
+}
+
+func (f OutputFormatFlags) Validate() error {
+	switch f.OutputFormat {
+	case "", outputFormatJSON:
+		return nil
+	default:
+		return fmt.Errorf("Expected --%s to be '%s', but was '%s'", outputFormatFlagName, outputFormatJSON, f.OutputFormat)
+	}
+}
+
+func (f OutputFormatFlags) IsJSON() bool { return f.OutputFormat == outputFormatJSON }
+
+// NewLogger returns the logger for progress messages; they are written
+// to stderr when stdout is reserved for the machine readable result
+func (f OutputFormatFlags) NewLogger(ui ui.UI) v1.Logger {
+	if f.IsJSON() {
+		return writerLogger{os.Stderr}
+	}
+	return uiLogger{ui}
+}
+
+// PrintResult prints result as JSON to stdout
+func (f OutputFormatFlags) PrintResult(ui ui.UI, result interface{}) error {
+	resultBytes, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return fmt.Errorf("Marshaling result: %s", err)
+	}
+
+	ui.PrintBlock(append(resultBytes, '\n'))
+	return nil
+}
+
+// IsMachineReadableOutput returns true when the executed command prints
+// its result in a machine readable format, hence nothing else should go to stdout
+func IsMachineReadableOutput(cmd *cobra.Command) bool {
+	flag := cmd.Flags().Lookup(outputFormatFlagName)
+	return flag != nil && flag.Value.String() != ""
+}
+
+// imageOutput is the machine readable description of a pushed or pulled image or bundle
+type imageOutput struct {
+	Digest     string   `json:"digest"`
+	Tag        string   `json:"tag,omitempty"`
+	Repository string   `json:"repository"`
+	Images     []string `json:"images"`
+}
+
+func newImageOutput(digestRef string, tag string, images []string) (imageOutput, error) {
+	parsedDigestRef, err := regname.NewDigest(digestRef)
+	if err != nil {
+		return imageOutput{}, err
+	}
+
+	if images == nil {
+		images = []string{}
+	}
+
+	return imageOutput{
+		Digest:     parsedDigestRef.DigestStr(),
+		Tag:        tag,
+		Repository: parsedDigestRef.Context().Name(),
+		Images:     images,
+	}, nil
+}
@@ -4,106 +4,199 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 
 	"github.com/cppforlife/go-cli-ui/ui"
-	"github.com/k14s/imgpkg/pkg/imgpkg/bundle"
 	ctlimg "github.com/k14s/imgpkg/pkg/imgpkg/image"
 	"github.com/k14s/imgpkg/pkg/imgpkg/lockconfig"
-	"github.com/k14s/imgpkg/pkg/imgpkg/plainimage"
 	"github.com/k14s/imgpkg/pkg/imgpkg/registry"
+	v1 "github.com/k14s/imgpkg/pkg/imgpkg/v1"
 	"github.com/spf13/cobra"
 )
 
 type PullOptions struct {
-	ui ui.UI
+	ui       ui.UI
+	logFlags *LogFlags
 
 	ImageFlags           ImageFlags
 	RegistryFlags        RegistryFlags
 	BundleFlags          BundleFlags
 	LockInputFlags       LockInputFlags
 	BundleRecursiveFlags BundleRecursiveFlags
+	OutputFormatFlags    OutputFormatFlags
 	OutputPath           string
+	Overwrite            bool
+	OutputChecksums      bool
 }
 
 var _ ctlimg.ImagesMetadata = registry.Registry{}
 
-func NewPullOptions(ui ui.UI) *PullOptions {
-	return &PullOptions{ui: ui}
+func NewPullOptions(ui ui.UI, logFlags *LogFlags) *PullOptions {
+	return &PullOptions{ui: ui, logFlags: logFlags}
 }
 
 func NewPullCmd(o *PullOptions) *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "pull",
 		Short: "Pull files from bundle, image, or bundle lock file",
-		RunE:  func(_ *cobra.Command, _ []string) error { return o.Run() },
+		RunE:  func(cmd *cobra.Command, _ []string) error { return o.RunContext(cmd.Context()) },
 		Example: `
   # Pull bundle repo/app1-bundle and extract into /tmp/app1-bundle
   imgpkg pull -b repo/app1-bundle -o /tmp/app1-bundle
 
   # Pull image repo/app1-image and extract into /tmp/app1-image
-  imgpkg pull -i repo/app1-image -o /tmp/app1-image`,
+  imgpkg pull -i repo/app1-image -o /tmp/app1-image
+
+  # Pull every bundle listed in bundles.yml into a subdirectory of /tmp/bundles named after it
+  imgpkg pull --lock bundles.yml -o /tmp/bundles
+
+  # Pull bundle repo/app1-bundle recording checksums of its files, to be verified later
+  imgpkg pull -b repo/app1-bundle -o /tmp/app1-bundle --output-checksums
+  imgpkg verify-checksums -o /tmp/app1-bundle`,
 	}
 	o.ImageFlags.Set(cmd)
 	o.RegistryFlags.Set(cmd)
 	o.BundleFlags.Set(cmd)
 	o.BundleRecursiveFlags.Set(cmd)
 	o.LockInputFlags.Set(cmd)
+	o.OutputFormatFlags.Set(cmd)
 	cmd.Flags().StringVarP(&o.OutputPath, "output", "o", "", "Output directory path")
 	cmd.MarkFlagRequired("output")
+	cmd.Flags().BoolVar(&o.Overwrite, "overwrite", false, "Remove contents of non-empty output directory before pulling")
+	cmd.Flags().BoolVar(&o.OutputChecksums, "output-checksums", false,
+		"Write the sha256 of every pulled bundle file into .imgpkg/checksums.txt (bundles only)")
 
 	return cmd
 }
 
 func (po *PullOptions) Run() error {
+	return po.RunContext(context.Background())
+}
+
+// RunContext pulls the same way as Run, but registry requests are aborted once ctx is done
+func (po *PullOptions) RunContext(ctx context.Context) error {
 	err := po.validate()
 	if err != nil {
 		return err
 	}
 
-	reg, err := registry.NewRegistry(po.RegistryFlags.AsRegistryOpts())
+	registryOpts, err := po.RegistryFlags.AsRegistryOpts()
+	if err != nil {
+		return err
+	}
+	registryOpts.Logger = po.logFlags.NewRegistryLogger(os.Stderr)
+
+	reg, err := registry.NewRegistry(registryOpts)
 	if err != nil {
-		return fmt.Errorf("Unable to create a registry with the options %v: %v", po.RegistryFlags.AsRegistryOpts(), err)
+		return fmt.Errorf("Unable to create a registry with the options %v: %v", registryOpts, err)
 	}
 
+	var result v1.PullResult
+
 	switch {
-	case len(po.LockInputFlags.LockFilePath) > 0 || len(po.BundleFlags.Bundle) > 0:
-		bundleRef := po.BundleFlags.Bundle
+	case len(po.LockInputFlags.LockFilePath) > 0:
+		bundleLock, _, bundlesLock, err := po.LockInputFlags.ReadLock(ctx, reg)
+		if err != nil {
+			return err
+		}
 
-		if len(po.LockInputFlags.LockFilePath) > 0 {
-			bundleLock, err := lockconfig.NewBundleLockFromPath(po.LockInputFlags.LockFilePath)
+		switch {
+		case bundleLock != nil:
+			result, err = po.pullBundle(ctx, bundleLock.Bundle.Image, po.OutputPath, reg)
 			if err != nil {
 				return err
 			}
-			bundleRef = bundleLock.Bundle.Image
+		case bundlesLock != nil:
+			return po.pullBundles(ctx, *bundlesLock, reg)
+		default:
+			return fmt.Errorf("Expected --lock to be a %s or %s (hint: Images Lock files can be copied but not pulled)",
+				lockconfig.BundleLockKind, lockconfig.BundlesLockKind)
 		}
 
-		err := bundle.NewBundle(bundleRef, reg).Pull(po.OutputPath, po.ui, po.BundleRecursiveFlags.Recursive)
+	case len(po.BundleFlags.Bundle) > 0:
+		result, err = po.pullBundle(ctx, po.BundleFlags.Bundle, po.OutputPath, reg)
 		if err != nil {
-			if bundle.IsNotBundleError(err) {
-				return fmt.Errorf("Expected bundle image but found plain image (hint: Did you use -i instead of -b?)")
-			}
 			return err
 		}
-		return nil
 
 	case len(po.ImageFlags.Image) > 0:
-		plainImg := plainimage.NewPlainImage(po.ImageFlags.Image, reg)
-		ok, err := bundle.NewBundleFromPlainImage(plainImg, reg).IsBundle()
+		result, err = v1.PullImage(ctx, po.ImageFlags.Image, po.OutputPath, reg, po.logFlags.ProgressLogger(po.OutputFormatFlags.NewLogger(po.ui)))
 		if err != nil {
+			if v1.IsBundleError(err) {
+				return imageFlagUsedForBundleErr("pulling")
+			}
 			return err
 		}
-		if ok {
-			return fmt.Errorf("Expected bundle flag when pulling a bundle (hint: Use -b instead of -i for bundles)")
-		}
-		return plainImg.Pull(po.OutputPath, po.ui)
 
 	default:
 		panic("Unreachable code")
 	}
+
+	if po.OutputFormatFlags.IsJSON() {
+		output, err := newImageOutput(result.DigestRef, result.Tag, result.Images)
+		if err != nil {
+			return err
+		}
+		return po.OutputFormatFlags.PrintResult(po.ui, output)
+	}
+
+	return nil
+}
+
+func (po *PullOptions) pullBundle(ctx context.Context, bundleRef string, outputPath string, reg registry.Registry) (v1.PullResult, error) {
+	result, err := v1.PullBundle(ctx, bundleRef, outputPath, v1.PullOpts{Recursive: po.BundleRecursiveFlags.Recursive, OutputChecksums: po.OutputChecksums}, reg, po.logFlags.ProgressLogger(po.OutputFormatFlags.NewLogger(po.ui)))
+	if err != nil {
+		if v1.IsNotBundleError(err) {
+			return v1.PullResult{}, bundleFlagUsedForImageErr()
+		}
+		return v1.PullResult{}, err
+	}
+	return result, nil
+}
+
+// pullBundles extracts every bundle of the lock into a subdirectory named after it
+func (po *PullOptions) pullBundles(ctx context.Context, bundlesLock lockconfig.BundlesLock, reg registry.Registry) error {
+	output := bundlesOutput{Bundles: []namedImageOutput{}}
+
+	for _, bundleRef := range bundlesLock.Bundles {
+		result, err := po.pullBundle(ctx, bundleRef.Image, filepath.Join(po.OutputPath, bundleRef.Name), reg)
+		if err != nil {
+			return fmt.Errorf("Pulling bundle '%s': %s", bundleRef.Name, err)
+		}
+
+		bundleOutput, err := newImageOutput(result.DigestRef, result.Tag, result.Images)
+		if err != nil {
+			return err
+		}
+		output.Bundles = append(output.Bundles, namedImageOutput{Name: bundleRef.Name, imageOutput: bundleOutput})
+	}
+
+	if po.OutputFormatFlags.IsJSON() {
+		return po.OutputFormatFlags.PrintResult(po.ui, output)
+	}
+	return nil
+}
+
+// bundlesOutput is the machine readable description of bundles pulled via a bundles lock
+type bundlesOutput struct {
+	Bundles []namedImageOutput `json:"bundles"`
+}
+
+type namedImageOutput struct {
+	Name string `json:"name"`
+	imageOutput
 }
 
 func (po *PullOptions) validate() error {
+	err := po.OutputFormatFlags.Validate()
+	if err != nil {
+		return err
+	}
+
 	if po.OutputPath == "" {
 		return fmt.Errorf("Expected --output to be none empty")
 	}
@@ -124,5 +217,39 @@ func (po *PullOptions) validate() error {
 	if presentInputParams == 0 {
 		return fmt.Errorf("Expected either image or bundle reference")
 	}
+	if po.OutputChecksums && len(po.ImageFlags.Image) > 0 {
+		return fmt.Errorf("Expected --output-checksums to be used with --bundle (-b) or --lock")
+	}
+
+	if !po.Overwrite {
+		empty, err := isEmptyDir(po.OutputPath)
+		if err != nil {
+			return err
+		}
+		if !empty {
+			return fmt.Errorf("Expected output directory '%s' to be empty (hint: Use --overwrite to replace its contents)", po.OutputPath)
+		}
+	}
 	return nil
 }
+
+// isEmptyDir considers a non-existent path to be an empty directory
+// since pulling creates the output directory when necessary
+func isEmptyDir(path string) (bool, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return true, nil
+		}
+		return false, fmt.Errorf("Checking output directory: %s", err)
+	}
+	if !info.IsDir() {
+		return false, nil
+	}
+
+	files, err := ioutil.ReadDir(path)
+	if err != nil {
+		return false, fmt.Errorf("Checking output directory: %s", err)
+	}
+	return len(files) == 0, nil
+}
@@ -4,10 +4,19 @@
 package cmd
 
 import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
 	"github.com/cppforlife/go-cli-ui/ui"
+	"github.com/k14s/imgpkg/pkg/imgpkg/lockconfig"
+	"github.com/k14s/imgpkg/test/helpers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestNoImageOrBundleOrLockError(t *testing.T) {
@@ -50,3 +59,287 @@ func TestInvalidArgsPassed(t *testing.T) {
 		t.Fatalf("\nExpceted: %s\nGot: %s", expected, err.Error())
 	}
 }
+
+func TestPullIntoOutputDirectory(t *testing.T) {
+	fakeRegistry := helpers.NewFakeRegistry(t)
+	defer fakeRegistry.CleanUp()
+	image := fakeRegistry.WithImageFromPath("library/image", "test_assets/image_with_config", map[string]string{})
+	bundle := fakeRegistry.WithBundleFromPath("library/bundle", "test_assets/bundle").
+		WithImageRefs([]lockconfig.ImageRef{{Image: image.RefDigest}})
+	fakeRegistry.Build()
+
+	confUI := ui.NewConfUI(ui.NewNoopLogger())
+	defer confUI.Flush()
+
+	t.Run("when output directory is empty, it pulls into it", func(t *testing.T) {
+		outputPath := createPullOutputDir(t)
+
+		subject := PullOptions{ui: confUI, ImageFlags: ImageFlags{image.RefDigest}, OutputPath: outputPath}
+		require.NoError(t, subject.Run())
+
+		assert.FileExists(t, filepath.Join(outputPath, "config.yml"))
+	})
+
+	t.Run("when output directory does not exist, it creates it", func(t *testing.T) {
+		outputPath := filepath.Join(createPullOutputDir(t), "nested")
+
+		subject := PullOptions{ui: confUI, ImageFlags: ImageFlags{image.RefDigest}, OutputPath: outputPath}
+		require.NoError(t, subject.Run())
+
+		assert.FileExists(t, filepath.Join(outputPath, "config.yml"))
+	})
+
+	t.Run("when output directory is not empty, it refuses to pull", func(t *testing.T) {
+		outputPath := createPullOutputDir(t)
+		require.NoError(t, ioutil.WriteFile(filepath.Join(outputPath, "existing.yml"), []byte("existing"), 0600))
+
+		subject := PullOptions{ui: confUI, ImageFlags: ImageFlags{image.RefDigest}, OutputPath: outputPath}
+		err := subject.Run()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "Expected output directory '"+outputPath+"' to be empty (hint: Use --overwrite to replace its contents)")
+
+		assert.FileExists(t, filepath.Join(outputPath, "existing.yml"))
+		assert.NoFileExists(t, filepath.Join(outputPath, "config.yml"))
+	})
+
+	t.Run("when output directory only contains .imgpkg from a previous pull, it refuses to pull", func(t *testing.T) {
+		outputPath := createPullOutputDir(t)
+		require.NoError(t, os.MkdirAll(filepath.Join(outputPath, ".imgpkg"), 0700))
+		require.NoError(t, ioutil.WriteFile(filepath.Join(outputPath, ".imgpkg", "images.yml"), []byte("stale"), 0600))
+
+		subject := PullOptions{ui: confUI, BundleFlags: BundleFlags{bundle.RefDigest}, OutputPath: outputPath}
+		err := subject.Run()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "(hint: Use --overwrite to replace its contents)")
+	})
+
+	t.Run("when --overwrite is provided, it clears output directory before pulling", func(t *testing.T) {
+		outputPath := createPullOutputDir(t)
+		require.NoError(t, ioutil.WriteFile(filepath.Join(outputPath, "existing.yml"), []byte("existing"), 0600))
+		require.NoError(t, os.MkdirAll(filepath.Join(outputPath, ".imgpkg"), 0700))
+		require.NoError(t, ioutil.WriteFile(filepath.Join(outputPath, ".imgpkg", "stale.yml"), []byte("stale"), 0600))
+
+		subject := PullOptions{ui: confUI, BundleFlags: BundleFlags{bundle.RefDigest}, OutputPath: outputPath, Overwrite: true}
+		require.NoError(t, subject.Run())
+
+		assert.NoFileExists(t, filepath.Join(outputPath, "existing.yml"))
+		assert.NoFileExists(t, filepath.Join(outputPath, ".imgpkg", "stale.yml"))
+		assert.FileExists(t, filepath.Join(outputPath, ".imgpkg", "images.yml"))
+	})
+}
+
+func TestPullWithOutputFormatJSON(t *testing.T) {
+	fakeRegistry := helpers.NewFakeRegistry(t)
+	defer fakeRegistry.CleanUp()
+	image := fakeRegistry.WithImageFromPath("library/image", "test_assets/image_with_config", map[string]string{})
+	bundle := fakeRegistry.WithBundleFromPath("library/bundle", "test_assets/bundle").
+		WithImageRefs([]lockconfig.ImageRef{{Image: image.RefDigest}})
+	fakeRegistry.Build()
+
+	t.Run("when pulling an image, it prints the pulled image as JSON and nothing else to stdout", func(t *testing.T) {
+		stdout := &bytes.Buffer{}
+		subject := PullOptions{
+			ui:                ui.NewWriterUI(stdout, ioutil.Discard, ui.NewNoopLogger()),
+			ImageFlags:        ImageFlags{fakeRegistry.ReferenceOnTestServer("library/image:latest")},
+			OutputPath:        createPullOutputDir(t),
+			OutputFormatFlags: OutputFormatFlags{OutputFormat: "json"},
+		}
+		require.NoError(t, subject.Run())
+
+		var output map[string]interface{}
+		require.NoError(t, json.Unmarshal(stdout.Bytes(), &output), "expected stdout to only contain JSON, got: %s", stdout)
+
+		assert.Equal(t, map[string]interface{}{
+			"digest":     image.Digest,
+			"tag":        "latest",
+			"repository": fakeRegistry.ReferenceOnTestServer("library/image"),
+			"images":     []interface{}{},
+		}, output)
+	})
+
+	t.Run("when pulling a bundle, it prints the pulled bundle and its images as JSON", func(t *testing.T) {
+		stdout := &bytes.Buffer{}
+		subject := PullOptions{
+			ui:                ui.NewWriterUI(stdout, ioutil.Discard, ui.NewNoopLogger()),
+			BundleFlags:       BundleFlags{bundle.RefDigest},
+			OutputPath:        createPullOutputDir(t),
+			OutputFormatFlags: OutputFormatFlags{OutputFormat: "json"},
+		}
+		require.NoError(t, subject.Run())
+
+		var output map[string]interface{}
+		require.NoError(t, json.Unmarshal(stdout.Bytes(), &output), "expected stdout to only contain JSON, got: %s", stdout)
+
+		assert.Equal(t, map[string]interface{}{
+			"digest":     bundle.Digest,
+			"repository": fakeRegistry.ReferenceOnTestServer("library/bundle"),
+			"images":     []interface{}{image.RefDigest},
+		}, output)
+	})
+}
+
+func createPullOutputDir(t *testing.T) string {
+	outputPath, err := ioutil.TempDir("", "imgpkg-pull-output")
+	require.NoError(t, err)
+	t.Cleanup(func() { os.RemoveAll(outputPath) })
+	return outputPath
+}
+
+func TestPullWithBundlesLock(t *testing.T) {
+	fakeRegistry := helpers.NewFakeRegistry(t)
+	defer fakeRegistry.CleanUp()
+	image := fakeRegistry.WithImageFromPath("library/image", "test_assets/image_with_config", map[string]string{})
+	bundle1 := fakeRegistry.WithBundleFromPath("library/bundle1", "test_assets/bundle").
+		WithImageRefs([]lockconfig.ImageRef{{Image: image.RefDigest}})
+	bundle2 := fakeRegistry.WithBundleFromPath("library/bundle2", "test_assets/bundle").
+		WithImageRefs([]lockconfig.ImageRef{})
+	fakeRegistry.Build()
+
+	lockPath := filepath.Join(t.TempDir(), "bundles.yml")
+	bundlesLock := lockconfig.BundlesLock{
+		LockVersion: lockconfig.LockVersion{APIVersion: lockconfig.BundlesLockAPIVersion, Kind: lockconfig.BundlesLockKind},
+		Bundles: []lockconfig.NamedBundleRef{
+			{Name: "first", Image: bundle1.RefDigest},
+			{Name: "second", Image: bundle2.RefDigest},
+		},
+	}
+	require.NoError(t, bundlesLock.WriteToPath(lockPath))
+
+	t.Run("it pulls every bundle into a subdirectory named after it", func(t *testing.T) {
+		outputPath := filepath.Join(t.TempDir(), "bundles")
+		stdout := &bytes.Buffer{}
+
+		subject := PullOptions{
+			ui:                ui.NewWriterUI(stdout, ioutil.Discard, ui.NewNoopLogger()),
+			LockInputFlags:    LockInputFlags{LockFilePath: lockPath},
+			OutputFormatFlags: OutputFormatFlags{OutputFormat: "json"},
+			OutputPath:        outputPath,
+		}
+		require.NoError(t, subject.Run())
+
+		firstImagesLock, err := lockconfig.NewImagesLockFromPath(filepath.Join(outputPath, "first", ".imgpkg", "images.yml"))
+		require.NoError(t, err)
+		require.Len(t, firstImagesLock.Images, 1)
+		assert.Equal(t, image.RefDigest, firstImagesLock.Images[0].Image)
+
+		secondImagesLock, err := lockconfig.NewImagesLockFromPath(filepath.Join(outputPath, "second", ".imgpkg", "images.yml"))
+		require.NoError(t, err)
+		assert.Empty(t, secondImagesLock.Images)
+
+		var output bundlesOutput
+		require.NoError(t, json.Unmarshal(stdout.Bytes(), &output), "expected stdout to only contain JSON, got: %s", stdout)
+		require.Len(t, output.Bundles, 2)
+		assert.Equal(t, "first", output.Bundles[0].Name)
+		assert.Equal(t, bundle1.Digest, output.Bundles[0].Digest)
+		assert.Equal(t, "second", output.Bundles[1].Name)
+		assert.Equal(t, bundle2.Digest, output.Bundles[1].Digest)
+	})
+
+	t.Run("when the lock is an images lock, it errors", func(t *testing.T) {
+		imagesLockPath := filepath.Join(t.TempDir(), "images.yml")
+		imagesLock := lockconfig.ImagesLock{
+			LockVersion: lockconfig.LockVersion{APIVersion: lockconfig.ImagesLockAPIVersion, Kind: lockconfig.ImagesLockKind},
+			Images:      []lockconfig.ImageRef{{Image: image.RefDigest}},
+		}
+		require.NoError(t, imagesLock.WriteToPath(imagesLockPath))
+
+		subject := PullOptions{
+			ui:             ui.NewWriterUI(ioutil.Discard, ioutil.Discard, ui.NewNoopLogger()),
+			LockInputFlags: LockInputFlags{LockFilePath: imagesLockPath},
+			OutputPath:     filepath.Join(t.TempDir(), "bundles"),
+		}
+		err := subject.Run()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "Expected --lock to be a BundleLock or BundlesLock")
+	})
+}
+
+func TestPullWithTagInBundleLock(t *testing.T) {
+	fakeRegistry := helpers.NewFakeRegistry(t)
+	defer fakeRegistry.CleanUp()
+	bundle := fakeRegistry.WithBundleFromPath("library/bundle", "test_assets/bundle").
+		WithImageRefs([]lockconfig.ImageRef{})
+	fakeRegistry.Build()
+
+	bundleTagRef := fakeRegistry.ReferenceOnTestServer("library/bundle:latest")
+	lockPath := filepath.Join(t.TempDir(), "bundle.yml")
+	require.NoError(t, ioutil.WriteFile(lockPath, []byte(`
+apiVersion: imgpkg.carvel.dev/v1alpha1
+kind: BundleLock
+bundle:
+  image: `+bundleTagRef+`
+`), 0600))
+
+	t.Run("when the bundle ref uses a tag, it errors", func(t *testing.T) {
+		subject := PullOptions{
+			ui:             ui.NewWriterUI(ioutil.Discard, ioutil.Discard, ui.NewNoopLogger()),
+			LockInputFlags: LockInputFlags{LockFilePath: lockPath},
+			OutputPath:     filepath.Join(t.TempDir(), "bundle"),
+		}
+		err := subject.Run()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "Expected ref to be in digest form, got '"+bundleTagRef+"'")
+	})
+
+	t.Run("when --allow-tags is provided, it pulls the bundle the tag points to", func(t *testing.T) {
+		stdout := &bytes.Buffer{}
+		subject := PullOptions{
+			ui:                ui.NewWriterUI(stdout, ioutil.Discard, ui.NewNoopLogger()),
+			LockInputFlags:    LockInputFlags{LockFilePath: lockPath, AllowTags: true},
+			OutputFormatFlags: OutputFormatFlags{OutputFormat: "json"},
+			OutputPath:        filepath.Join(t.TempDir(), "bundle"),
+		}
+		require.NoError(t, subject.Run())
+
+		var output imageOutput
+		require.NoError(t, json.Unmarshal(stdout.Bytes(), &output), "expected stdout to only contain JSON, got: %s", stdout)
+		assert.Equal(t, bundle.Digest, output.Digest)
+	})
+}
+
+func TestPullWithOutputChecksums(t *testing.T) {
+	fakeRegistry := helpers.NewFakeRegistry(t)
+	defer fakeRegistry.CleanUp()
+	bundle := fakeRegistry.WithBundleFromPath("library/bundle", "test_assets/bundle").
+		WithImageRefs([]lockconfig.ImageRef{})
+	fakeRegistry.Build()
+
+	outputPath := filepath.Join(t.TempDir(), "bundle")
+	pullOptions := PullOptions{
+		ui:              ui.NewWriterUI(ioutil.Discard, ioutil.Discard, ui.NewNoopLogger()),
+		BundleFlags:     BundleFlags{Bundle: bundle.RefDigest},
+		OutputPath:      outputPath,
+		OutputChecksums: true,
+	}
+	require.NoError(t, pullOptions.Run())
+
+	t.Run("verify-checksums succeeds when files were not modified", func(t *testing.T) {
+		stdout := &bytes.Buffer{}
+		subject := VerifyChecksumsOptions{ui: ui.NewWriterUI(stdout, ioutil.Discard, ui.NewNoopLogger()), OutputPath: outputPath}
+		require.NoError(t, subject.Run())
+		assert.Contains(t, stdout.String(), "match their checksums")
+	})
+
+	t.Run("verify-checksums reports tampered files", func(t *testing.T) {
+		require.NoError(t, ioutil.WriteFile(filepath.Join(outputPath, "config.yml"), []byte("tampered: true\n"), 0600))
+
+		stdout := &bytes.Buffer{}
+		subject := VerifyChecksumsOptions{ui: ui.NewWriterUI(stdout, ioutil.Discard, ui.NewNoopLogger()), OutputPath: outputPath}
+		err := subject.Run()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "found 0 added, 0 removed and 1 changed files")
+		assert.Contains(t, stdout.String(), "changed: config.yml")
+	})
+
+	t.Run("when pulling an image, it errors", func(t *testing.T) {
+		subject := PullOptions{
+			ui:              ui.NewWriterUI(ioutil.Discard, ioutil.Discard, ui.NewNoopLogger()),
+			ImageFlags:      ImageFlags{Image: bundle.RefDigest},
+			OutputPath:      filepath.Join(t.TempDir(), "image"),
+			OutputChecksums: true,
+		}
+		err := subject.Run()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "Expected --output-checksums to be used with --bundle (-b) or --lock")
+	})
+}
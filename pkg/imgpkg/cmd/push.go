@@ -4,58 +4,102 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
+	"math"
+	"os"
+	"strconv"
+	"strings"
 
 	"github.com/cppforlife/go-cli-ui/ui"
-	regname "github.com/google/go-containerregistry/pkg/name"
-	"github.com/k14s/imgpkg/pkg/imgpkg/bundle"
 	"github.com/k14s/imgpkg/pkg/imgpkg/lockconfig"
-	"github.com/k14s/imgpkg/pkg/imgpkg/plainimage"
 	"github.com/k14s/imgpkg/pkg/imgpkg/registry"
+	v1 "github.com/k14s/imgpkg/pkg/imgpkg/v1"
 	"github.com/spf13/cobra"
 )
 
 type PushOptions struct {
-	ui ui.UI
+	ui       ui.UI
+	logFlags *LogFlags
 
-	ImageFlags      ImageFlags
-	BundleFlags     BundleFlags
-	LockOutputFlags LockOutputFlags
-	FileFlags       FileFlags
-	RegistryFlags   RegistryFlags
+	ImageFlags        ImageFlags
+	BundleFlags       BundleFlags
+	LockOutputFlags   LockOutputFlags
+	FileFlags         FileFlags
+	RegistryFlags     RegistryFlags
+	OutputFormatFlags OutputFormatFlags
+
+	ValidateImages bool
+	Compression    string
+	Tags           []string
+	MaxBundleSize  string
+	MaxFiles       int
 }
 
-func NewPushOptions(ui ui.UI) *PushOptions {
-	return &PushOptions{ui: ui}
+func NewPushOptions(ui ui.UI, logFlags *LogFlags) *PushOptions {
+	return &PushOptions{ui: ui, logFlags: logFlags}
 }
 
 func NewPushCmd(o *PushOptions) *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "push",
 		Short: "Push files as image",
-		RunE:  func(_ *cobra.Command, _ []string) error { return o.Run() },
+		RunE:  func(cmd *cobra.Command, _ []string) error { return o.RunContext(cmd.Context()) },
 		Example: `
   # Push bundle repo/app1-config with contents of config/ directory
   imgpkg push -b repo/app1-config -f config/
 
   # Push image repo/app1-config with contents from multiple locations
-  imgpkg push -i repo/app1-config -f config/ -f additional-config.yml`,
+  imgpkg push -i repo/app1-config -f config/ -f additional-config.yml
+
+  # Push bundle repo/app1-config tagged with both v1.2.3 and latest
+  imgpkg push -b repo/app1-config:v1.2.3 --tag latest -f config/
+
+  # Push bundle repo/app1-config, failing if config/ holds more than 100MiB or 1000 files
+  imgpkg push -b repo/app1-config -f config/ --max-bundle-size 100Mi --max-files 1000
+
+  # Push image repo/app1-config with a tar produced by the build as its layer
+  build-config | imgpkg push -i repo/app1-config --file-tar -`,
 	}
 	o.ImageFlags.Set(cmd)
 	o.BundleFlags.Set(cmd)
 	o.LockOutputFlags.Set(cmd)
 	o.FileFlags.Set(cmd)
 	o.RegistryFlags.Set(cmd)
+	o.OutputFormatFlags.Set(cmd)
+	cmd.Flags().BoolVar(&o.ValidateImages, "validate-images", false,
+		"Validate that every image referenced in the bundle's .imgpkg/images.yml exists before pushing")
+	cmd.Flags().StringVar(&o.Compression, "compression", "gzip", "Set layer compression (gzip, zstd)")
+	cmd.Flags().StringSliceVar(&o.Tags, "tag", nil, "Additional tag to apply to the pushed image or bundle (can be specified multiple times)")
+	cmd.Flags().StringVar(&o.MaxBundleSize, "max-bundle-size", "",
+		"Fail if the files to push total more than this size (format: 500000, 100M, 100Mi) (no limit by default)")
+	cmd.Flags().IntVar(&o.MaxFiles, "max-files", 0, "Fail if there are more files to push than this number (no limit by default)")
 	return cmd
 }
 
 func (po *PushOptions) Run() error {
-	reg, err := registry.NewRegistry(po.RegistryFlags.AsRegistryOpts())
+	return po.RunContext(context.Background())
+}
+
+// RunContext pushes the same way as Run, but registry requests are aborted once ctx is done
+func (po *PushOptions) RunContext(ctx context.Context) error {
+	err := po.OutputFormatFlags.Validate()
+	if err != nil {
+		return err
+	}
+
+	registryOpts, err := po.RegistryFlags.AsRegistryOpts()
+	if err != nil {
+		return err
+	}
+	registryOpts.Logger = po.logFlags.NewRegistryLogger(os.Stderr)
+
+	reg, err := registry.NewRegistry(registryOpts)
 	if err != nil {
 		return fmt.Errorf("Unable to create a registry with provided options: %v", err)
 	}
 
-	var imageURL string
+	var result v1.PushResult
 
 	isBundle := po.BundleFlags.Bundle != ""
 	isImage := po.ImageFlags.Image != ""
@@ -67,14 +111,20 @@ func (po *PushOptions) Run() error {
 	case !isBundle && !isImage:
 		return fmt.Errorf("Expected either image or bundle")
 
+	case po.FileFlags.FileTar != "" && len(po.FileFlags.Files) > 0:
+		return fmt.Errorf("Expected only one of --file (-f) or --file-tar")
+
+	case isBundle && po.FileFlags.FileTar != "":
+		return fmt.Errorf("Expected --file-tar to be used with image (hint: Bundles are pushed from a directory containing '.imgpkg')")
+
 	case isBundle:
-		imageURL, err = po.pushBundle(reg)
+		result, err = po.pushBundle(ctx, reg)
 		if err != nil {
 			return err
 		}
 
 	case isImage:
-		imageURL, err = po.pushImage(reg)
+		result, err = po.pushImage(ctx, reg)
 		if err != nil {
 			return err
 		}
@@ -83,20 +133,28 @@ func (po *PushOptions) Run() error {
 		panic("Unreachable code")
 	}
 
-	po.ui.BeginLinef("Pushed '%s'", imageURL)
+	if po.OutputFormatFlags.IsJSON() {
+		output, err := newImageOutput(result.DigestRef, result.Tag, result.Images)
+		if err != nil {
+			return err
+		}
+		return po.OutputFormatFlags.PrintResult(po.ui, output)
+	}
+
+	po.ui.BeginLinef("Pushed '%s'", result.DigestRef)
 
 	return nil
 }
 
-func (po *PushOptions) pushBundle(registry registry.Registry) (string, error) {
-	uploadRef, err := regname.NewTag(po.BundleFlags.Bundle, regname.WeakValidation)
+func (po *PushOptions) pushBundle(ctx context.Context, registry registry.Registry) (v1.PushResult, error) {
+	opts, err := po.pushOpts()
 	if err != nil {
-		return "", fmt.Errorf("Parsing '%s': %s", po.BundleFlags.Bundle, err)
+		return v1.PushResult{}, err
 	}
 
-	imageURL, err := bundle.NewContents(po.FileFlags.Files, po.FileFlags.ExcludedFilePaths).Push(uploadRef, registry, po.ui)
+	result, err := v1.PushBundle(ctx, po.BundleFlags.Bundle, opts, registry, po.logFlags.ProgressLogger(po.OutputFormatFlags.NewLogger(po.ui)))
 	if err != nil {
-		return "", err
+		return v1.PushResult{}, err
 	}
 
 	if po.LockOutputFlags.LockFilePath != "" {
@@ -106,37 +164,111 @@ func (po *PushOptions) pushBundle(registry registry.Registry) (string, error) {
 				Kind:       lockconfig.BundleLockKind,
 			},
 			Bundle: lockconfig.BundleRef{
-				Image: imageURL,
-				Tag:   uploadRef.TagStr(),
+				Image: result.DigestRef,
+				Tag:   result.Tag,
 			},
 		}
 
 		err := bundleLock.WriteToPath(po.LockOutputFlags.LockFilePath)
 		if err != nil {
-			return "", err
+			return v1.PushResult{}, err
 		}
 	}
 
-	return imageURL, nil
+	return result, nil
 }
 
-func (po *PushOptions) pushImage(registry registry.Registry) (string, error) {
+func (po *PushOptions) pushImage(ctx context.Context, registry registry.Registry) (v1.PushResult, error) {
 	if po.LockOutputFlags.LockFilePath != "" {
-		return "", fmt.Errorf("Lock output is not compatible with image, use bundle for lock output")
+		return v1.PushResult{}, fmt.Errorf("Lock output is not compatible with image, use bundle for lock output")
 	}
 
-	uploadRef, err := regname.NewTag(po.ImageFlags.Image, regname.WeakValidation)
+	opts, err := po.pushOpts()
 	if err != nil {
-		return "", fmt.Errorf("Parsing '%s': %s", po.ImageFlags.Image, err)
+		return v1.PushResult{}, err
 	}
 
-	isBundle, err := bundle.NewContents(po.FileFlags.Files, po.FileFlags.ExcludedFilePaths).PresentsAsBundle()
+	if po.FileFlags.FileTar != "" {
+		fileTar, err := po.FileFlags.OpenFileTar()
+		if err != nil {
+			return v1.PushResult{}, err
+		}
+
+		defer fileTar.Close()
+
+		opts.FileTar = fileTar
+	}
+
+	result, err := v1.PushImage(ctx, po.ImageFlags.Image, opts, registry, po.logFlags.ProgressLogger(po.OutputFormatFlags.NewLogger(po.ui)))
+	if err != nil {
+		if v1.IsBundleError(err) {
+			return v1.PushResult{}, fmt.Errorf("Images cannot be pushed with '.imgpkg' directories, consider using --bundle (-b) option")
+		}
+		return v1.PushResult{}, err
+	}
+
+	return result, nil
+}
+
+func (po *PushOptions) pushOpts() (v1.PushOpts, error) {
+	var maxSize int64
+	if po.MaxBundleSize != "" {
+		var err error
+		maxSize, err = parseSize(po.MaxBundleSize)
+		if err != nil || maxSize <= 0 {
+			return v1.PushOpts{}, fmt.Errorf("Expected --max-bundle-size to be a positive number of bytes "+
+				"with an optional suffix (e.g. 500000, 100M, 100Mi), but was '%s'", po.MaxBundleSize)
+		}
+	}
+
+	if po.MaxFiles < 0 {
+		return v1.PushOpts{}, fmt.Errorf("Expected --max-files to be a positive number, but was '%d'", po.MaxFiles)
+	}
+
+	return v1.PushOpts{
+		Paths:          po.FileFlags.Files,
+		ExcludedPaths:  po.FileFlags.ExcludedFilePaths,
+		ValidateImages: po.ValidateImages,
+		Compression:    po.Compression,
+		AdditionalTags: po.Tags,
+		MaxSize:        maxSize,
+		MaxFiles:       po.MaxFiles,
+	}, nil
+}
+
+// sizeSuffixes are the decimal and binary multiples accepted by parseSize
+var sizeSuffixes = map[string]int64{
+	"":   1,
+	"K":  1000,
+	"M":  1000 * 1000,
+	"G":  1000 * 1000 * 1000,
+	"T":  1000 * 1000 * 1000 * 1000,
+	"Ki": 1 << 10,
+	"Mi": 1 << 20,
+	"Gi": 1 << 30,
+	"Ti": 1 << 40,
+}
+
+// parseSize converts sizes such as 500000, 100M or 100Mi to bytes
+func parseSize(size string) (int64, error) {
+	numberEnd := strings.IndexFunc(size, func(r rune) bool { return r < '0' || r > '9' })
+	if numberEnd == -1 {
+		numberEnd = len(size)
+	}
+
+	multiplier, found := sizeSuffixes[size[numberEnd:]]
+	if !found {
+		return 0, fmt.Errorf("Unknown size suffix '%s'", size[numberEnd:])
+	}
+
+	number, err := strconv.ParseInt(size[:numberEnd], 10, 64)
 	if err != nil {
-		return "", err
+		return 0, err
 	}
-	if isBundle {
-		return "", fmt.Errorf("Images cannot be pushed with '.imgpkg' directories, consider using --bundle (-b) option")
+
+	if number > math.MaxInt64/multiplier {
+		return 0, fmt.Errorf("Size '%s' is too large", size)
 	}
 
-	return plainimage.NewContents(po.FileFlags.Files, po.FileFlags.ExcludedFilePaths).Push(uploadRef, nil, registry, po.ui)
+	return number * multiplier, nil
 }
@@ -4,11 +4,23 @@
 package cmd
 
 import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
 	"io/ioutil"
 	"os"
 	"path/filepath"
 	"strings"
 	"testing"
+
+	"github.com/cppforlife/go-cli-ui/ui"
+	regname "github.com/google/go-containerregistry/pkg/name"
+	"github.com/k14s/imgpkg/pkg/imgpkg/lockconfig"
+	"github.com/k14s/imgpkg/test/helpers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 const emptyImagesYaml = `apiVersion: imgpkg.carvel.dev/v1alpha1
@@ -211,6 +223,123 @@ func TestImageAndBundleLockError(t *testing.T) {
 	}
 }
 
+func TestPushBundleValidatingImages(t *testing.T) {
+	fakeRegistry := helpers.NewFakeRegistry(t)
+	defer fakeRegistry.CleanUp()
+	existingImage := fakeRegistry.WithRandomImage("library/existing-image")
+	fakeRegistry.Build()
+
+	missingImageRef := fakeRegistry.ReferenceOnTestServer("library/missing-image@sha256:703218c0465075f4425e58fac086e09e1de5c340b12976ab9eb8ad26615c3715")
+
+	pushDir, err := ioutil.TempDir("", "imgpkg-push-units-validate-images")
+	require.NoError(t, err)
+	defer Cleanup(pushDir)
+
+	err = createBundleDir(pushDir, fmt.Sprintf(`---
+apiVersion: imgpkg.carvel.dev/v1alpha1
+kind: ImagesLock
+images:
+- image: %s
+- image: %s
+`, existingImage.RefDigest, missingImageRef))
+	require.NoError(t, err)
+
+	confUI := ui.NewConfUI(ui.NewNoopLogger())
+	defer confUI.Flush()
+
+	t.Run("when a referenced image does not exist, it fails naming the reference", func(t *testing.T) {
+		push := PushOptions{
+			ui:             confUI,
+			FileFlags:      FileFlags{Files: []string{pushDir}},
+			BundleFlags:    BundleFlags{Bundle: fakeRegistry.ReferenceOnTestServer("library/bundle")},
+			ValidateImages: true,
+		}
+
+		err := push.Run()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "Expected every image referenced in '.imgpkg/images.yml' to exist, but unable to find:")
+		assert.Contains(t, err.Error(), "- "+missingImageRef)
+		assert.NotContains(t, err.Error(), existingImage.RefDigest)
+
+		_, err = fakeRegistry.Build().Digest(context.Background(), mustParseTag(t, fakeRegistry.ReferenceOnTestServer("library/bundle")))
+		assert.Error(t, err, "expected bundle to not be pushed")
+	})
+
+	t.Run("when validation is not requested, it pushes the bundle", func(t *testing.T) {
+		push := PushOptions{
+			ui:          confUI,
+			FileFlags:   FileFlags{Files: []string{pushDir}},
+			BundleFlags: BundleFlags{Bundle: fakeRegistry.ReferenceOnTestServer("library/bundle")},
+		}
+
+		require.NoError(t, push.Run())
+	})
+}
+
+func TestPushWithOutputFormatJSON(t *testing.T) {
+	fakeRegistry := helpers.NewFakeRegistry(t)
+	defer fakeRegistry.CleanUp()
+	image := fakeRegistry.WithRandomImage("library/image")
+	fakeRegistry.Build()
+
+	pushDir, err := ioutil.TempDir("", "imgpkg-push-units-output-format")
+	require.NoError(t, err)
+	defer Cleanup(pushDir)
+
+	err = createBundleDir(pushDir, fmt.Sprintf("%simages:\n- image: %s\n", emptyImagesYaml, image.RefDigest))
+	require.NoError(t, err)
+
+	t.Run("prints the pushed bundle as JSON and nothing else to stdout", func(t *testing.T) {
+		stdout := &bytes.Buffer{}
+		push := PushOptions{
+			ui:                ui.NewWriterUI(stdout, ioutil.Discard, ui.NewNoopLogger()),
+			FileFlags:         FileFlags{Files: []string{pushDir}},
+			BundleFlags:       BundleFlags{Bundle: fakeRegistry.ReferenceOnTestServer("library/bundle:v1")},
+			OutputFormatFlags: OutputFormatFlags{OutputFormat: "json"},
+		}
+		require.NoError(t, push.Run())
+
+		var output map[string]interface{}
+		require.NoError(t, json.Unmarshal(stdout.Bytes(), &output), "expected stdout to only contain JSON, got: %s", stdout)
+
+		assert.Len(t, output, 4)
+		assert.Regexp(t, "^sha256:[a-f0-9]{64}$", output["digest"])
+		assert.Equal(t, "v1", output["tag"])
+		assert.Equal(t, fakeRegistry.ReferenceOnTestServer("library/bundle"), output["repository"])
+		assert.Equal(t, []interface{}{image.RefDigest}, output["images"])
+	})
+
+	t.Run("when output format is not provided, it prints the human readable result", func(t *testing.T) {
+		stdout := &bytes.Buffer{}
+		push := PushOptions{
+			ui:          ui.NewWriterUI(stdout, ioutil.Discard, ui.NewNoopLogger()),
+			FileFlags:   FileFlags{Files: []string{pushDir}},
+			BundleFlags: BundleFlags{Bundle: fakeRegistry.ReferenceOnTestServer("library/bundle:v1")},
+		}
+		require.NoError(t, push.Run())
+
+		assert.Contains(t, stdout.String(), "Pushed '"+fakeRegistry.ReferenceOnTestServer("library/bundle")+"@sha256:")
+	})
+
+	t.Run("when output format is unknown, it errors", func(t *testing.T) {
+		push := PushOptions{
+			FileFlags:         FileFlags{Files: []string{pushDir}},
+			BundleFlags:       BundleFlags{Bundle: fakeRegistry.ReferenceOnTestServer("library/bundle:v1")},
+			OutputFormatFlags: OutputFormatFlags{OutputFormat: "yaml"},
+		}
+
+		err := push.Run()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "Expected --output-format to be 'json', but was 'yaml'")
+	})
+}
+
+func mustParseTag(t *testing.T, ref string) regname.Tag {
+	tag, err := regname.NewTag(ref)
+	require.NoError(t, err)
+	return tag
+}
+
 func Cleanup(dirs ...string) {
 	for _, dir := range dirs {
 		os.RemoveAll(dir)
@@ -230,3 +359,179 @@ func createBundleDir(loc, imagesYaml string) error {
 
 	return ioutil.WriteFile(filepath.Join(bundleDir, "images.yml"), []byte(imagesYaml), 0600)
 }
+
+func TestPushWithFileTar(t *testing.T) {
+	fakeRegistry := helpers.NewFakeRegistry(t)
+	defer fakeRegistry.CleanUp()
+	fakeRegistry.Build()
+
+	tarPath := filepath.Join(t.TempDir(), "layer.tar")
+	tarFile, err := os.Create(tarPath)
+	require.NoError(t, err)
+	tarWriter := tar.NewWriter(tarFile)
+	require.NoError(t, tarWriter.WriteHeader(&tar.Header{Name: "config", Typeflag: tar.TypeDir, Mode: 0700}))
+	require.NoError(t, tarWriter.WriteHeader(&tar.Header{Name: "config/app.yml", Typeflag: tar.TypeReg, Mode: 0600, Size: int64(len("key: value\n"))}))
+	_, err = tarWriter.Write([]byte("key: value\n"))
+	require.NoError(t, err)
+	require.NoError(t, tarWriter.Close())
+	require.NoError(t, tarFile.Close())
+
+	t.Run("pushes the tar as the image layer, which pulls into the same files", func(t *testing.T) {
+		stdout := &bytes.Buffer{}
+		push := PushOptions{
+			ui:                ui.NewWriterUI(stdout, ioutil.Discard, ui.NewNoopLogger()),
+			FileFlags:         FileFlags{FileTar: tarPath},
+			ImageFlags:        ImageFlags{Image: fakeRegistry.ReferenceOnTestServer("library/image:v1")},
+			OutputFormatFlags: OutputFormatFlags{OutputFormat: "json"},
+		}
+		require.NoError(t, push.Run())
+
+		var output imageOutput
+		require.NoError(t, json.Unmarshal(stdout.Bytes(), &output))
+
+		outputPath := filepath.Join(t.TempDir(), "pulled")
+		pull := PullOptions{
+			ui:         ui.NewWriterUI(ioutil.Discard, ioutil.Discard, ui.NewNoopLogger()),
+			ImageFlags: ImageFlags{Image: output.Repository + "@" + output.Digest},
+			OutputPath: outputPath,
+		}
+		require.NoError(t, pull.Run())
+
+		contents, err := ioutil.ReadFile(filepath.Join(outputPath, "config", "app.yml"))
+		require.NoError(t, err)
+		assert.Equal(t, "key: value\n", string(contents))
+
+		_, err = os.Stat(tarPath)
+		assert.NoError(t, err, "expected the provided tar to be kept")
+	})
+
+	t.Run("when files are provided as well, it errors", func(t *testing.T) {
+		push := PushOptions{
+			FileFlags:  FileFlags{FileTar: tarPath, Files: []string{t.TempDir()}},
+			ImageFlags: ImageFlags{Image: fakeRegistry.ReferenceOnTestServer("library/image")},
+		}
+		require.EqualError(t, push.Run(), "Expected only one of --file (-f) or --file-tar")
+	})
+
+	t.Run("when pushing a bundle, it errors", func(t *testing.T) {
+		push := PushOptions{
+			FileFlags:   FileFlags{FileTar: tarPath},
+			BundleFlags: BundleFlags{Bundle: fakeRegistry.ReferenceOnTestServer("library/bundle")},
+		}
+		err := push.Run()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "Expected --file-tar to be used with image")
+	})
+}
+
+func TestPushWithAdditionalTags(t *testing.T) {
+	fakeRegistry := helpers.NewFakeRegistry(t)
+	defer fakeRegistry.CleanUp()
+	reg := fakeRegistry.Build()
+
+	pushDir := t.TempDir()
+	require.NoError(t, createBundleDir(pushDir, emptyImagesYaml))
+
+	t.Run("it points every tag to the pushed bundle and records the primary tag in the lock", func(t *testing.T) {
+		lockPath := filepath.Join(t.TempDir(), "bundle.yml")
+		push := PushOptions{
+			ui:              ui.NewWriterUI(ioutil.Discard, ioutil.Discard, ui.NewNoopLogger()),
+			FileFlags:       FileFlags{Files: []string{pushDir}},
+			BundleFlags:     BundleFlags{Bundle: fakeRegistry.ReferenceOnTestServer("library/bundle:v1")},
+			LockOutputFlags: LockOutputFlags{LockFilePath: lockPath},
+			Tags:            []string{"v1.2.3", "latest"},
+		}
+		require.NoError(t, push.Run())
+
+		bundleLock, err := lockconfig.NewBundleLockFromPath(lockPath)
+		require.NoError(t, err)
+		assert.Equal(t, "v1", bundleLock.Bundle.Tag)
+
+		pushedDigestRef, err := regname.NewDigest(bundleLock.Bundle.Image)
+		require.NoError(t, err)
+
+		for _, tag := range []string{"v1", "v1.2.3", "latest"} {
+			tagRef, err := regname.NewTag(fakeRegistry.ReferenceOnTestServer("library/bundle:" + tag))
+			require.NoError(t, err)
+			digest, err := reg.Digest(context.Background(), tagRef)
+			require.NoError(t, err)
+			assert.Equal(t, pushedDigestRef.DigestStr(), digest.String(), "expected tag '%s' to point to the pushed bundle", tag)
+		}
+	})
+
+	t.Run("when a tag is invalid, it errors before pushing", func(t *testing.T) {
+		push := PushOptions{
+			ui:          ui.NewWriterUI(ioutil.Discard, ioutil.Discard, ui.NewNoopLogger()),
+			FileFlags:   FileFlags{Files: []string{pushDir}},
+			BundleFlags: BundleFlags{Bundle: fakeRegistry.ReferenceOnTestServer("library/invalid-tag-bundle:v1")},
+			Tags:        []string{"valid", "not/valid"},
+		}
+		err := push.Run()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "Parsing tag 'not/valid'")
+
+		tagRef, err := regname.NewTag(fakeRegistry.ReferenceOnTestServer("library/invalid-tag-bundle:v1"))
+		require.NoError(t, err)
+		_, err = reg.Digest(context.Background(), tagRef)
+		assert.Error(t, err, "expected nothing to be pushed")
+	})
+}
+
+func TestPushWithLimits(t *testing.T) {
+	fakeRegistry := helpers.NewFakeRegistry(t)
+	defer fakeRegistry.CleanUp()
+	fakeRegistry.Build()
+
+	pushDir := t.TempDir()
+	require.NoError(t, createBundleDir(pushDir, emptyImagesYaml))
+	require.NoError(t, os.MkdirAll(filepath.Join(pushDir, "node_modules"), 0700))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(pushDir, "node_modules", "index.js"), bytes.Repeat([]byte("a"), 2000), 0600))
+
+	t.Run("when the files total more than --max-bundle-size, it errors naming the largest paths", func(t *testing.T) {
+		push := PushOptions{
+			ui:            ui.NewWriterUI(ioutil.Discard, ioutil.Discard, ui.NewNoopLogger()),
+			FileFlags:     FileFlags{Files: []string{pushDir}},
+			BundleFlags:   BundleFlags{Bundle: fakeRegistry.ReferenceOnTestServer("library/bundle")},
+			MaxBundleSize: "1K",
+		}
+		err := push.Run()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "Expected files to total at most 1000 B, but they total 2.0 KiB. Largest paths:\n- "+filepath.Join(pushDir, "node_modules")+" (2.0 KiB)")
+	})
+
+	t.Run("when there are more files than --max-files, it errors", func(t *testing.T) {
+		push := PushOptions{
+			ui:          ui.NewWriterUI(ioutil.Discard, ioutil.Discard, ui.NewNoopLogger()),
+			FileFlags:   FileFlags{Files: []string{pushDir}},
+			BundleFlags: BundleFlags{Bundle: fakeRegistry.ReferenceOnTestServer("library/bundle")},
+			MaxFiles:    1,
+		}
+		err := push.Run()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "Expected at most 1 files, but found 2.")
+	})
+
+	t.Run("when the files are within the limits, it pushes the bundle", func(t *testing.T) {
+		push := PushOptions{
+			ui:            ui.NewWriterUI(ioutil.Discard, ioutil.Discard, ui.NewNoopLogger()),
+			FileFlags:     FileFlags{Files: []string{pushDir}},
+			BundleFlags:   BundleFlags{Bundle: fakeRegistry.ReferenceOnTestServer("library/bundle")},
+			MaxBundleSize: "1Mi",
+			MaxFiles:      2,
+		}
+		require.NoError(t, push.Run())
+	})
+
+	t.Run("when --max-bundle-size is not a size, it errors", func(t *testing.T) {
+		for _, size := range []string{"100MB", "-1", "0", "Mi", "1.5G", "99999999999Ti"} {
+			push := PushOptions{
+				FileFlags:     FileFlags{Files: []string{pushDir}},
+				BundleFlags:   BundleFlags{Bundle: fakeRegistry.ReferenceOnTestServer("library/bundle")},
+				MaxBundleSize: size,
+			}
+			err := push.Run()
+			require.Error(t, err, "size: %s", size)
+			assert.Contains(t, err.Error(), "Expected --max-bundle-size to be a positive number of bytes with an optional suffix", "size: %s", size)
+		}
+	})
+}
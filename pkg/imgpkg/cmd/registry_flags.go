@@ -4,21 +4,33 @@
 package cmd
 
 import (
+	"fmt"
+	"io/ioutil"
 	"os"
+	"strings"
 
 	"github.com/k14s/imgpkg/pkg/imgpkg/registry"
 	"github.com/spf13/cobra"
 )
 
+// registryCacheSize bounds the manifests and configs kept in memory
+// so that images referenced multiple times are fetched only once
+const registryCacheSize = 1000
+
 type RegistryFlags struct {
 	CACertPaths []string
 	VerifyCerts bool
 	Insecure    bool
 
-	Username string
-	Password string
-	Token    string
-	Anon     bool
+	Username     string
+	Password     string
+	PasswordFile string
+	Token        string
+	TokenFile    string
+	Anon         bool
+
+	UserAgent         string
+	RequestsPerSecond float64
 }
 
 func (r *RegistryFlags) Set(cmd *cobra.Command) {
@@ -28,11 +40,20 @@ func (r *RegistryFlags) Set(cmd *cobra.Command) {
 
 	cmd.Flags().StringVar(&r.Username, "registry-username", "", "Set username for auth ($IMGPKG_USERNAME)")
 	cmd.Flags().StringVar(&r.Password, "registry-password", "", "Set password for auth ($IMGPKG_PASSWORD)")
+	cmd.Flags().StringVar(&r.PasswordFile, "registry-password-file", "", "Set path to file containing password for auth ($IMGPKG_PASSWORD_FILE)")
 	cmd.Flags().StringVar(&r.Token, "registry-token", "", "Set token for auth ($IMGPKG_TOKEN)")
+	cmd.Flags().StringVar(&r.TokenFile, "registry-token-file", "", "Set path to file containing token for auth ($IMGPKG_TOKEN_FILE)")
 	cmd.Flags().BoolVar(&r.Anon, "registry-anon", false, "Set anonymous auth ($IMGPKG_ANON)")
+
+	cmd.Flags().StringVar(&r.UserAgent, "registry-user-agent", "", "Append to the User-Agent sent to the registry (e.g. CI job id)")
+	cmd.Flags().Float64Var(&r.RequestsPerSecond, "registry-rps", 0, "Limit requests sent to registries per second, requests over the limit wait (0 means no limit)")
 }
 
-func (r *RegistryFlags) AsRegistryOpts() registry.Opts {
+func (r *RegistryFlags) AsRegistryOpts() (registry.Opts, error) {
+	if r.RequestsPerSecond < 0 {
+		return registry.Opts{}, fmt.Errorf("Expected --registry-rps to be >= 0, but was %v", r.RequestsPerSecond)
+	}
+
 	opts := registry.Opts{
 		CACertPaths: r.CACertPaths,
 		VerifyCerts: r.VerifyCerts,
@@ -42,6 +63,26 @@ func (r *RegistryFlags) AsRegistryOpts() registry.Opts {
 		Password: r.Password,
 		Token:    r.Token,
 		Anon:     r.Anon,
+
+		UserAgent:         r.userAgent(),
+		CacheSize:         registryCacheSize,
+		RequestsPerSecond: r.RequestsPerSecond,
+	}
+
+	password, err := r.secretFromFile("password", r.Password, r.PasswordFile, "IMGPKG_PASSWORD_FILE")
+	if err != nil {
+		return registry.Opts{}, err
+	}
+	if len(password) > 0 {
+		opts.Password = password
+	}
+
+	token, err := r.secretFromFile("token", r.Token, r.TokenFile, "IMGPKG_TOKEN_FILE")
+	if err != nil {
+		return registry.Opts{}, err
+	}
+	if len(token) > 0 {
+		opts.Token = token
 	}
 
 	if len(opts.Username) == 0 {
@@ -57,5 +98,36 @@ func (r *RegistryFlags) AsRegistryOpts() registry.Opts {
 		opts.Anon = true
 	}
 
-	return opts
+	return opts, nil
+}
+
+func (r *RegistryFlags) userAgent() string {
+	userAgent := "imgpkg/" + Version
+	if len(r.UserAgent) > 0 {
+		userAgent += " " + r.UserAgent
+	}
+	return userAgent
+}
+
+// secretFromFile reads a secret from the path given via the --registry-<name>-file flag
+// (or its env variable). Secret files take precedence over env variables holding the secret
+// itself, but cannot be combined with the inline --registry-<name> flag.
+func (r *RegistryFlags) secretFromFile(name, inlineVal, path, pathEnvVar string) (string, error) {
+	if len(path) == 0 {
+		path = os.Getenv(pathEnvVar)
+	}
+	if len(path) == 0 {
+		return "", nil
+	}
+
+	if len(inlineVal) > 0 {
+		return "", fmt.Errorf("Expected only one of --registry-%s or --registry-%s-file ($%s) to be provided", name, name, pathEnvVar)
+	}
+
+	bs, err := ioutil.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("Reading registry %s file: %s", name, err)
+	}
+
+	return strings.TrimRight(string(bs), "\r\n"), nil
 }
@@ -0,0 +1,122 @@
+// Copyright 2021 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegistryFlagsSecretsFromFiles(t *testing.T) {
+	secretsDir, err := ioutil.TempDir("", "imgpkg-registry-flags")
+	require.NoError(t, err)
+	defer os.RemoveAll(secretsDir)
+
+	passwordFile := filepath.Join(secretsDir, "password")
+	require.NoError(t, ioutil.WriteFile(passwordFile, []byte("password-from-file\n"), 0600))
+
+	tokenFile := filepath.Join(secretsDir, "token")
+	require.NoError(t, ioutil.WriteFile(tokenFile, []byte("token-from-file\r\n"), 0600))
+
+	t.Run("reads password and token from files trimming trailing newlines", func(t *testing.T) {
+		flags := RegistryFlags{PasswordFile: passwordFile, TokenFile: tokenFile}
+
+		opts, err := flags.AsRegistryOpts()
+		require.NoError(t, err)
+		assert.Equal(t, "password-from-file", opts.Password)
+		assert.Equal(t, "token-from-file", opts.Token)
+	})
+
+	t.Run("reads password and token from files provided via env variables", func(t *testing.T) {
+		setEnv(t, "IMGPKG_PASSWORD_FILE", passwordFile)
+		setEnv(t, "IMGPKG_TOKEN_FILE", tokenFile)
+
+		opts, err := (&RegistryFlags{}).AsRegistryOpts()
+		require.NoError(t, err)
+		assert.Equal(t, "password-from-file", opts.Password)
+		assert.Equal(t, "token-from-file", opts.Token)
+	})
+
+	t.Run("files take precedence over secrets provided via env variables", func(t *testing.T) {
+		setEnv(t, "IMGPKG_PASSWORD", "password-from-env")
+		setEnv(t, "IMGPKG_TOKEN", "token-from-env")
+
+		flags := RegistryFlags{PasswordFile: passwordFile, TokenFile: tokenFile}
+
+		opts, err := flags.AsRegistryOpts()
+		require.NoError(t, err)
+		assert.Equal(t, "password-from-file", opts.Password)
+		assert.Equal(t, "token-from-file", opts.Token)
+	})
+
+	t.Run("when both --registry-password and --registry-password-file are provided, it errors", func(t *testing.T) {
+		flags := RegistryFlags{Password: "inline-password", PasswordFile: passwordFile}
+
+		_, err := flags.AsRegistryOpts()
+		require.EqualError(t, err, "Expected only one of --registry-password or --registry-password-file ($IMGPKG_PASSWORD_FILE) to be provided")
+	})
+
+	t.Run("when both --registry-token and $IMGPKG_TOKEN_FILE are provided, it errors", func(t *testing.T) {
+		setEnv(t, "IMGPKG_TOKEN_FILE", tokenFile)
+
+		flags := RegistryFlags{Token: "inline-token"}
+
+		_, err := flags.AsRegistryOpts()
+		require.EqualError(t, err, "Expected only one of --registry-token or --registry-token-file ($IMGPKG_TOKEN_FILE) to be provided")
+	})
+
+	t.Run("when the file does not exist, it errors", func(t *testing.T) {
+		flags := RegistryFlags{PasswordFile: filepath.Join(secretsDir, "does-not-exist")}
+
+		_, err := flags.AsRegistryOpts()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "Reading registry password file")
+	})
+}
+
+func setEnv(t *testing.T, key, value string) {
+	prevValue, wasSet := os.LookupEnv(key)
+	require.NoError(t, os.Setenv(key, value))
+
+	t.Cleanup(func() {
+		if wasSet {
+			os.Setenv(key, prevValue)
+		} else {
+			os.Unsetenv(key)
+		}
+	})
+}
+
+func TestRegistryFlagsUserAgent(t *testing.T) {
+	t.Run("uses imgpkg version as the User-Agent", func(t *testing.T) {
+		opts, err := (&RegistryFlags{}).AsRegistryOpts()
+		require.NoError(t, err)
+		assert.Equal(t, "imgpkg/"+Version, opts.UserAgent)
+	})
+
+	t.Run("appends --registry-user-agent to the User-Agent", func(t *testing.T) {
+		opts, err := (&RegistryFlags{UserAgent: "ci-job-42"}).AsRegistryOpts()
+		require.NoError(t, err)
+		assert.Equal(t, "imgpkg/"+Version+" ci-job-42", opts.UserAgent)
+	})
+}
+
+func TestRegistryFlagsRequestsPerSecond(t *testing.T) {
+	t.Run("passes the limit to the registry", func(t *testing.T) {
+		opts, err := (&RegistryFlags{RequestsPerSecond: 2.5}).AsRegistryOpts()
+		require.NoError(t, err)
+		assert.Equal(t, 2.5, opts.RequestsPerSecond)
+	})
+
+	t.Run("when limit is negative, it errors", func(t *testing.T) {
+		_, err := (&RegistryFlags{RequestsPerSecond: -1}).AsRegistryOpts()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "Expected --registry-rps to be >= 0, but was -1")
+	})
+}
@@ -4,7 +4,9 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
+	"os"
 
 	"github.com/cppforlife/go-cli-ui/ui"
 	uitable "github.com/cppforlife/go-cli-ui/ui/table"
@@ -15,7 +17,8 @@ import (
 )
 
 type TagListOptions struct {
-	ui ui.UI
+	ui       ui.UI
+	logFlags *LogFlags
 
 	ImageFlags    ImageFlags
 	RegistryFlags RegistryFlags
@@ -24,8 +27,8 @@ type TagListOptions struct {
 
 var _ ctlimg.ImagesMetadata = registry.Registry{}
 
-func NewTagListOptions(ui ui.UI) *TagListOptions {
-	return &TagListOptions{ui: ui}
+func NewTagListOptions(ui ui.UI, logFlags *LogFlags) *TagListOptions {
+	return &TagListOptions{ui: ui, logFlags: logFlags}
 }
 
 func NewTagListCmd(o *TagListOptions) *cobra.Command {
@@ -33,7 +36,7 @@ func NewTagListCmd(o *TagListOptions) *cobra.Command {
 		Use:     "list",
 		Aliases: []string{"ls"},
 		Short:   "List tags for image",
-		RunE:    func(_ *cobra.Command, _ []string) error { return o.Run() },
+		RunE:    func(cmd *cobra.Command, _ []string) error { return o.RunContext(cmd.Context()) },
 	}
 	o.ImageFlags.Set(cmd)
 	o.RegistryFlags.Set(cmd)
@@ -42,9 +45,20 @@ func NewTagListCmd(o *TagListOptions) *cobra.Command {
 }
 
 func (t *TagListOptions) Run() error {
-	reg, err := registry.NewRegistry(t.RegistryFlags.AsRegistryOpts())
+	return t.RunContext(context.Background())
+}
+
+// RunContext lists tags the same way as Run, but registry requests are aborted once ctx is done
+func (t *TagListOptions) RunContext(ctx context.Context) error {
+	registryOpts, err := t.RegistryFlags.AsRegistryOpts()
+	if err != nil {
+		return err
+	}
+	registryOpts.Logger = t.logFlags.NewRegistryLogger(os.Stderr)
+
+	reg, err := registry.NewRegistry(registryOpts)
 	if err != nil {
-		return fmt.Errorf("Unable to create a registry with the options %v: %v", t.RegistryFlags.AsRegistryOpts(), err)
+		return fmt.Errorf("Unable to create a registry with the options %v: %v", registryOpts, err)
 	}
 
 	ref, err := regname.ParseReference(t.ImageFlags.Image, regname.WeakValidation)
@@ -52,7 +66,7 @@ func (t *TagListOptions) Run() error {
 		return err
 	}
 
-	tags, err := reg.ListTags(ref.Context())
+	tags, err := reg.ListTags(ctx, ref.Context())
 	if err != nil {
 		return err
 	}
@@ -80,7 +94,7 @@ func (t *TagListOptions) Run() error {
 				return err
 			}
 
-			hash, err := reg.Digest(tagRef)
+			hash, err := reg.Digest(ctx, tagRef)
 			if err != nil {
 				return err
 			}
@@ -4,14 +4,24 @@
 package cmd
 
 import (
+	"fmt"
+	"os"
+
 	"github.com/cppforlife/go-cli-ui/ui"
 	uitable "github.com/cppforlife/go-cli-ui/ui/table"
+	"github.com/fatih/color"
 	"github.com/spf13/cobra"
 )
 
+const (
+	colorAuto   = "auto"
+	colorAlways = "always"
+	colorNever  = "never"
+)
+
 type UIFlags struct {
 	TTY            bool
-	Color          bool
+	Color          string
 	JSON           bool
 	NonInteractive bool
 	Columns        []string
@@ -19,16 +29,31 @@ type UIFlags struct {
 
 func (f *UIFlags) Set(cmd *cobra.Command) {
 	cmd.PersistentFlags().BoolVar(&f.TTY, "tty", false, "Force TTY-like output")
-	cmd.PersistentFlags().BoolVar(&f.Color, "color", true, "Set color output")
+	cmd.PersistentFlags().StringVar(&f.Color, "color", colorAuto, "Set color output (auto, always, never); auto disables color when output is not a terminal or NO_COLOR is set")
+	// --color used to be a boolean flag, so it can still be given without a value
+	cmd.PersistentFlags().Lookup("color").NoOptDefVal = colorAlways
 	cmd.PersistentFlags().BoolVar(&f.JSON, "json", false, "Output as JSON")
 	cmd.PersistentFlags().BoolVarP(&f.NonInteractive, "yes", "y", false, "Assume yes for any prompt")
 	cmd.PersistentFlags().StringSliceVar(&f.Columns, "column", nil, "Filter to show only given columns")
 }
 
+func (f *UIFlags) Validate() error {
+	switch f.Color {
+	// true and false are accepted since --color used to be a boolean flag
+	case colorAuto, colorAlways, colorNever, "true", "false":
+		return nil
+	default:
+		return fmt.Errorf("Expected --color to be one of '%s', '%s' or '%s', but was '%s'", colorAuto, colorAlways, colorNever, f.Color)
+	}
+}
+
 func (f *UIFlags) ConfigureUI(ui *ui.ConfUI) {
 	ui.EnableTTY(f.TTY)
 
-	if f.Color {
+	// Decide for color library as well since by default
+	// it only checks whether stdout is a terminal
+	color.NoColor = !f.ColorEnabled(os.Stdout)
+	if !color.NoColor {
 		ui.EnableColor()
 	}
 
@@ -52,3 +77,20 @@ func (f *UIFlags) ConfigureUI(ui *ui.ConfUI) {
 		ui.ShowColumns(headers)
 	}
 }
+
+// ColorEnabled decides whether output written to file is colorized
+func (f *UIFlags) ColorEnabled(file *os.File) bool {
+	switch f.Color {
+	case colorAlways, "true":
+		return true
+	case colorNever, "false":
+		return false
+	default:
+		return os.Getenv("NO_COLOR") == "" && isTerminal(file)
+	}
+}
+
+func isTerminal(file *os.File) bool {
+	info, err := file.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
@@ -0,0 +1,104 @@
+// Copyright 2021 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUIFlagsColor(t *testing.T) {
+	// Files are never terminals, same as pipes used by scripts
+	nonTTY, err := os.Create(filepath.Join(t.TempDir(), "output"))
+	require.NoError(t, err)
+	defer nonTTY.Close()
+
+	t.Run("when color is auto and output is not a terminal, color is disabled", func(t *testing.T) {
+		flags := UIFlags{Color: "auto"}
+		require.NoError(t, flags.Validate())
+		assert.False(t, flags.ColorEnabled(nonTTY))
+	})
+
+	t.Run("when color is auto and NO_COLOR is set, color is disabled", func(t *testing.T) {
+		setEnv(t, "NO_COLOR", "1")
+
+		flags := UIFlags{Color: "auto"}
+		assert.False(t, flags.ColorEnabled(nonTTY))
+		assert.False(t, flags.ColorEnabled(os.Stderr))
+	})
+
+	t.Run("when color is always, color is enabled even if output is not a terminal", func(t *testing.T) {
+		setEnv(t, "NO_COLOR", "1")
+
+		flags := UIFlags{Color: "always"}
+		require.NoError(t, flags.Validate())
+		assert.True(t, flags.ColorEnabled(nonTTY))
+	})
+
+	t.Run("when color is true, color is enabled the same way as always", func(t *testing.T) {
+		flags := UIFlags{Color: "true"}
+		require.NoError(t, flags.Validate())
+		assert.True(t, flags.ColorEnabled(nonTTY))
+	})
+
+	t.Run("when --color is given without a value, color is always enabled", func(t *testing.T) {
+		cmd := &cobra.Command{}
+		flags := UIFlags{}
+		flags.Set(cmd)
+
+		require.NoError(t, cmd.ParseFlags([]string{"--color"}))
+		require.NoError(t, flags.Validate())
+		assert.Equal(t, "always", flags.Color)
+		assert.True(t, flags.ColorEnabled(nonTTY))
+	})
+
+	t.Run("when color is never or false, color is disabled", func(t *testing.T) {
+		for _, color := range []string{"never", "false"} {
+			flags := UIFlags{Color: color}
+			require.NoError(t, flags.Validate())
+			assert.False(t, flags.ColorEnabled(os.Stderr))
+		}
+	})
+
+	t.Run("when color is unknown, it errors", func(t *testing.T) {
+		flags := UIFlags{Color: "rainbow"}
+		require.EqualError(t, flags.Validate(), "Expected --color to be one of 'auto', 'always' or 'never', but was 'rainbow'")
+	})
+
+	t.Run("when color is disabled, no ANSI codes leak into log output", func(t *testing.T) {
+		setEnv(t, "NO_COLOR", "1")
+
+		logFlags := LogFlags{Debug: true, Color: (&UIFlags{Color: "auto"}).ColorEnabled(os.Stderr)}
+
+		var buf bytes.Buffer
+		logger := logFlags.NewLogger(&buf).NewPrefixedWriter("copy | ")
+		logger.WriteStr("copying 1 image\n")
+		logger.Debugf("GET /v2/\n")
+
+		assert.Equal(t, "copy | copying 1 image\ncopy | GET /v2/\n", buf.String())
+	})
+}
+
+func TestLogFlagsQuiet(t *testing.T) {
+	t.Run("when quiet, progress is not logged", func(t *testing.T) {
+		logFlags := LogFlags{Quiet: true}
+
+		var buf bytes.Buffer
+		logFlags.NewLogger(&buf).NewPrefixedWriter("copy | ").WriteStr("copying 1 image\n")
+		logFlags.ProgressLogger(writerLogger{&buf}).Logf("file: config.yml\n")
+
+		assert.Empty(t, buf.String())
+	})
+
+	t.Run("when quiet and debug, it errors", func(t *testing.T) {
+		logFlags := LogFlags{Quiet: true, Debug: true}
+		require.EqualError(t, logFlags.Validate(), "Expected only one of --debug or --quiet")
+	})
+}
@@ -0,0 +1,34 @@
+// Copyright 2021 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/cppforlife/go-cli-ui/ui"
+)
+
+// uiLogger forwards progress messages from the v1 API to the UI
+type uiLogger struct {
+	ui ui.UI
+}
+
+func (l uiLogger) Logf(msg string, args ...interface{}) {
+	l.ui.BeginLinef(msg, args...)
+}
+
+// writerLogger forwards progress messages from the v1 API to a writer
+type writerLogger struct {
+	writer io.Writer
+}
+
+func (l writerLogger) Logf(msg string, args ...interface{}) {
+	fmt.Fprintf(l.writer, msg, args...)
+}
+
+// noopLogger drops progress messages from the v1 API
+type noopLogger struct{}
+
+func (noopLogger) Logf(string, ...interface{}) {}
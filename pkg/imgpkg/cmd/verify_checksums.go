@@ -0,0 +1,71 @@
+// Copyright 2021 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/cppforlife/go-cli-ui/ui"
+	v1 "github.com/k14s/imgpkg/pkg/imgpkg/v1"
+	"github.com/spf13/cobra"
+)
+
+type VerifyChecksumsOptions struct {
+	ui ui.UI
+
+	OutputPath string
+}
+
+func NewVerifyChecksumsOptions(ui ui.UI) *VerifyChecksumsOptions {
+	return &VerifyChecksumsOptions{ui: ui}
+}
+
+func NewVerifyChecksumsCmd(o *VerifyChecksumsOptions) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "verify-checksums",
+		Short: "Verify files of a bundle pulled with --output-checksums",
+		RunE:  func(cmd *cobra.Command, _ []string) error { return o.RunContext(cmd.Context()) },
+		Example: `
+  # Verify that files pulled into /tmp/app1-bundle were not modified, added or removed
+  imgpkg verify-checksums -o /tmp/app1-bundle`,
+	}
+	cmd.Flags().StringVarP(&o.OutputPath, "output", "o", "", "Directory the bundle was pulled into")
+	cmd.MarkFlagRequired("output")
+	return cmd
+}
+
+func (o *VerifyChecksumsOptions) Run() error {
+	return o.RunContext(context.Background())
+}
+
+// RunContext verifies the same way as Run, but stops once ctx is done
+func (o *VerifyChecksumsOptions) RunContext(ctx context.Context) error {
+	if o.OutputPath == "" {
+		return fmt.Errorf("Expected --output to be none empty")
+	}
+
+	diff, err := v1.VerifyChecksums(ctx, o.OutputPath)
+	if err != nil {
+		return err
+	}
+
+	for _, path := range diff.Added {
+		o.ui.PrintLinef("added: %s", path)
+	}
+	for _, path := range diff.Removed {
+		o.ui.PrintLinef("removed: %s", path)
+	}
+	for _, path := range diff.Changed {
+		o.ui.PrintLinef("changed: %s", path)
+	}
+
+	if !diff.Empty() {
+		return fmt.Errorf("Expected files in '%s' to match their checksums, found %d added, %d removed and %d changed files",
+			o.OutputPath, len(diff.Added), len(diff.Removed), len(diff.Changed))
+	}
+
+	o.ui.PrintLinef("Files in '%s' match their checksums", o.OutputPath)
+	return nil
+}
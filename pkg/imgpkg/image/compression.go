@@ -0,0 +1,63 @@
+// Copyright 2021 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package image
+
+import (
+	"fmt"
+	"io"
+
+	regv1 "github.com/google/go-containerregistry/pkg/v1"
+	regtypes "github.com/google/go-containerregistry/pkg/v1/types"
+	"github.com/k14s/imgpkg/pkg/imgpkg/imageutils/zstd"
+)
+
+type Compression string
+
+const (
+	CompressionGzip Compression = "gzip"
+	CompressionZstd Compression = "zstd"
+
+	// Not yet defined by go-containerregistry
+	OCIZstdLayer regtypes.MediaType = "application/vnd.oci.image.layer.v1.tar+zstd"
+)
+
+var compressions = []Compression{CompressionGzip, CompressionZstd}
+
+func NewCompression(name string) (Compression, error) {
+	if len(name) == 0 {
+		return CompressionGzip, nil
+	}
+	for _, compression := range compressions {
+		if string(compression) == name {
+			return compression, nil
+		}
+	}
+	return "", fmt.Errorf("Expected compression to be one of %v, but was '%s'", compressions, name)
+}
+
+// UncompressedLayer decompresses layer according to its media type
+// since go-containerregistry layers always expect gzip compression
+func UncompressedLayer(layer regv1.Layer) (io.ReadCloser, error) {
+	mediaType, err := layer.MediaType()
+	if err != nil {
+		return nil, err
+	}
+
+	if mediaType != OCIZstdLayer {
+		return layer.Uncompressed()
+	}
+
+	compressedStream, err := layer.Compressed()
+	if err != nil {
+		return nil, err
+	}
+
+	stream, err := zstd.UnzipReadCloser(compressedStream)
+	if err != nil {
+		compressedStream.Close()
+		return nil, fmt.Errorf("Decompressing zstd layer: %s", err)
+	}
+
+	return stream, nil
+}
@@ -16,15 +16,20 @@ import (
 	regv1 "github.com/google/go-containerregistry/pkg/v1"
 )
 
+// copyBufferSize bounds how much of a layer is held in memory while
+// its files are written to disk
+const copyBufferSize = 32 * 1024
+
 type DirImage struct {
 	dirPath     string
 	img         regv1.Image
 	shouldChown bool
 	ui          goui.UI
+	copyBuf     []byte
 }
 
 func NewDirImage(dirPath string, img regv1.Image, ui goui.UI) *DirImage {
-	return &DirImage{dirPath, img, os.Getuid() == 0, ui}
+	return &DirImage{dirPath, img, os.Getuid() == 0, ui, make([]byte, copyBufferSize)}
 }
 
 func (i *DirImage) AsDirectory() error {
@@ -51,20 +56,26 @@ func (i *DirImage) AsDirectory() error {
 
 		i.ui.BeginLinef("Extracting layer '%s' (%d/%d)\n", digest, idx+1, len(layers))
 
-		layerStream, err := imgLayer.Uncompressed()
+		err = i.extractLayer(imgLayer)
 		if err != nil {
 			return err
 		}
+	}
 
-		defer layerStream.Close()
+	return nil
+}
 
-		err = i.writeLayer(layerStream)
-		if err != nil {
-			return err
-		}
+// extractLayer streams the uncompressed layer onto disk
+// and releases it before the next layer is opened
+func (i *DirImage) extractLayer(imgLayer regv1.Layer) error {
+	layerStream, err := UncompressedLayer(imgLayer)
+	if err != nil {
+		return err
 	}
 
-	return nil
+	defer layerStream.Close()
+
+	return i.writeLayer(layerStream)
 }
 
 // Taken from https://github.com/concourse/registry-image-resource/blob/b5481130ad61bc74e0a74f9b00b287b3a24bab88/cmd/in/unpack.go
@@ -84,6 +95,11 @@ func (i *DirImage) writeLayer(stream io.Reader) error {
 		path := filepath.Join(i.dirPath, filepath.Clean(hdr.Name))
 		base := filepath.Base(path)
 
+		err = i.checkEntryWithinDir(hdr.Name, path)
+		if err != nil {
+			return err
+		}
+
 		const (
 			whiteoutPrefix = ".wh."
 		)
@@ -142,7 +158,9 @@ func (i *DirImage) extractTarEntry(header *tar.Header, input io.Reader) error {
 			return err
 		}
 
-		_, err = io.Copy(file, input)
+		// Hide file's ReadFrom so that the shared buffer is used
+		// instead of allocating a new one for every file
+		_, err = io.CopyBuffer(struct{ io.Writer }{file}, input, i.copyBuf)
 		if err != nil {
 			_ = file.Close()
 			return err
@@ -153,8 +171,24 @@ func (i *DirImage) extractTarEntry(header *tar.Header, input io.Reader) error {
 			return err
 		}
 
-	case tar.TypeLink, tar.TypeSymlink:
-		// skipping symlinks as a security feature
+	case tar.TypeSymlink:
+		if filepath.IsAbs(header.Linkname) || !isWithinDir(i.dirPath, filepath.Join(filepath.Dir(path), header.Linkname)) {
+			// skipping symlinks pointing outside of output directory as a security feature
+			return nil
+		}
+
+		err := i.checkLinkWithinDir(header.Name, path, header.Linkname)
+		if err != nil {
+			return err
+		}
+
+		err = os.Symlink(header.Linkname, path)
+		if err != nil {
+			return err
+		}
+
+	case tar.TypeLink:
+		// skipping hard links as a security feature
 		return nil
 
 	default:
@@ -178,6 +212,134 @@ func (i *DirImage) extractTarEntry(header *tar.Header, input io.Reader) error {
 	return lchtimes(header, path)
 }
 
+// checkEntryWithinDir prevents tar entries from writing (or removing) files
+// outside of output directory either via '..' in their names or
+// via previously extracted symlinks found in their parent directories
+func (i *DirImage) checkEntryWithinDir(name, path string) error {
+	escapeErr := fmt.Errorf("Expected tar entry '%s' to be within output directory '%s'", name, i.dirPath)
+
+	if !isWithinDir(i.dirPath, path) {
+		return escapeErr
+	}
+	if path == i.dirPath {
+		return nil
+	}
+
+	resolvedDirPath, err := filepath.EvalSymlinks(i.dirPath)
+	if err != nil {
+		return err
+	}
+
+	resolvedParentPath, err := i.resolveExistingPath(filepath.Dir(path))
+	if err != nil {
+		return err
+	}
+
+	if !isWithinDir(resolvedDirPath, resolvedParentPath) {
+		return escapeErr
+	}
+
+	return nil
+}
+
+// checkLinkWithinDir prevents symlinks from pointing outside of output directory
+// via previously extracted symlinks found in their parent directories or in their target
+func (i *DirImage) checkLinkWithinDir(name, path, linkname string) error {
+	escapeErr := fmt.Errorf("Expected tar entry '%s' target '%s' to be within output directory '%s'", name, linkname, i.dirPath)
+
+	resolvedDirPath, err := filepath.EvalSymlinks(i.dirPath)
+	if err != nil {
+		return err
+	}
+
+	resolvedParentPath, err := i.resolveExistingPath(filepath.Dir(path))
+	if err != nil {
+		return err
+	}
+
+	targetPath, ok, err := i.resolveLinkTarget(resolvedParentPath, linkname)
+	if err != nil {
+		return err
+	}
+
+	if !ok || !isWithinDir(resolvedDirPath, targetPath) {
+		return escapeErr
+	}
+
+	return nil
+}
+
+// resolveLinkTarget follows linkname from resolvedParentPath one element at a time,
+// so that '..' is applied to where previously extracted symlinks point to.
+// Once an element does not exist, '..' cannot be resolved (the element may still
+// be extracted as a symlink), hence false is returned.
+func (i *DirImage) resolveLinkTarget(resolvedParentPath, linkname string) (string, bool, error) {
+	targetPath := resolvedParentPath
+	missing := false
+
+	for _, elem := range strings.Split(filepath.ToSlash(linkname), "/") {
+		switch elem {
+		case "", ".":
+			continue
+
+		case "..":
+			if missing {
+				return "", false, nil
+			}
+			targetPath = filepath.Dir(targetPath)
+
+		default:
+			targetPath = filepath.Join(targetPath, elem)
+			if missing {
+				continue
+			}
+
+			resolvedPath, err := filepath.EvalSymlinks(targetPath)
+			if err != nil {
+				if !os.IsNotExist(err) {
+					return "", false, err
+				}
+				missing = true
+				continue
+			}
+			targetPath = resolvedPath
+		}
+	}
+
+	return targetPath, true, nil
+}
+
+// resolveExistingPath follows symlinks in the part of path that already exists
+func (i *DirImage) resolveExistingPath(path string) (string, error) {
+	var missingPath string
+
+	for {
+		resolvedPath, err := filepath.EvalSymlinks(path)
+		if err == nil {
+			return filepath.Join(resolvedPath, missingPath), nil
+		}
+		if !os.IsNotExist(err) {
+			return "", err
+		}
+
+		parentPath := filepath.Dir(path)
+		if parentPath == path {
+			return "", err
+		}
+
+		missingPath = filepath.Join(filepath.Base(path), missingPath)
+		path = parentPath
+	}
+}
+
+func isWithinDir(dirPath, path string) bool {
+	relPath, err := filepath.Rel(dirPath, path)
+	if err != nil {
+		return false
+	}
+	return relPath != ".." && !strings.HasPrefix(relPath, ".."+string(filepath.Separator))
+}
+
 func lchmod(header *tar.Header, path string, mode os.FileMode) error {
 	if header.Typeflag == tar.TypeLink {
 		if fi, err := os.Lstat(header.Linkname); err == nil && (fi.Mode()&os.ModeSymlink == 0) {
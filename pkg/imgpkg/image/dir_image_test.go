@@ -0,0 +1,227 @@
+// Copyright 2021 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package image_test
+
+import (
+	"archive/tar"
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	goui "github.com/cppforlife/go-cli-ui/ui"
+	regv1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/tarball"
+	ctlimg "github.com/k14s/imgpkg/pkg/imgpkg/image"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDirImageRejectsEntriesOutsideOfOutputDir(t *testing.T) {
+	testCases := []struct {
+		name    string
+		entries []tar.Header
+	}{
+		{
+			name: "entry name contains '..'",
+			entries: []tar.Header{
+				{Name: "../evil", Typeflag: tar.TypeReg, Mode: 0600},
+			},
+		},
+		{
+			name: "nested entry name contains '..'",
+			entries: []tar.Header{
+				{Name: "dir/../../evil", Typeflag: tar.TypeReg, Mode: 0600},
+			},
+		},
+		{
+			name: "entry is written via chain of symlinks",
+			entries: []tar.Header{
+				{Name: "self", Typeflag: tar.TypeSymlink, Linkname: ".", Mode: 0700},
+				{Name: "parent", Typeflag: tar.TypeSymlink, Linkname: "self/..", Mode: 0700},
+				{Name: "parent/evil", Typeflag: tar.TypeReg, Mode: 0600},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			parentPath := createTempDir(t)
+			outputPath := filepath.Join(parentPath, "output")
+
+			fileImg := createTarFileImage(t, tc.entries)
+			defer fileImg.Remove()
+
+			err := ctlimg.NewDirImage(outputPath, fileImg, goui.NewNoopUI()).AsDirectory()
+			require.Error(t, err)
+			require.Contains(t, err.Error(), "Expected tar entry")
+			require.Contains(t, err.Error(), "to be within output directory")
+
+			_, err = os.Stat(filepath.Join(parentPath, "evil"))
+			require.True(t, os.IsNotExist(err), "Expected file outside of output directory to not be created")
+		})
+	}
+}
+
+func TestDirImageSkipsSymlinksPointingOutsideOfOutputDir(t *testing.T) {
+	outputPath := filepath.Join(createTempDir(t), "output")
+
+	fileImg := createTarFileImage(t, []tar.Header{
+		{Name: "absolute", Typeflag: tar.TypeSymlink, Linkname: "/etc/passwd", Mode: 0700},
+		{Name: "relative", Typeflag: tar.TypeSymlink, Linkname: "../outside", Mode: 0700},
+		{Name: "within", Typeflag: tar.TypeSymlink, Linkname: "file", Mode: 0700},
+	})
+	defer fileImg.Remove()
+
+	require.NoError(t, ctlimg.NewDirImage(outputPath, fileImg, goui.NewNoopUI()).AsDirectory())
+
+	_, err := os.Lstat(filepath.Join(outputPath, "absolute"))
+	require.True(t, os.IsNotExist(err))
+	_, err = os.Lstat(filepath.Join(outputPath, "relative"))
+	require.True(t, os.IsNotExist(err))
+
+	target, err := os.Readlink(filepath.Join(outputPath, "within"))
+	require.NoError(t, err)
+	require.Equal(t, "file", target)
+}
+
+func TestDirImageRejectsSymlinksPointingOutsideOfOutputDirViaSymlinks(t *testing.T) {
+	testCases := []struct {
+		name     string
+		entries  []tar.Header
+		linkName string
+	}{
+		{
+			name: "symlink parent directory is a symlink",
+			entries: []tar.Header{
+				{Name: "x", Typeflag: tar.TypeSymlink, Linkname: ".", Mode: 0700},
+				{Name: "x/t", Typeflag: tar.TypeSymlink, Linkname: "../secret", Mode: 0700},
+			},
+			linkName: "t",
+		},
+		{
+			name: "symlink target goes through a symlink",
+			entries: []tar.Header{
+				{Name: "x", Typeflag: tar.TypeSymlink, Linkname: ".", Mode: 0700},
+				{Name: "t", Typeflag: tar.TypeSymlink, Linkname: "x/../secret", Mode: 0700},
+			},
+			linkName: "t",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			parentPath := createTempDir(t)
+			outputPath := filepath.Join(parentPath, "output")
+			require.NoError(t, ioutil.WriteFile(filepath.Join(parentPath, "secret"), []byte("secret"), 0600))
+
+			fileImg := createTarFileImage(t, tc.entries)
+			defer fileImg.Remove()
+
+			err := ctlimg.NewDirImage(outputPath, fileImg, goui.NewNoopUI()).AsDirectory()
+			require.Error(t, err)
+			require.Contains(t, err.Error(), "to be within output directory")
+
+			_, err = os.Lstat(filepath.Join(outputPath, tc.linkName))
+			require.True(t, os.IsNotExist(err), "Expected symlink pointing outside of output directory to not be created")
+		})
+	}
+}
+
+func TestDirImageStreamsLayersToDisk(t *testing.T) {
+	const largeFileSize = 64 * 1024 * 1024
+
+	img := createLargeFileImage(t, largeFileSize)
+	outputPath := filepath.Join(createTempDir(t), "output")
+
+	var before, after runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&before)
+
+	require.NoError(t, ctlimg.NewDirImage(outputPath, img, goui.NewNoopUI()).AsDirectory())
+
+	runtime.ReadMemStats(&after)
+
+	info, err := os.Stat(filepath.Join(outputPath, "large-file"))
+	require.NoError(t, err)
+	require.Equal(t, int64(largeFileSize), info.Size())
+
+	allocated := after.TotalAlloc - before.TotalAlloc
+	require.Less(t, allocated, uint64(largeFileSize/16),
+		"Expected extraction to allocate much less than the layer size, but allocated %d bytes", allocated)
+}
+
+func BenchmarkDirImageAsDirectory(b *testing.B) {
+	for _, size := range []int64{1024 * 1024, 64 * 1024 * 1024} {
+		img := createLargeFileImage(b, size)
+		outputPath := filepath.Join(b.TempDir(), "output")
+
+		b.Run(fmt.Sprintf("%dMiB", size/1024/1024), func(b *testing.B) {
+			b.SetBytes(size)
+			b.ReportAllocs()
+
+			for n := 0; n < b.N; n++ {
+				err := ctlimg.NewDirImage(outputPath, img, goui.NewNoopUI()).AsDirectory()
+				if err != nil {
+					b.Fatalf("Extracting image: %s", err)
+				}
+			}
+		})
+	}
+}
+
+// createLargeFileImage returns an image with a single layer containing a file
+// of the given size; the layer is generated on every read, hence never held in memory
+func createLargeFileImage(t testing.TB, size int64) regv1.Image {
+	header := &bytes.Buffer{}
+	require.NoError(t, tar.NewWriter(header).WriteHeader(&tar.Header{
+		Name: "large-file", Typeflag: tar.TypeReg, Mode: 0600, Size: size,
+	}))
+
+	// File contents are zeros, as are the tar padding and end of archive blocks
+	padding := (512 - size%512) % 512
+	opener := func() (io.ReadCloser, error) {
+		return ioutil.NopCloser(io.MultiReader(bytes.NewReader(header.Bytes()), io.LimitReader(zeroReader{}, size+padding+1024))), nil
+	}
+
+	layer, err := tarball.LayerFromOpener(opener)
+	require.NoError(t, err)
+
+	img, err := mutate.AppendLayers(empty.Image, layer)
+	require.NoError(t, err)
+	return img
+}
+
+type zeroReader struct{}
+
+func (zeroReader) Read(p []byte) (int, error) {
+	for i := range p {
+		p[i] = 0
+	}
+	return len(p), nil
+}
+
+func createTarFileImage(t *testing.T, entries []tar.Header) *ctlimg.FileImage {
+	tarPath := filepath.Join(createTempDir(t), "layer.tar")
+
+	file, err := os.Create(tarPath)
+	require.NoError(t, err)
+
+	tarWriter := tar.NewWriter(file)
+	for _, entry := range entries {
+		entry := entry
+		require.NoError(t, tarWriter.WriteHeader(&entry))
+	}
+	require.NoError(t, tarWriter.Close())
+	require.NoError(t, file.Close())
+
+	fileImg, err := ctlimg.NewFileImage(tarPath, nil, ctlimg.CompressionGzip)
+	require.NoError(t, err)
+	return fileImg
+}
@@ -8,6 +8,7 @@ import (
 	"encoding/hex"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"os"
 
 	v1 "github.com/google/go-containerregistry/pkg/v1"
@@ -15,24 +16,23 @@ import (
 	"github.com/google/go-containerregistry/pkg/v1/mutate"
 	"github.com/google/go-containerregistry/pkg/v1/partial"
 	"github.com/google/go-containerregistry/pkg/v1/types"
+	"github.com/k14s/imgpkg/pkg/imgpkg/imageutils/zstd"
 )
 
 type FileImage struct {
 	v1.Image
-	path string
+	path           string
+	compressedPath string
 }
 
-func NewFileImage(path string, labels map[string]string) (*FileImage, error) {
-	sha256, err := sha256Path(path)
-	if err != nil {
-		return nil, err
+func NewFileImage(path string, labels map[string]string, compression Compression) (*FileImage, error) {
+	baseImg := empty.Image
+	if compression == CompressionZstd {
+		// zstd layers are only allowed in OCI manifests
+		baseImg = mutate.MediaType(empty.Image, types.OCIManifestSchema1)
 	}
 
-	layer, err := partial.UncompressedToLayer(&UncompressedFileLayer{
-		diffID:    v1.Hash{Algorithm: "sha256", Hex: sha256},
-		mediaType: types.DockerLayer,
-		path:      path,
-	})
+	layer, compressedPath, err := newFileLayer(path, compression, types.DockerLayer)
 	if err != nil {
 		return nil, err
 	}
@@ -46,8 +46,9 @@ func NewFileImage(path string, labels map[string]string) (*FileImage, error) {
 		},
 	}
 
-	img, err := mutate.Append(empty.Image, add)
+	img, err := mutate.Append(baseImg, add)
 	if err != nil {
+		_ = removeIfPresent(compressedPath)
 		return nil, err
 	}
 
@@ -61,17 +62,106 @@ func NewFileImage(path string, labels map[string]string) (*FileImage, error) {
 
 		img, err = mutate.ConfigFile(img, cfg)
 		if err != nil {
+			_ = removeIfPresent(compressedPath)
 			return nil, err
 		}
 	}
 
-	return &FileImage{img, path}, nil
+	return &FileImage{img, path, compressedPath}, nil
+}
+
+// newFileLayer returns a layer backed by the tar at path, compressed with compression.
+// mediaType is used for gzip compressed layers, zstd ones always use OCIZstdLayer.
+// The returned compressed path (if any) must be removed once the layer is not needed anymore.
+func newFileLayer(path string, compression Compression, mediaType types.MediaType) (v1.Layer, string, error) {
+	sha256, err := sha256Path(path)
+	if err != nil {
+		return nil, "", err
+	}
+
+	diffID := v1.Hash{Algorithm: "sha256", Hex: sha256}
+
+	if compression != CompressionZstd {
+		layer, err := partial.UncompressedToLayer(&UncompressedFileLayer{
+			diffID:    diffID,
+			mediaType: mediaType,
+			path:      path,
+		})
+		return layer, "", err
+	}
+
+	compressedPath, err := zstdCompressPath(path)
+	if err != nil {
+		return nil, "", err
+	}
+
+	layer, err := newZstdFileLayer(diffID, path, compressedPath)
+	if err != nil {
+		_ = os.Remove(compressedPath)
+		return nil, "", err
+	}
+
+	return layer, compressedPath, nil
 }
 
 func (i *FileImage) Remove() error {
+	err := removeIfPresent(i.compressedPath)
+	if err != nil {
+		return err
+	}
 	return os.Remove(i.path)
 }
 
+func removeIfPresent(path string) error {
+	if len(path) == 0 {
+		return nil
+	}
+	return os.Remove(path)
+}
+
+func zstdCompressPath(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+
+	compressedFile, err := ioutil.TempFile("", "imgpkg-zstd-layer")
+	if err != nil {
+		file.Close()
+		return "", err
+	}
+
+	defer compressedFile.Close()
+
+	_, err = io.Copy(compressedFile, zstd.ReadCloser(file))
+	if err != nil {
+		_ = os.Remove(compressedFile.Name())
+		return "", fmt.Errorf("Compressing layer with zstd: %s", err)
+	}
+
+	return compressedFile.Name(), nil
+}
+
+func newZstdFileLayer(diffID v1.Hash, path, compressedPath string) (*ZstdFileLayer, error) {
+	sha256, err := sha256Path(compressedPath)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := os.Stat(compressedPath)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ZstdFileLayer{
+		diffID:         diffID,
+		digest:         v1.Hash{Algorithm: "sha256", Hex: sha256},
+		size:           info.Size(),
+		path:           path,
+		compressedPath: compressedPath,
+	}, nil
+}
+
 func sha256Path(path string) (string, error) {
 	file, err := os.Open(path)
 	if err != nil {
@@ -32,3 +32,41 @@ func (ul *UncompressedFileLayer) Uncompressed() (io.ReadCloser, error) {
 func (ul *UncompressedFileLayer) MediaType() (regtypes.MediaType, error) {
 	return ul.mediaType, nil
 }
+
+// ZstdFileLayer is backed by an uncompressed tar and its zstd compressed
+// copy since go-containerregistry can only compress layers with gzip
+type ZstdFileLayer struct {
+	diffID         regv1.Hash
+	digest         regv1.Hash
+	size           int64
+	path           string
+	compressedPath string
+}
+
+var _ regv1.Layer = (*ZstdFileLayer)(nil)
+
+func (zl *ZstdFileLayer) Digest() (regv1.Hash, error) {
+	return zl.digest, nil
+}
+
+func (zl *ZstdFileLayer) DiffID() (regv1.Hash, error) {
+	return zl.diffID, nil
+}
+
+func (zl *ZstdFileLayer) Compressed() (io.ReadCloser, error) {
+	file, err := os.Open(zl.compressedPath)
+	return file, err
+}
+
+func (zl *ZstdFileLayer) Uncompressed() (io.ReadCloser, error) {
+	file, err := os.Open(zl.path)
+	return file, err
+}
+
+func (zl *ZstdFileLayer) Size() (int64, error) {
+	return zl.size, nil
+}
+
+func (zl *ZstdFileLayer) MediaType() (regtypes.MediaType, error) {
+	return OCIZstdLayer, nil
+}
@@ -2,6 +2,7 @@
 package imagefakes
 
 import (
+	"context"
 	"sync"
 
 	"github.com/google/go-containerregistry/pkg/name"
@@ -11,10 +12,11 @@ import (
 )
 
 type FakeImagesMetadata struct {
-	DigestStub        func(name.Reference) (v1.Hash, error)
+	DigestStub        func(context.Context, name.Reference) (v1.Hash, error)
 	digestMutex       sync.RWMutex
 	digestArgsForCall []struct {
-		arg1 name.Reference
+		arg1 context.Context
+		arg2 name.Reference
 	}
 	digestReturns struct {
 		result1 v1.Hash
@@ -24,10 +26,11 @@ type FakeImagesMetadata struct {
 		result1 v1.Hash
 		result2 error
 	}
-	GenericStub        func(name.Reference) (v1.Descriptor, error)
+	GenericStub        func(context.Context, name.Reference) (v1.Descriptor, error)
 	genericMutex       sync.RWMutex
 	genericArgsForCall []struct {
-		arg1 name.Reference
+		arg1 context.Context
+		arg2 name.Reference
 	}
 	genericReturns struct {
 		result1 v1.Descriptor
@@ -37,10 +40,11 @@ type FakeImagesMetadata struct {
 		result1 v1.Descriptor
 		result2 error
 	}
-	GetStub        func(name.Reference) (*remote.Descriptor, error)
+	GetStub        func(context.Context, name.Reference) (*remote.Descriptor, error)
 	getMutex       sync.RWMutex
 	getArgsForCall []struct {
-		arg1 name.Reference
+		arg1 context.Context
+		arg2 name.Reference
 	}
 	getReturns struct {
 		result1 *remote.Descriptor
@@ -50,10 +54,11 @@ type FakeImagesMetadata struct {
 		result1 *remote.Descriptor
 		result2 error
 	}
-	ImageStub        func(name.Reference) (v1.Image, error)
+	ImageStub        func(context.Context, name.Reference) (v1.Image, error)
 	imageMutex       sync.RWMutex
 	imageArgsForCall []struct {
-		arg1 name.Reference
+		arg1 context.Context
+		arg2 name.Reference
 	}
 	imageReturns struct {
 		result1 v1.Image
@@ -63,10 +68,11 @@ type FakeImagesMetadata struct {
 		result1 v1.Image
 		result2 error
 	}
-	IndexStub        func(name.Reference) (v1.ImageIndex, error)
+	IndexStub        func(context.Context, name.Reference) (v1.ImageIndex, error)
 	indexMutex       sync.RWMutex
 	indexArgsForCall []struct {
-		arg1 name.Reference
+		arg1 context.Context
+		arg2 name.Reference
 	}
 	indexReturns struct {
 		result1 v1.ImageIndex
@@ -80,18 +86,19 @@ type FakeImagesMetadata struct {
 	invocationsMutex sync.RWMutex
 }
 
-func (fake *FakeImagesMetadata) Digest(arg1 name.Reference) (v1.Hash, error) {
+func (fake *FakeImagesMetadata) Digest(arg1 context.Context, arg2 name.Reference) (v1.Hash, error) {
 	fake.digestMutex.Lock()
 	ret, specificReturn := fake.digestReturnsOnCall[len(fake.digestArgsForCall)]
 	fake.digestArgsForCall = append(fake.digestArgsForCall, struct {
-		arg1 name.Reference
-	}{arg1})
+		arg1 context.Context
+		arg2 name.Reference
+	}{arg1, arg2})
 	stub := fake.DigestStub
 	fakeReturns := fake.digestReturns
-	fake.recordInvocation("Digest", []interface{}{arg1})
+	fake.recordInvocation("Digest", []interface{}{arg1, arg2})
 	fake.digestMutex.Unlock()
 	if stub != nil {
-		return stub(arg1)
+		return stub(arg1, arg2)
 	}
 	if specificReturn {
 		return ret.result1, ret.result2
@@ -105,17 +112,17 @@ func (fake *FakeImagesMetadata) DigestCallCount() int {
 	return len(fake.digestArgsForCall)
 }
 
-func (fake *FakeImagesMetadata) DigestCalls(stub func(name.Reference) (v1.Hash, error)) {
+func (fake *FakeImagesMetadata) DigestCalls(stub func(context.Context, name.Reference) (v1.Hash, error)) {
 	fake.digestMutex.Lock()
 	defer fake.digestMutex.Unlock()
 	fake.DigestStub = stub
 }
 
-func (fake *FakeImagesMetadata) DigestArgsForCall(i int) name.Reference {
+func (fake *FakeImagesMetadata) DigestArgsForCall(i int) (context.Context, name.Reference) {
 	fake.digestMutex.RLock()
 	defer fake.digestMutex.RUnlock()
 	argsForCall := fake.digestArgsForCall[i]
-	return argsForCall.arg1
+	return argsForCall.arg1, argsForCall.arg2
 }
 
 func (fake *FakeImagesMetadata) DigestReturns(result1 v1.Hash, result2 error) {
@@ -144,18 +151,19 @@ func (fake *FakeImagesMetadata) DigestReturnsOnCall(i int, result1 v1.Hash, resu
 	}{result1, result2}
 }
 
-func (fake *FakeImagesMetadata) Generic(arg1 name.Reference) (v1.Descriptor, error) {
+func (fake *FakeImagesMetadata) Generic(arg1 context.Context, arg2 name.Reference) (v1.Descriptor, error) {
 	fake.genericMutex.Lock()
 	ret, specificReturn := fake.genericReturnsOnCall[len(fake.genericArgsForCall)]
 	fake.genericArgsForCall = append(fake.genericArgsForCall, struct {
-		arg1 name.Reference
-	}{arg1})
+		arg1 context.Context
+		arg2 name.Reference
+	}{arg1, arg2})
 	stub := fake.GenericStub
 	fakeReturns := fake.genericReturns
-	fake.recordInvocation("Generic", []interface{}{arg1})
+	fake.recordInvocation("Generic", []interface{}{arg1, arg2})
 	fake.genericMutex.Unlock()
 	if stub != nil {
-		return stub(arg1)
+		return stub(arg1, arg2)
 	}
 	if specificReturn {
 		return ret.result1, ret.result2
@@ -169,17 +177,17 @@ func (fake *FakeImagesMetadata) GenericCallCount() int {
 	return len(fake.genericArgsForCall)
 }
 
-func (fake *FakeImagesMetadata) GenericCalls(stub func(name.Reference) (v1.Descriptor, error)) {
+func (fake *FakeImagesMetadata) GenericCalls(stub func(context.Context, name.Reference) (v1.Descriptor, error)) {
 	fake.genericMutex.Lock()
 	defer fake.genericMutex.Unlock()
 	fake.GenericStub = stub
 }
 
-func (fake *FakeImagesMetadata) GenericArgsForCall(i int) name.Reference {
+func (fake *FakeImagesMetadata) GenericArgsForCall(i int) (context.Context, name.Reference) {
 	fake.genericMutex.RLock()
 	defer fake.genericMutex.RUnlock()
 	argsForCall := fake.genericArgsForCall[i]
-	return argsForCall.arg1
+	return argsForCall.arg1, argsForCall.arg2
 }
 
 func (fake *FakeImagesMetadata) GenericReturns(result1 v1.Descriptor, result2 error) {
@@ -208,18 +216,19 @@ func (fake *FakeImagesMetadata) GenericReturnsOnCall(i int, result1 v1.Descripto
 	}{result1, result2}
 }
 
-func (fake *FakeImagesMetadata) Get(arg1 name.Reference) (*remote.Descriptor, error) {
+func (fake *FakeImagesMetadata) Get(arg1 context.Context, arg2 name.Reference) (*remote.Descriptor, error) {
 	fake.getMutex.Lock()
 	ret, specificReturn := fake.getReturnsOnCall[len(fake.getArgsForCall)]
 	fake.getArgsForCall = append(fake.getArgsForCall, struct {
-		arg1 name.Reference
-	}{arg1})
+		arg1 context.Context
+		arg2 name.Reference
+	}{arg1, arg2})
 	stub := fake.GetStub
 	fakeReturns := fake.getReturns
-	fake.recordInvocation("Get", []interface{}{arg1})
+	fake.recordInvocation("Get", []interface{}{arg1, arg2})
 	fake.getMutex.Unlock()
 	if stub != nil {
-		return stub(arg1)
+		return stub(arg1, arg2)
 	}
 	if specificReturn {
 		return ret.result1, ret.result2
@@ -233,17 +242,17 @@ func (fake *FakeImagesMetadata) GetCallCount() int {
 	return len(fake.getArgsForCall)
 }
 
-func (fake *FakeImagesMetadata) GetCalls(stub func(name.Reference) (*remote.Descriptor, error)) {
+func (fake *FakeImagesMetadata) GetCalls(stub func(context.Context, name.Reference) (*remote.Descriptor, error)) {
 	fake.getMutex.Lock()
 	defer fake.getMutex.Unlock()
 	fake.GetStub = stub
 }
 
-func (fake *FakeImagesMetadata) GetArgsForCall(i int) name.Reference {
+func (fake *FakeImagesMetadata) GetArgsForCall(i int) (context.Context, name.Reference) {
 	fake.getMutex.RLock()
 	defer fake.getMutex.RUnlock()
 	argsForCall := fake.getArgsForCall[i]
-	return argsForCall.arg1
+	return argsForCall.arg1, argsForCall.arg2
 }
 
 func (fake *FakeImagesMetadata) GetReturns(result1 *remote.Descriptor, result2 error) {
@@ -272,18 +281,19 @@ func (fake *FakeImagesMetadata) GetReturnsOnCall(i int, result1 *remote.Descript
 	}{result1, result2}
 }
 
-func (fake *FakeImagesMetadata) Image(arg1 name.Reference) (v1.Image, error) {
+func (fake *FakeImagesMetadata) Image(arg1 context.Context, arg2 name.Reference) (v1.Image, error) {
 	fake.imageMutex.Lock()
 	ret, specificReturn := fake.imageReturnsOnCall[len(fake.imageArgsForCall)]
 	fake.imageArgsForCall = append(fake.imageArgsForCall, struct {
-		arg1 name.Reference
-	}{arg1})
+		arg1 context.Context
+		arg2 name.Reference
+	}{arg1, arg2})
 	stub := fake.ImageStub
 	fakeReturns := fake.imageReturns
-	fake.recordInvocation("Image", []interface{}{arg1})
+	fake.recordInvocation("Image", []interface{}{arg1, arg2})
 	fake.imageMutex.Unlock()
 	if stub != nil {
-		return stub(arg1)
+		return stub(arg1, arg2)
 	}
 	if specificReturn {
 		return ret.result1, ret.result2
@@ -297,17 +307,17 @@ func (fake *FakeImagesMetadata) ImageCallCount() int {
 	return len(fake.imageArgsForCall)
 }
 
-func (fake *FakeImagesMetadata) ImageCalls(stub func(name.Reference) (v1.Image, error)) {
+func (fake *FakeImagesMetadata) ImageCalls(stub func(context.Context, name.Reference) (v1.Image, error)) {
 	fake.imageMutex.Lock()
 	defer fake.imageMutex.Unlock()
 	fake.ImageStub = stub
 }
 
-func (fake *FakeImagesMetadata) ImageArgsForCall(i int) name.Reference {
+func (fake *FakeImagesMetadata) ImageArgsForCall(i int) (context.Context, name.Reference) {
 	fake.imageMutex.RLock()
 	defer fake.imageMutex.RUnlock()
 	argsForCall := fake.imageArgsForCall[i]
-	return argsForCall.arg1
+	return argsForCall.arg1, argsForCall.arg2
 }
 
 func (fake *FakeImagesMetadata) ImageReturns(result1 v1.Image, result2 error) {
@@ -336,18 +346,19 @@ func (fake *FakeImagesMetadata) ImageReturnsOnCall(i int, result1 v1.Image, resu
 	}{result1, result2}
 }
 
-func (fake *FakeImagesMetadata) Index(arg1 name.Reference) (v1.ImageIndex, error) {
+func (fake *FakeImagesMetadata) Index(arg1 context.Context, arg2 name.Reference) (v1.ImageIndex, error) {
 	fake.indexMutex.Lock()
 	ret, specificReturn := fake.indexReturnsOnCall[len(fake.indexArgsForCall)]
 	fake.indexArgsForCall = append(fake.indexArgsForCall, struct {
-		arg1 name.Reference
-	}{arg1})
+		arg1 context.Context
+		arg2 name.Reference
+	}{arg1, arg2})
 	stub := fake.IndexStub
 	fakeReturns := fake.indexReturns
-	fake.recordInvocation("Index", []interface{}{arg1})
+	fake.recordInvocation("Index", []interface{}{arg1, arg2})
 	fake.indexMutex.Unlock()
 	if stub != nil {
-		return stub(arg1)
+		return stub(arg1, arg2)
 	}
 	if specificReturn {
 		return ret.result1, ret.result2
@@ -361,17 +372,17 @@ func (fake *FakeImagesMetadata) IndexCallCount() int {
 	return len(fake.indexArgsForCall)
 }
 
-func (fake *FakeImagesMetadata) IndexCalls(stub func(name.Reference) (v1.ImageIndex, error)) {
+func (fake *FakeImagesMetadata) IndexCalls(stub func(context.Context, name.Reference) (v1.ImageIndex, error)) {
 	fake.indexMutex.Lock()
 	defer fake.indexMutex.Unlock()
 	fake.IndexStub = stub
 }
 
-func (fake *FakeImagesMetadata) IndexArgsForCall(i int) name.Reference {
+func (fake *FakeImagesMetadata) IndexArgsForCall(i int) (context.Context, name.Reference) {
 	fake.indexMutex.RLock()
 	defer fake.indexMutex.RUnlock()
 	argsForCall := fake.indexArgsForCall[i]
-	return argsForCall.arg1
+	return argsForCall.arg1, argsForCall.arg2
 }
 
 func (fake *FakeImagesMetadata) IndexReturns(result1 v1.ImageIndex, result2 error) {
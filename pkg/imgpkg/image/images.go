@@ -4,6 +4,7 @@
 package image
 
 import (
+	"context"
 	"fmt"
 	"strings"
 
@@ -16,11 +17,11 @@ import (
 
 //go:generate go run github.com/maxbrunsfeld/counterfeiter/v6 . ImagesMetadata
 type ImagesMetadata interface {
-	Generic(regname.Reference) (regv1.Descriptor, error)
-	Get(regname.Reference) (*regremote.Descriptor, error)
-	Digest(regname.Reference) (regv1.Hash, error)
-	Index(regname.Reference) (regv1.ImageIndex, error)
-	Image(regname.Reference) (regv1.Image, error)
+	Generic(context.Context, regname.Reference) (regv1.Descriptor, error)
+	Get(context.Context, regname.Reference) (*regremote.Descriptor, error)
+	Digest(context.Context, regname.Reference) (regv1.Hash, error)
+	Index(context.Context, regname.Reference) (regv1.ImageIndex, error)
+	Image(context.Context, regname.Reference) (regv1.Image, error)
 }
 
 type Images struct {
@@ -32,8 +33,8 @@ func NewImages(ref regname.Reference, metadata ImagesMetadata) Images {
 	return Images{ref: ref, metadata: errImagesMetadata{metadata}}
 }
 
-func (tds Images) Images() ([]regv1.Image, error) {
-	desc, err := tds.metadata.Generic(tds.ref)
+func (tds Images) Images(ctx context.Context) ([]regv1.Image, error) {
+	desc, err := tds.metadata.Generic(ctx, tds.ref)
 	if err != nil {
 		return nil, err
 	}
@@ -41,13 +42,13 @@ func (tds Images) Images() ([]regv1.Image, error) {
 	var result []regv1.Image
 
 	if tds.isImageIndex(desc) {
-		imgs, err := tds.buildImageIndex(tds.ref)
+		imgs, err := tds.buildImageIndex(ctx, tds.ref)
 		if err != nil {
 			return nil, err
 		}
 		result = append(result, imgs...)
 	} else {
-		img, err := tds.buildImage(tds.ref)
+		img, err := tds.buildImage(ctx, tds.ref)
 		if err != nil {
 			return nil, err
 		}
@@ -57,8 +58,8 @@ func (tds Images) Images() ([]regv1.Image, error) {
 	return result, nil
 }
 
-func (tds Images) buildImageIndex(ref regname.Reference) ([]regv1.Image, error) {
-	imgIndex, err := tds.metadata.Index(ref)
+func (tds Images) buildImageIndex(ctx context.Context, ref regname.Reference) ([]regv1.Image, error) {
+	imgIndex, err := tds.metadata.Index(ctx, ref)
 	if err != nil {
 		return nil, err
 	}
@@ -72,13 +73,13 @@ func (tds Images) buildImageIndex(ref regname.Reference) ([]regv1.Image, error)
 
 	for _, manDesc := range imgIndexManifest.Manifests {
 		if tds.isImageIndex(manDesc) {
-			imgs, err := tds.buildImageIndex(tds.buildRef(ref, manDesc.Digest.String()))
+			imgs, err := tds.buildImageIndex(ctx, tds.buildRef(ref, manDesc.Digest.String()))
 			if err != nil {
 				return nil, err
 			}
 			result = append(result, imgs...)
 		} else {
-			img, err := tds.buildImage(tds.buildRef(ref, manDesc.Digest.String()))
+			img, err := tds.buildImage(ctx, tds.buildRef(ref, manDesc.Digest.String()))
 			if err != nil {
 				return nil, err
 			}
@@ -89,8 +90,8 @@ func (tds Images) buildImageIndex(ref regname.Reference) ([]regv1.Image, error)
 	return result, nil
 }
 
-func (tds Images) buildImage(ref regname.Reference) (regv1.Image, error) {
-	return tds.metadata.Image(ref)
+func (tds Images) buildImage(ctx context.Context, ref regname.Reference) (regv1.Image, error) {
+	return tds.metadata.Image(ctx, ref)
 }
 
 func (Images) isImageIndex(desc regv1.Descriptor) bool {
@@ -113,28 +114,28 @@ type errImagesMetadata struct {
 	delegate ImagesMetadata
 }
 
-func (m errImagesMetadata) Generic(ref regname.Reference) (regv1.Descriptor, error) {
-	desc, err := m.delegate.Generic(ref)
+func (m errImagesMetadata) Generic(ctx context.Context, ref regname.Reference) (regv1.Descriptor, error) {
+	desc, err := m.delegate.Generic(ctx, ref)
 	return desc, m.betterErr(ref, err)
 }
 
-func (m errImagesMetadata) Get(ref regname.Reference) (*regremote.Descriptor, error) {
-	desc, err := m.delegate.Get(ref)
+func (m errImagesMetadata) Get(ctx context.Context, ref regname.Reference) (*regremote.Descriptor, error) {
+	desc, err := m.delegate.Get(ctx, ref)
 	return desc, m.betterErr(ref, err)
 }
 
-func (m errImagesMetadata) Digest(ref regname.Reference) (regv1.Hash, error) {
-	desc, err := m.delegate.Digest(ref)
+func (m errImagesMetadata) Digest(ctx context.Context, ref regname.Reference) (regv1.Hash, error) {
+	desc, err := m.delegate.Digest(ctx, ref)
 	return desc, m.betterErr(ref, err)
 }
 
-func (m errImagesMetadata) Index(ref regname.Reference) (regv1.ImageIndex, error) {
-	idx, err := m.delegate.Index(ref)
+func (m errImagesMetadata) Index(ctx context.Context, ref regname.Reference) (regv1.ImageIndex, error) {
+	idx, err := m.delegate.Index(ctx, ref)
 	return idx, m.betterErr(ref, err)
 }
 
-func (m errImagesMetadata) Image(ref regname.Reference) (regv1.Image, error) {
-	img, err := m.delegate.Image(ref)
+func (m errImagesMetadata) Image(ctx context.Context, ref regname.Reference) (regv1.Image, error) {
+	img, err := m.delegate.Image(ctx, ref)
 	return img, m.betterErr(ref, err)
 }
 
@@ -5,6 +5,7 @@ package image_test
 
 import (
 	"bytes"
+	"strings"
 	"testing"
 
 	ctlimg "github.com/k14s/imgpkg/pkg/imgpkg/image"
@@ -41,3 +42,77 @@ prefix:
 		t.Fatalf("Expected >>>%s<<< to match >>>%s<<<", out, expectedOut)
 	}
 }
+
+func TestLoggerDebugf(t *testing.T) {
+	t.Run("when level is debug, debug lines are written", func(t *testing.T) {
+		var buf bytes.Buffer
+
+		prefLogger := ctlimg.NewLogger(&buf).WithLevel(ctlimg.LogLevelDebug).NewPrefixedWriter("prefix: ")
+		prefLogger.WriteStr("content1\n")
+		prefLogger.Debugf("debug %s\n", "content2")
+
+		expectedOut := "prefix: content1\nprefix: debug content2\n"
+		if buf.String() != expectedOut {
+			t.Fatalf("Expected >>>%s<<< to match >>>%s<<<", buf.String(), expectedOut)
+		}
+	})
+
+	t.Run("when level is the default, debug lines are not written", func(t *testing.T) {
+		var buf bytes.Buffer
+
+		prefLogger := ctlimg.NewLogger(&buf).NewPrefixedWriter("prefix: ")
+		prefLogger.WriteStr("content1\n")
+		prefLogger.Debugf("debug %s\n", "content2")
+
+		expectedOut := "prefix: content1\n"
+		if buf.String() != expectedOut {
+			t.Fatalf("Expected >>>%s<<< to match >>>%s<<<", buf.String(), expectedOut)
+		}
+	})
+}
+
+func TestLoggerColor(t *testing.T) {
+	t.Run("when color is enabled, prefixes are wrapped in ANSI codes", func(t *testing.T) {
+		var buf bytes.Buffer
+
+		prefLogger := ctlimg.NewLogger(&buf).WithLevel(ctlimg.LogLevelDebug).WithColor(true).NewPrefixedWriter("prefix: ")
+		prefLogger.WriteStr("content1\ncontent2\n")
+		prefLogger.Debugf("debug %s\n", "content3")
+
+		expectedOut := "\x1b[36mprefix: \x1b[0mcontent1\n\x1b[36mprefix: \x1b[0mcontent2\n\x1b[90mprefix: \x1b[0mdebug content3\n"
+		if buf.String() != expectedOut {
+			t.Fatalf("Expected >>>%q<<< to match >>>%q<<<", buf.String(), expectedOut)
+		}
+	})
+
+	t.Run("when color is not enabled, no ANSI codes are written", func(t *testing.T) {
+		var buf bytes.Buffer
+
+		prefLogger := ctlimg.NewLogger(&buf).WithLevel(ctlimg.LogLevelDebug).NewPrefixedWriter("prefix: ")
+		prefLogger.WriteStr("content1\n")
+		prefLogger.Debugf("debug %s\n", "content2")
+
+		if strings.Contains(buf.String(), "\x1b[") {
+			t.Fatalf("Expected >>>%q<<< to not contain ANSI codes", buf.String())
+		}
+	})
+}
+
+func TestLoggerQuiet(t *testing.T) {
+	var buf bytes.Buffer
+
+	prefLogger := ctlimg.NewLogger(&buf).WithLevel(ctlimg.LogLevelQuiet).NewPrefixedWriter("prefix: ")
+	prefLogger.WriteStr("content1\n")
+	prefLogger.Debugf("debug %s\n", "content2")
+
+	if buf.Len() != 0 {
+		t.Fatalf("Expected nothing to be written, but was >>>%s<<<", buf.String())
+	}
+
+	prefLogger.Warnf("warning %s\n", "content3")
+	prefLogger.Resultf("result %s\n", "content4")
+
+	if buf.String() != "prefix: warning content3\nprefix: result content4\n" {
+		t.Fatalf("Expected warnings and results to be written, but was >>>%s<<<", buf.String())
+	}
+}
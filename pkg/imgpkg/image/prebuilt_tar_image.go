@@ -0,0 +1,101 @@
+// Copyright 2021 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package image
+
+import (
+	"archive/tar"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+)
+
+// PrebuiltTarImage uses an existing tar as the only layer of an image
+// instead of building one by walking files
+type PrebuiltTarImage struct {
+	tarStream   io.Reader
+	compression Compression
+	infoLog     io.Writer
+}
+
+func NewPrebuiltTarImage(tarStream io.Reader, compression Compression, infoLog io.Writer) *PrebuiltTarImage {
+	return &PrebuiltTarImage{tarStream, compression, infoLog}
+}
+
+// AsFileImage copies the tar into a temporary file, so that streams
+// such as stdin can be read more than once, checking that it is well-formed
+func (i *PrebuiltTarImage) AsFileImage(labels map[string]string) (*FileImage, error) {
+	tmpFile, err := ioutil.TempFile("", "imgpkg-prebuilt-tar-image")
+	if err != nil {
+		return nil, err
+	}
+
+	defer tmpFile.Close()
+
+	err = i.copyValidTar(tmpFile)
+	if err != nil {
+		_ = os.Remove(tmpFile.Name())
+		return nil, err
+	}
+
+	fileImg, err := NewFileImage(tmpFile.Name(), labels, i.compression)
+	if err != nil {
+		_ = os.Remove(tmpFile.Name())
+		return nil, err
+	}
+
+	return fileImg, nil
+}
+
+func (i *PrebuiltTarImage) copyValidTar(file *os.File) error {
+	counter := &countingWriter{}
+	stream := io.TeeReader(i.tarStream, io.MultiWriter(file, counter))
+	tarReader := tar.NewReader(stream)
+
+	for {
+		header, err := tarReader.Next()
+		if err != nil {
+			if err == io.EOF {
+				break
+			}
+			return fmt.Errorf("Expected input to be a tar: %s", err)
+		}
+
+		switch header.Typeflag {
+		case tar.TypeDir:
+			i.infoLog.Write([]byte(fmt.Sprintf("dir: %s\n", header.Name)))
+		case tar.TypeSymlink:
+			i.infoLog.Write([]byte(fmt.Sprintf("symlink: %s -> %s\n", header.Name, header.Linkname)))
+		default:
+			i.infoLog.Write([]byte(fmt.Sprintf("file: %s\n", header.Name)))
+		}
+
+		_, err = io.Copy(ioutil.Discard, tarReader)
+		if err != nil {
+			return fmt.Errorf("Expected input to be a tar: Reading '%s': %s", header.Name, err)
+		}
+	}
+
+	// Keep whatever follows the end of archive marker so that
+	// the layer is exactly the provided tar
+	_, err := io.Copy(ioutil.Discard, stream)
+	if err != nil {
+		return fmt.Errorf("Reading tar: %s", err)
+	}
+
+	if counter.written == 0 {
+		return fmt.Errorf("Expected input to be a tar, but it was empty")
+	}
+
+	return nil
+}
+
+type countingWriter struct {
+	written int64
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	w.written += int64(len(p))
+	return len(p), nil
+}
@@ -10,26 +10,68 @@ import (
 	"sync"
 )
 
+type LogLevel int
+
+const (
+	// LogLevelQuiet only logs warnings and results (see Warnf and Resultf)
+	LogLevelQuiet LogLevel = iota
+	LogLevelInfo
+	// LogLevelDebug additionally logs troubleshooting information, e.g. registry requests
+	LogLevelDebug
+)
+
+const (
+	colorPrefix = "\x1b[36m" // cyan
+	colorDebug  = "\x1b[90m" // grey
+	colorReset  = "\x1b[0m"
+)
+
 type KbldLogger struct {
 	writer     io.Writer
 	writerLock *sync.Mutex
+	level      LogLevel
+	color      bool
 }
 
 func NewLogger(writer io.Writer) KbldLogger {
-	return KbldLogger{writer: writer, writerLock: &sync.Mutex{}}
+	return KbldLogger{writer: writer, writerLock: &sync.Mutex{}, level: LogLevelInfo}
+}
+
+func (l KbldLogger) WithLevel(level LogLevel) KbldLogger {
+	l.level = level
+	return l
+}
+
+// WithColor wraps prefixes in ANSI color codes when enabled
+func (l KbldLogger) WithColor(enabled bool) KbldLogger {
+	l.color = enabled
+	return l
 }
 
 func (l KbldLogger) NewPrefixedWriter(prefix string) *LoggerPrefixWriter {
-	return &LoggerPrefixWriter{prefix, l.writer, l.writerLock}
+	return &LoggerPrefixWriter{prefix, l.writer, l.writerLock, l.level, l.color}
 }
 
 type LoggerPrefixWriter struct {
 	prefix     string
 	writer     io.Writer
 	writerLock *sync.Mutex
+	level      LogLevel
+	color      bool
 }
 
 func (w *LoggerPrefixWriter) Write(data []byte) (int, error) {
+	return w.write(data, LogLevelInfo)
+}
+
+func (w *LoggerPrefixWriter) write(data []byte, level LogLevel) (int, error) {
+	if w.level < level {
+		// return original data length
+		return len(data), nil
+	}
+
+	prefix := w.coloredPrefix(level)
+
 	newData := make([]byte, len(data))
 	copy(newData, data)
 
@@ -37,9 +79,9 @@ func (w *LoggerPrefixWriter) Write(data []byte) (int, error) {
 	if endsWithNl {
 		newData = newData[0 : len(newData)-1]
 	}
-	newData = bytes.Replace(newData, []byte("\n"), []byte("\n"+w.prefix), -1)
+	newData = bytes.Replace(newData, []byte("\n"), []byte("\n"+prefix), -1)
 	newData = append(newData, []byte("\n")...)
-	newData = append([]byte(w.prefix), newData...)
+	newData = append([]byte(prefix), newData...)
 
 	w.writerLock.Lock()
 	defer w.writerLock.Unlock()
@@ -53,7 +95,38 @@ func (w *LoggerPrefixWriter) Write(data []byte) (int, error) {
 	return len(data), nil
 }
 
+func (w *LoggerPrefixWriter) coloredPrefix(level LogLevel) string {
+	if !w.color {
+		return w.prefix
+	}
+	if level == LogLevelDebug {
+		return colorDebug + w.prefix + colorReset
+	}
+	return colorPrefix + w.prefix + colorReset
+}
+
 func (w *LoggerPrefixWriter) WriteStr(str string, args ...interface{}) error {
 	_, err := w.Write([]byte(fmt.Sprintf(str, args...)))
 	return err
 }
+
+// Warnf writes at every level, including LogLevelQuiet
+func (w *LoggerPrefixWriter) Warnf(str string, args ...interface{}) {
+	w.write([]byte(fmt.Sprintf(str, args...)), LogLevelQuiet)
+}
+
+// Resultf writes at every level, including LogLevelQuiet,
+// it is meant for the outcome of an operation (e.g. a summary)
+func (w *LoggerPrefixWriter) Resultf(str string, args ...interface{}) {
+	w.write([]byte(fmt.Sprintf(str, args...)), LogLevelQuiet)
+}
+
+// Debugf only writes when the logger was created with LogLevelDebug
+func (w *LoggerPrefixWriter) Debugf(str string, args ...interface{}) {
+	w.write([]byte(fmt.Sprintf(str, args...)), LogLevelDebug)
+}
+
+// DebugEnabled returns true when the logger was created with LogLevelDebug
+func (w *LoggerPrefixWriter) DebugEnabled() bool {
+	return w.level >= LogLevelDebug
+}
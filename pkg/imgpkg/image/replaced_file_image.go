@@ -0,0 +1,180 @@
+// Copyright 2021 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package image
+
+import (
+	"archive/tar"
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+)
+
+// NewReplacedFileImage returns a copy of the single layer image img whose file
+// at path is replaced by contents. Everything else is kept as is: the config
+// (labels, creation time, history), the manifest annotations, the layer compression
+// and the metadata of every file in the layer.
+func NewReplacedFileImage(img v1.Image, path string, contents []byte) (*FileImage, error) {
+	manifest, err := img.Manifest()
+	if err != nil {
+		return nil, err
+	}
+	if len(manifest.Layers) != 1 {
+		return nil, fmt.Errorf("Expected image to only have a single layer, got %d", len(manifest.Layers))
+	}
+
+	layers, err := img.Layers()
+	if err != nil {
+		return nil, err
+	}
+
+	configFile, err := img.ConfigFile()
+	if err != nil {
+		return nil, fmt.Errorf("Fetching image config: %s", err)
+	}
+
+	layerPath, err := replaceFileInLayer(layers[0], path, contents)
+	if err != nil {
+		return nil, err
+	}
+
+	compression := CompressionGzip
+	if manifest.Layers[0].MediaType == OCIZstdLayer {
+		compression = CompressionZstd
+	}
+
+	layer, compressedPath, err := newFileLayer(layerPath, compression, manifest.Layers[0].MediaType)
+	if err != nil {
+		_ = os.Remove(layerPath)
+		return nil, err
+	}
+
+	replacedImg, err := replaceLayer(img, configFile, manifest.Layers[0], layer)
+	if err != nil {
+		_ = removeIfPresent(compressedPath)
+		_ = os.Remove(layerPath)
+		return nil, err
+	}
+
+	return &FileImage{replacedImg, layerPath, compressedPath}, nil
+}
+
+// replaceLayer swaps the single layer of img by layer,
+// keeping the original layer descriptor annotations and urls
+func replaceLayer(img v1.Image, configFile *v1.ConfigFile, origLayer v1.Descriptor, layer v1.Layer) (v1.Image, error) {
+	layerlessConfigFile := configFile.DeepCopy()
+	layerlessConfigFile.RootFS.DiffIDs = nil
+	layerlessConfigFile.History = nil
+
+	layerlessImg, err := mutate.ConfigFile(layerlessImage{img}, layerlessConfigFile)
+	if err != nil {
+		return nil, err
+	}
+
+	replacedImg, err := mutate.Append(layerlessImg, mutate.Addendum{
+		Layer:       layer,
+		Annotations: origLayer.Annotations,
+		URLs:        origLayer.URLs,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	diffID, err := layer.DiffID()
+	if err != nil {
+		return nil, err
+	}
+
+	// Only the diff id changes, history is kept as is
+	replacedConfigFile := configFile.DeepCopy()
+	replacedConfigFile.RootFS.DiffIDs = []v1.Hash{diffID}
+
+	return mutate.ConfigFile(replacedImg, replacedConfigFile)
+}
+
+// replaceFileInLayer writes the uncompressed contents of layer into a temporary tar
+// with the file at path replaced by contents, keeping the metadata of every entry
+func replaceFileInLayer(layer v1.Layer, path string, contents []byte) (string, error) {
+	stream, err := UncompressedLayer(layer)
+	if err != nil {
+		return "", err
+	}
+
+	defer stream.Close()
+
+	tmpFile, err := ioutil.TempFile("", "imgpkg-replaced-layer")
+	if err != nil {
+		return "", err
+	}
+
+	defer tmpFile.Close()
+
+	err = copyTarReplacingFile(tar.NewReader(stream), tar.NewWriter(tmpFile), path, contents)
+	if err != nil {
+		_ = os.Remove(tmpFile.Name())
+		return "", err
+	}
+
+	return tmpFile.Name(), nil
+}
+
+func copyTarReplacingFile(tarReader *tar.Reader, tarWriter *tar.Writer, path string, contents []byte) error {
+	replaced := false
+
+	for {
+		header, err := tarReader.Next()
+		if err != nil {
+			if err == io.EOF {
+				break
+			}
+			return fmt.Errorf("Reading layer: %s", err)
+		}
+
+		var body io.Reader = tarReader
+
+		if header.Typeflag == tar.TypeReg && filepath.Clean(header.Name) == filepath.Clean(path) {
+			header.Size = int64(len(contents))
+			body = bytes.NewReader(contents)
+			replaced = true
+		}
+
+		err = tarWriter.WriteHeader(header)
+		if err != nil {
+			return err
+		}
+
+		_, err = io.Copy(tarWriter, body)
+		if err != nil {
+			return err
+		}
+	}
+
+	if !replaced {
+		return fmt.Errorf("Expected to find file '%s' in layer", path)
+	}
+
+	return tarWriter.Close()
+}
+
+// layerlessImage is img without layers, so that a layer can be appended to it
+// while keeping its manifest (e.g. media type and annotations)
+type layerlessImage struct {
+	v1.Image
+}
+
+func (i layerlessImage) Manifest() (*v1.Manifest, error) {
+	manifest, err := i.Image.Manifest()
+	if err != nil {
+		return nil, err
+	}
+
+	manifest = manifest.DeepCopy()
+	manifest.Layers = nil
+	return manifest, nil
+}
@@ -0,0 +1,166 @@
+// Copyright 2021 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package image_test
+
+import (
+	"archive/tar"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	regv1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	ctlimg "github.com/k14s/imgpkg/pkg/imgpkg/image"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewReplacedFileImage(t *testing.T) {
+	modTime := time.Date(2021, 6, 1, 12, 0, 0, 0, time.UTC)
+	created := regv1.Time{Time: time.Date(2021, 6, 2, 12, 0, 0, 0, time.UTC)}
+	labels := map[string]string{"dev.carvel.imgpkg.bundle": "true"}
+	annotations := map[string]string{"some.annotation": "some-value"}
+
+	for _, compression := range []ctlimg.Compression{ctlimg.CompressionGzip, ctlimg.CompressionZstd} {
+		t.Run("with "+string(compression)+" compression, it only replaces the file", func(t *testing.T) {
+			tarPath := filepath.Join(t.TempDir(), "layer.tar")
+			writeTar(t, tarPath, []tarEntry{
+				{header: tar.Header{Name: ".imgpkg", Typeflag: tar.TypeDir, Mode: 0700, ModTime: modTime}},
+				{header: tar.Header{Name: ".imgpkg/images.yml", Typeflag: tar.TypeReg, Mode: 0600, ModTime: modTime}, contents: "old"},
+				{header: tar.Header{Name: "run.sh", Typeflag: tar.TypeReg, Mode: 0700, ModTime: modTime}, contents: "#!/bin/sh"},
+			})
+
+			fileImg, err := ctlimg.NewFileImage(tarPath, labels, compression)
+			require.NoError(t, err)
+			defer fileImg.Remove()
+
+			createdImg, err := mutate.CreatedAt(fileImg, created)
+			require.NoError(t, err)
+			img := annotatedImage{createdImg, annotations}
+
+			replacedImg, err := ctlimg.NewReplacedFileImage(img, ".imgpkg/images.yml", []byte("new contents"))
+			require.NoError(t, err)
+			defer replacedImg.Remove()
+
+			origManifest, err := img.Manifest()
+			require.NoError(t, err)
+			manifest, err := replacedImg.Manifest()
+			require.NoError(t, err)
+			assert.Equal(t, annotations, manifest.Annotations)
+			require.Len(t, manifest.Layers, 1)
+			assert.Equal(t, origManifest.Layers[0].MediaType, manifest.Layers[0].MediaType)
+			assert.NotEqual(t, origManifest.Layers[0].Digest, manifest.Layers[0].Digest)
+
+			origMediaType, err := img.MediaType()
+			require.NoError(t, err)
+			mediaType, err := replacedImg.MediaType()
+			require.NoError(t, err)
+			assert.Equal(t, origMediaType, mediaType)
+
+			origConfigFile, err := img.ConfigFile()
+			require.NoError(t, err)
+			configFile, err := replacedImg.ConfigFile()
+			require.NoError(t, err)
+			assert.Equal(t, created, configFile.Created)
+			assert.Equal(t, labels, configFile.Config.Labels)
+			assert.Equal(t, origConfigFile.History, configFile.History)
+			assert.NotEqual(t, origConfigFile.RootFS.DiffIDs, configFile.RootFS.DiffIDs)
+
+			layers, err := replacedImg.Layers()
+			require.NoError(t, err)
+			require.Len(t, layers, 1)
+			diffID, err := layers[0].DiffID()
+			require.NoError(t, err)
+			assert.Equal(t, []regv1.Hash{diffID}, configFile.RootFS.DiffIDs)
+
+			entries := readTar(t, layers[0])
+			require.Len(t, entries, 3)
+			assert.Equal(t, ".imgpkg", entries[0].header.Name)
+			assert.Equal(t, ".imgpkg/images.yml", entries[1].header.Name)
+			assert.Equal(t, "new contents", entries[1].contents)
+			assert.Equal(t, "run.sh", entries[2].header.Name)
+			assert.Equal(t, "#!/bin/sh", entries[2].contents)
+			for _, entry := range entries {
+				assert.True(t, modTime.Equal(entry.header.ModTime), "expected modification time of '%s' to be kept", entry.header.Name)
+			}
+			assert.Equal(t, int64(0700), entries[2].header.Mode)
+		})
+	}
+
+	t.Run("when the file is not in the layer, it errors", func(t *testing.T) {
+		tarPath := filepath.Join(t.TempDir(), "layer.tar")
+		writeTar(t, tarPath, []tarEntry{
+			{header: tar.Header{Name: "config.yml", Typeflag: tar.TypeReg, Mode: 0600}, contents: "key: value"},
+		})
+
+		fileImg, err := ctlimg.NewFileImage(tarPath, nil, ctlimg.CompressionGzip)
+		require.NoError(t, err)
+		defer fileImg.Remove()
+
+		_, err = ctlimg.NewReplacedFileImage(fileImg, ".imgpkg/images.yml", []byte("new contents"))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "Expected to find file '.imgpkg/images.yml' in layer")
+	})
+}
+
+type tarEntry struct {
+	header   tar.Header
+	contents string
+}
+
+func writeTar(t *testing.T, path string, entries []tarEntry) {
+	file, err := os.Create(path)
+	require.NoError(t, err)
+	defer file.Close()
+
+	tarWriter := tar.NewWriter(file)
+	for _, entry := range entries {
+		header := entry.header
+		header.Size = int64(len(entry.contents))
+		require.NoError(t, tarWriter.WriteHeader(&header))
+		_, err := tarWriter.Write([]byte(entry.contents))
+		require.NoError(t, err)
+	}
+	require.NoError(t, tarWriter.Close())
+}
+
+func readTar(t *testing.T, layer regv1.Layer) []tarEntry {
+	stream, err := ctlimg.UncompressedLayer(layer)
+	require.NoError(t, err)
+	defer stream.Close()
+
+	var entries []tarEntry
+	tarReader := tar.NewReader(stream)
+	for {
+		header, err := tarReader.Next()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+
+		contents, err := ioutil.ReadAll(tarReader)
+		require.NoError(t, err)
+		entries = append(entries, tarEntry{header: *header, contents: string(contents)})
+	}
+	return entries
+}
+
+// annotatedImage adds annotations to the manifest of the image
+type annotatedImage struct {
+	regv1.Image
+	annotations map[string]string
+}
+
+func (i annotatedImage) Manifest() (*regv1.Manifest, error) {
+	manifest, err := i.Image.Manifest()
+	if err != nil {
+		return nil, err
+	}
+	manifest = manifest.DeepCopy()
+	manifest.Annotations = i.annotations
+	return manifest, nil
+}
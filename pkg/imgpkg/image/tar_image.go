@@ -16,11 +16,12 @@ import (
 type TarImage struct {
 	files        []string
 	excludePaths []string
+	compression  Compression
 	infoLog      io.Writer
 }
 
-func NewTarImage(files []string, excludePaths []string, infoLog io.Writer) *TarImage {
-	return &TarImage{files, excludePaths, infoLog}
+func NewTarImage(files []string, excludePaths []string, compression Compression, infoLog io.Writer) *TarImage {
+	return &TarImage{files, excludePaths, compression, infoLog}
 }
 
 func (i *TarImage) AsFileImage(labels map[string]string) (*FileImage, error) {
@@ -37,7 +38,7 @@ func (i *TarImage) AsFileImage(labels map[string]string) (*FileImage, error) {
 		return nil, err
 	}
 
-	fileImg, err := NewFileImage(tmpFile.Name(), labels)
+	fileImg, err := NewFileImage(tmpFile.Name(), labels, i.compression)
 	if err != nil {
 		_ = os.Remove(tmpFile.Name())
 		return nil, err
@@ -72,8 +73,11 @@ func (i *TarImage) createTarball(file *os.File, filePaths []string) error {
 					}
 					return i.addDirToTar(relPath, info, tarWriter)
 				}
+				if (info.Mode() & os.ModeSymlink) != 0 {
+					return i.addSymlinkToTar(path, walkedPath, relPath, tarWriter)
+				}
 				if (info.Mode() & os.ModeType) != 0 {
-					return fmt.Errorf("Expected file '%s' to be a regular file", walkedPath)
+					return fmt.Errorf("Expected file '%s' to be a regular file or a symlink", walkedPath)
 				}
 				return i.addFileToTar(walkedPath, relPath, info, tarWriter)
 			})
@@ -123,10 +127,17 @@ func (i *TarImage) addFileToTar(fullPath, relPath string, info os.FileInfo, tarW
 
 	defer file.Close()
 
+	// Only executable bit is preserved to keep
+	// the result independent of local umask
+	mode := int64(0600)
+	if info.Mode()&0111 != 0 {
+		mode = 0700
+	}
+
 	header := &tar.Header{
 		Name:     relPath,
 		Size:     info.Size(),
-		Mode:     0600,        // static
+		Mode:     mode,
 		ModTime:  time.Time{}, // static
 		Typeflag: tar.TypeReg,
 	}
@@ -140,6 +151,65 @@ func (i *TarImage) addFileToTar(fullPath, relPath string, info os.FileInfo, tarW
 	return err
 }
 
+func (i *TarImage) addSymlinkToTar(rootPath, fullPath, relPath string, tarWriter *tar.Writer) error {
+	if i.isExcluded(relPath) {
+		return nil
+	}
+
+	linkTarget, err := os.Readlink(fullPath)
+	if err != nil {
+		return err
+	}
+
+	err = i.checkSymlinkWithinRoot(rootPath, fullPath, linkTarget)
+	if err != nil {
+		return err
+	}
+
+	i.infoLog.Write([]byte(fmt.Sprintf("symlink: %s -> %s\n", relPath, linkTarget)))
+
+	header := &tar.Header{
+		Name:     relPath,
+		Linkname: linkTarget,
+		Mode:     0700,        // static
+		ModTime:  time.Time{}, // static
+		Typeflag: tar.TypeSymlink,
+	}
+
+	return tarWriter.WriteHeader(header)
+}
+
+// checkSymlinkWithinRoot makes sure that symlink does not allow
+// to reach files outside of the directory being pushed once pulled
+func (i *TarImage) checkSymlinkWithinRoot(rootPath, fullPath, linkTarget string) error {
+	escapeErr := fmt.Errorf("Expected symlink '%s' to point to a file within '%s', but it points to '%s'", fullPath, rootPath, linkTarget)
+
+	if filepath.IsAbs(linkTarget) || !isWithinDir(rootPath, filepath.Join(filepath.Dir(fullPath), linkTarget)) {
+		return escapeErr
+	}
+
+	// Also follow chain of symlinks (e.g. 'a -> .' and 'b -> a/..')
+	// since lexical check above does not account for them
+	resolvedPath, err := filepath.EvalSymlinks(fullPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil // dangling symlinks are preserved as is
+		}
+		return err
+	}
+
+	resolvedRootPath, err := filepath.EvalSymlinks(rootPath)
+	if err != nil {
+		return err
+	}
+
+	if !isWithinDir(resolvedRootPath, resolvedPath) {
+		return escapeErr
+	}
+
+	return nil
+}
+
 func (i *TarImage) isExcluded(relPath string) bool {
 	for _, path := range i.excludePaths {
 		if path == relPath {
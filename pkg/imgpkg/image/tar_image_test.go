@@ -0,0 +1,82 @@
+// Copyright 2021 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package image_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	goui "github.com/cppforlife/go-cli-ui/ui"
+	ctlimg "github.com/k14s/imgpkg/pkg/imgpkg/image"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTarImageSymlinks(t *testing.T) {
+	t.Run("symlinks pointing within directory are preserved", func(t *testing.T) {
+		assetsPath := createTempDir(t)
+		require.NoError(t, ioutil.WriteFile(filepath.Join(assetsPath, "file.yml"), []byte("content"), 0600))
+		require.NoError(t, os.Mkdir(filepath.Join(assetsPath, "nested"), 0700))
+		require.NoError(t, os.Symlink("file.yml", filepath.Join(assetsPath, "link.yml")))
+		require.NoError(t, os.Symlink("../file.yml", filepath.Join(assetsPath, "nested", "link.yml")))
+		require.NoError(t, os.Symlink("missing.yml", filepath.Join(assetsPath, "dangling.yml")))
+
+		fileImg, err := ctlimg.NewTarImage([]string{assetsPath}, nil, ctlimg.CompressionGzip, ioutil.Discard).AsFileImage(nil)
+		require.NoError(t, err)
+		defer fileImg.Remove()
+
+		outputPath := createTempDir(t)
+		require.NoError(t, ctlimg.NewDirImage(outputPath, fileImg, goui.NewNoopUI()).AsDirectory())
+
+		for linkPath, expectedTarget := range map[string]string{
+			"link.yml":        "file.yml",
+			"nested/link.yml": "../file.yml",
+			"dangling.yml":    "missing.yml",
+		} {
+			target, err := os.Readlink(filepath.Join(outputPath, linkPath))
+			require.NoError(t, err)
+			require.Equal(t, expectedTarget, target)
+		}
+
+		contents, err := ioutil.ReadFile(filepath.Join(outputPath, "nested", "link.yml"))
+		require.NoError(t, err)
+		require.Equal(t, "content", string(contents))
+	})
+
+	t.Run("symlinks pointing outside of directory are rejected", func(t *testing.T) {
+		outsidePath := createTempDir(t)
+		require.NoError(t, ioutil.WriteFile(filepath.Join(outsidePath, "secret"), []byte("secret"), 0600))
+
+		for linkName, target := range map[string]string{
+			"relative": "../" + filepath.Base(outsidePath) + "/secret",
+			"absolute": filepath.Join(outsidePath, "secret"),
+			"chained":  "self/..",
+		} {
+			assetsPath := createTempDir(t)
+			require.NoError(t, os.Symlink(".", filepath.Join(assetsPath, "self")))
+			require.NoError(t, os.Symlink(target, filepath.Join(assetsPath, linkName)))
+
+			_, err := ctlimg.NewTarImage([]string{assetsPath}, nil, ctlimg.CompressionGzip, ioutil.Discard).AsFileImage(nil)
+			require.Error(t, err, linkName)
+			require.Contains(t, err.Error(), "Expected symlink '"+filepath.Join(assetsPath, linkName)+"' to point to a file within")
+		}
+	})
+
+	t.Run("excluded symlinks pointing outside of directory are ignored", func(t *testing.T) {
+		assetsPath := createTempDir(t)
+		require.NoError(t, os.Symlink("/etc/passwd", filepath.Join(assetsPath, "passwd")))
+
+		fileImg, err := ctlimg.NewTarImage([]string{assetsPath}, []string{"passwd"}, ctlimg.CompressionGzip, ioutil.Discard).AsFileImage(nil)
+		require.NoError(t, err)
+		require.NoError(t, fileImg.Remove())
+	})
+}
+
+func createTempDir(t *testing.T) string {
+	path, err := ioutil.TempDir("", "imgpkg-test")
+	require.NoError(t, err)
+	t.Cleanup(func() { os.RemoveAll(path) })
+	return path
+}
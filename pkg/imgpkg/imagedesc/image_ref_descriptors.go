@@ -4,6 +4,7 @@
 package imagedesc
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -20,10 +21,10 @@ import (
 )
 
 type Registry interface {
-	Generic(regname.Reference) (regv1.Descriptor, error)
-	Digest(regname.Reference) (regv1.Hash, error)
-	Index(regname.Reference) (regv1.ImageIndex, error)
-	Image(regname.Reference) (regv1.Image, error)
+	Generic(context.Context, regname.Reference) (regv1.Descriptor, error)
+	Digest(context.Context, regname.Reference) (regv1.Hash, error)
+	Index(context.Context, regname.Reference) (regv1.ImageIndex, error)
+	Image(context.Context, regname.Reference) (regv1.Image, error)
 }
 
 type Metadata struct {
@@ -51,7 +52,7 @@ func NewImageRefDescriptorsFromBytes(data []byte) (*ImageRefDescriptors, error)
 	return &ImageRefDescriptors{descs: descs}, nil
 }
 
-func NewImageRefDescriptors(refs []Metadata, registry Registry) (*ImageRefDescriptors, error) {
+func NewImageRefDescriptors(ctx context.Context, refs []Metadata, registry Registry) (*ImageRefDescriptors, error) {
 	registry = errRegistry{registry}
 
 	imageRefDescs := &ImageRefDescriptors{
@@ -70,7 +71,7 @@ func NewImageRefDescriptors(refs []Metadata, registry Registry) (*ImageRefDescri
 			buildThrottle.Take()
 			defer buildThrottle.Done()
 
-			regDesc, err := registry.Generic(ref.Ref)
+			regDesc, err := registry.Generic(ctx, ref.Ref)
 			if err != nil {
 				return err
 			}
@@ -78,7 +79,7 @@ func NewImageRefDescriptors(refs []Metadata, registry Registry) (*ImageRefDescri
 			var td ImageOrImageIndexDescriptor
 
 			if imageRefDescs.isImageIndex(regDesc) {
-				imgIndexTd, err := imageRefDescs.buildImageIndex(ref, regDesc)
+				imgIndexTd, err := imageRefDescs.buildImageIndex(ctx, ref, regDesc)
 
 				if err != nil {
 					return err
@@ -86,7 +87,7 @@ func NewImageRefDescriptors(refs []Metadata, registry Registry) (*ImageRefDescri
 
 				td = ImageOrImageIndexDescriptor{ImageIndex: &imgIndexTd}
 			} else {
-				img, err := imageRefDescs.buildImage(ref)
+				img, err := imageRefDescs.buildImage(ctx, ref)
 				if err != nil {
 					return err
 				}
@@ -111,7 +112,7 @@ func (ids *ImageRefDescriptors) Descriptors() []ImageOrImageIndexDescriptor {
 	return ids.descs
 }
 
-func (ids *ImageRefDescriptors) buildImageIndex(ref Metadata, regDesc regv1.Descriptor) (ImageIndexDescriptor, error) {
+func (ids *ImageRefDescriptors) buildImageIndex(ctx context.Context, ref Metadata, regDesc regv1.Descriptor) (ImageIndexDescriptor, error) {
 	td := ImageIndexDescriptor{
 		Refs:      []string{ref.Ref.Name()},
 		MediaType: string(regDesc.MediaType),
@@ -119,7 +120,7 @@ func (ids *ImageRefDescriptors) buildImageIndex(ref Metadata, regDesc regv1.Desc
 		Tag:       ref.Tag,
 	}
 
-	imgIndex, err := ids.registry.Index(ref.Ref)
+	imgIndex, err := ids.registry.Index(ctx, ref.Ref)
 	if err != nil {
 		return td, err
 	}
@@ -138,13 +139,13 @@ func (ids *ImageRefDescriptors) buildImageIndex(ref Metadata, regDesc regv1.Desc
 
 	for _, manDesc := range imgIndexManifest.Manifests {
 		if ids.isImageIndex(manDesc) {
-			imgIndexTd, err := ids.buildImageIndex(Metadata{ids.buildRef(ref.Ref, manDesc.Digest.String()), ref.Tag}, manDesc)
+			imgIndexTd, err := ids.buildImageIndex(ctx, Metadata{ids.buildRef(ref.Ref, manDesc.Digest.String()), ref.Tag}, manDesc)
 			if err != nil {
 				return ImageIndexDescriptor{}, err
 			}
 			td.Indexes = append(td.Indexes, imgIndexTd)
 		} else {
-			imgTd, err := ids.buildImage(Metadata{ids.buildRef(ref.Ref, manDesc.Digest.String()), ref.Tag})
+			imgTd, err := ids.buildImage(ctx, Metadata{ids.buildRef(ref.Ref, manDesc.Digest.String()), ref.Tag})
 			if err != nil {
 				return ImageIndexDescriptor{}, err
 			}
@@ -155,10 +156,10 @@ func (ids *ImageRefDescriptors) buildImageIndex(ref Metadata, regDesc regv1.Desc
 	return td, nil
 }
 
-func (ids *ImageRefDescriptors) buildImage(ref Metadata) (ImageDescriptor, error) {
+func (ids *ImageRefDescriptors) buildImage(ctx context.Context, ref Metadata) (ImageDescriptor, error) {
 	td := ImageDescriptor{}
 
-	img, err := ids.registry.Image(ref.Ref)
+	img, err := ids.registry.Image(ctx, ref.Ref)
 	if err != nil {
 		return td, err
 	}
@@ -201,21 +202,19 @@ func (ids *ImageRefDescriptors) buildImage(ref Metadata) (ImageDescriptor, error
 		Tag: ref.Tag,
 	}
 
-	layers, err := img.Layers()
+	manifest, err := img.Manifest()
 	if err != nil {
 		return td, err
 	}
 
-	for _, layer := range layers {
-		layerMediaType, err := layer.MediaType()
+	// Layers are listed from the manifest rather than via img.Layers() since
+	// OCI artifacts (e.g. Helm charts) do not have diff ids in their config
+	for _, layerDesc := range manifest.Layers {
+		layer, err := img.LayerByDigest(layerDesc.Digest)
 		if err != nil {
 			return td, err
 		}
-		layerDigest, err := layer.Digest()
-		if err != nil {
-			return td, err
-		}
-		layerDiffID, err := layer.DiffID()
+		layerMediaType, err := layer.MediaType()
 		if err != nil {
 			return td, err
 		}
@@ -226,11 +225,18 @@ func (ids *ImageRefDescriptors) buildImage(ref Metadata) (ImageDescriptor, error
 
 		layerTD := ImageLayerDescriptor{
 			MediaType: string(layerMediaType),
-			Digest:    layerDigest.String(),
-			DiffID:    layerDiffID.String(),
+			Digest:    layerDesc.Digest.String(),
 			Size:      layerSize,
 		}
 
+		if IsImageConfig(manifest.Config.MediaType) {
+			layerDiffID, err := layer.DiffID()
+			if err != nil {
+				return td, err
+			}
+			layerTD.DiffID = layerDiffID.String()
+		}
+
 		td.Layers = append(td.Layers, layerTD)
 
 		ids.imageLayersLock.Lock()
@@ -296,23 +302,23 @@ type errRegistry struct {
 	delegate Registry
 }
 
-func (m errRegistry) Generic(ref regname.Reference) (regv1.Descriptor, error) {
-	regDesc, err := m.delegate.Generic(ref)
+func (m errRegistry) Generic(ctx context.Context, ref regname.Reference) (regv1.Descriptor, error) {
+	regDesc, err := m.delegate.Generic(ctx, ref)
 	return regDesc, m.betterErr(ref, err)
 }
 
-func (m errRegistry) Digest(ref regname.Reference) (regv1.Hash, error) {
-	regDesc, err := m.delegate.Digest(ref)
+func (m errRegistry) Digest(ctx context.Context, ref regname.Reference) (regv1.Hash, error) {
+	regDesc, err := m.delegate.Digest(ctx, ref)
 	return regDesc, m.betterErr(ref, err)
 }
 
-func (m errRegistry) Index(ref regname.Reference) (regv1.ImageIndex, error) {
-	idx, err := m.delegate.Index(ref)
+func (m errRegistry) Index(ctx context.Context, ref regname.Reference) (regv1.ImageIndex, error) {
+	idx, err := m.delegate.Index(ctx, ref)
 	return idx, m.betterErr(ref, err)
 }
 
-func (m errRegistry) Image(ref regname.Reference) (regv1.Image, error) {
-	img, err := m.delegate.Image(ref)
+func (m errRegistry) Image(ctx context.Context, ref regname.Reference) (regv1.Image, error) {
+	img, err := m.delegate.Image(ctx, ref)
 	return img, m.betterErr(ref, err)
 }
 
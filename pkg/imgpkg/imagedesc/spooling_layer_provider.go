@@ -0,0 +1,99 @@
+// Copyright 2021 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package imagedesc
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// SpoolingLayerProvider saves layers read from the wrapped provider
+// into a directory so that subsequent reads do not fetch them again
+// (e.g. when same images are imported into multiple repositories)
+type SpoolingLayerProvider struct {
+	layerProvider LayerProvider
+	dirPath       string
+}
+
+var _ LayerProvider = SpoolingLayerProvider{}
+
+func NewSpoolingLayerProvider(layerProvider LayerProvider, dirPath string) SpoolingLayerProvider {
+	return SpoolingLayerProvider{layerProvider, dirPath}
+}
+
+func (p SpoolingLayerProvider) FindLayer(layerTD ImageLayerDescriptor) (LayerContents, error) {
+	contents, err := p.layerProvider.FindLayer(layerTD)
+	if err != nil {
+		return nil, err
+	}
+
+	path := filepath.Join(p.dirPath, strings.Replace(layerTD.Digest, ":", "-", 1))
+
+	return spooledLayerContents{contents, path}, nil
+}
+
+type spooledLayerContents struct {
+	contents LayerContents
+	path     string
+}
+
+func (lc spooledLayerContents) Open() (io.ReadCloser, error) {
+	file, err := os.Open(lc.path)
+	if err == nil {
+		return file, nil
+	}
+
+	rc, err := lc.contents.Open()
+	if err != nil {
+		return nil, err
+	}
+
+	tmpFile, err := ioutil.TempFile(filepath.Dir(lc.path), "spooling-layer")
+	if err != nil {
+		rc.Close()
+		return nil, err
+	}
+
+	return &spoolingReadCloser{ReadCloser: rc, file: tmpFile, path: lc.path}, nil
+}
+
+type spoolingReadCloser struct {
+	io.ReadCloser
+	file      *os.File
+	path      string
+	failed    bool
+	completed bool
+}
+
+func (r *spoolingReadCloser) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	if n > 0 && !r.failed {
+		_, writeErr := r.file.Write(p[:n])
+		if writeErr != nil {
+			// Spooling is only an optimization, hence continue reading
+			r.failed = true
+		}
+	}
+	if err == io.EOF && !r.failed {
+		r.completed = true
+	}
+	return n, err
+}
+
+func (r *spoolingReadCloser) Close() error {
+	err := r.ReadCloser.Close()
+	r.file.Close()
+
+	// Only fully read layers are kept; rename makes
+	// sure other readers never see a partial layer
+	if r.completed && os.Rename(r.file.Name(), r.path) == nil {
+		return err
+	}
+
+	_ = os.Remove(r.file.Name())
+	return err
+}
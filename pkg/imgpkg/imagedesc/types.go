@@ -93,6 +93,16 @@ func (td ImageLayerDescriptor) IsDistributable() bool {
 	return regv1types.MediaType(td.MediaType).IsDistributable()
 }
 
+// IsImageConfig returns false when the config media type is the one of
+// an OCI artifact (e.g. a Helm chart), whose config does not describe layers
+func IsImageConfig(mediaType regv1types.MediaType) bool {
+	switch mediaType {
+	case regv1types.OCIConfigJSON, regv1types.DockerConfigJSON, "":
+		return true
+	}
+	return false
+}
+
 func (td ImageOrImageIndexDescriptor) SortKey() string {
 	switch {
 	case td.ImageIndex != nil:
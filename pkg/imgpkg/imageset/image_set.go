@@ -4,6 +4,7 @@
 package imageset
 
 import (
+	"context"
 	"fmt"
 	"sync"
 
@@ -18,10 +19,11 @@ import (
 //go:generate go run github.com/maxbrunsfeld/counterfeiter/v6 . ImagesReaderWriter
 type ImagesReaderWriter interface {
 	ctlimg.ImagesMetadata
-	MultiWrite(map[regname.Reference]regremote.Taggable, int) error
-	WriteImage(regname.Reference, regv1.Image) error
-	WriteIndex(regname.Reference, regv1.ImageIndex) error
-	WriteTag(regname.Tag, regremote.Taggable) error
+	MultiWrite(context.Context, map[regname.Reference]regremote.Taggable, int) ([]regv1.Hash, error)
+	WriteImage(context.Context, regname.Reference, regv1.Image) error
+	WriteIndex(context.Context, regname.Reference, regv1.ImageIndex) error
+	WriteTag(context.Context, regname.Tag, regremote.Taggable) error
+	ListTags(context.Context, regname.Repository) ([]string, error)
 }
 
 type ImageSet struct {
@@ -33,19 +35,19 @@ func NewImageSet(concurrency int, logger *ctlimg.LoggerPrefixWriter) ImageSet {
 	return ImageSet{concurrency, logger}
 }
 
-func (i ImageSet) Relocate(foundImages *UnprocessedImageRefs,
+func (i ImageSet) Relocate(ctx context.Context, foundImages *UnprocessedImageRefs,
 	importRepo regname.Repository, registry ImagesReaderWriter) (*ProcessedImages, *imagedesc.ImageRefDescriptors, error) {
 
-	ids, err := i.Export(foundImages, registry)
+	ids, err := i.Export(ctx, foundImages, registry)
 	if err != nil {
 		return nil, nil, err
 	}
 
-	images, err := i.Import(imagedesc.NewDescribedReader(ids, ids).Read(), importRepo, registry)
+	images, err := i.Import(ctx, imagedesc.NewDescribedReader(ids, ids).Read(), importRepo, registry)
 	return images, ids, err
 }
 
-func (i ImageSet) Export(foundImages *UnprocessedImageRefs,
+func (i ImageSet) Export(ctx context.Context, foundImages *UnprocessedImageRefs,
 	imagesMetadata ctlimg.ImagesMetadata) (*imagedesc.ImageRefDescriptors, error) {
 
 	i.logger.WriteStr("exporting %d images...\n", len(foundImages.All()))
@@ -63,7 +65,7 @@ func (i ImageSet) Export(foundImages *UnprocessedImageRefs,
 		refs = append(refs, imagedesc.Metadata{Ref: ref, Tag: img.Tag})
 	}
 
-	ids, err := imagedesc.NewImageRefDescriptors(refs, imagesMetadata)
+	ids, err := imagedesc.NewImageRefDescriptors(ctx, refs, imagesMetadata)
 	if err != nil {
 		return nil, fmt.Errorf("Collecting packaging metadata: %s", err)
 	}
@@ -71,7 +73,7 @@ func (i ImageSet) Export(foundImages *UnprocessedImageRefs,
 	return ids, nil
 }
 
-func (i *ImageSet) Import(imgOrIndexes []imagedesc.ImageOrIndex,
+func (i *ImageSet) Import(ctx context.Context, imgOrIndexes []imagedesc.ImageOrIndex,
 	importRepo regname.Repository, registry ImagesReaderWriter) (*ProcessedImages, error) {
 
 	importedImages := NewProcessedImages()
@@ -82,7 +84,10 @@ func (i *ImageSet) Import(imgOrIndexes []imagedesc.ImageOrIndex,
 	importThrottle := util.NewThrottle(i.concurrency)
 
 	imageOrIndexesToWrite := map[regname.Reference]regremote.Taggable{}
+	imageOrIndexesAlreadyPresent := map[regname.Tag]regremote.Taggable{}
 	var imageOrIndexesToWriteLock = &sync.Mutex{}
+	var layersToWrite []regv1.Hash
+	layersCount := layersPresence{}
 	errCh := make(chan error, len(imgOrIndexes))
 	for _, item := range imgOrIndexes {
 		item := item // copy
@@ -90,14 +95,25 @@ func (i *ImageSet) Import(imgOrIndexes []imagedesc.ImageOrIndex,
 		go func() {
 			importThrottle.Take()
 			defer importThrottle.Done()
-			tag, taggable, err := i.getImageOrImageIndexForMultiWrite(item, importRepo, registry)
+			tag, taggable, err := i.getImageOrImageIndexForMultiWrite(ctx, item, importRepo, registry)
 			if err != nil {
 				errCh <- err
 				return
 			}
+
+			itemLayers, err := layerDigests(item)
+			if err != nil {
+				errCh <- err
+				return
+			}
+
+			alreadyPresent := i.presentInDestination(ctx, item, importRepo, registry)
+
 			imageOrIndexesToWriteLock.Lock()
 			defer imageOrIndexesToWriteLock.Unlock()
 
+			layersCount.total += len(itemLayers)
+
 			itemDigest, err := item.Digest()
 			if err != nil {
 				errCh <- err
@@ -108,9 +124,19 @@ func (i *ImageSet) Import(imgOrIndexes []imagedesc.ImageOrIndex,
 				errCh <- err
 				return
 			}
+
+			if alreadyPresent {
+				i.logger.Write([]byte(fmt.Sprintf("skipping %s, already present in %s\n", item.Ref(), importDigestRef.Name())))
+				imageOrIndexesAlreadyPresent[tag] = taggable
+				layersCount.present += len(itemLayers)
+				errCh <- nil
+				return
+			}
+
 			i.logger.Write([]byte(fmt.Sprintf("importing %s -> %s...\n", item.Ref(), importDigestRef.Name())))
 
 			imageOrIndexesToWrite[tag] = taggable
+			layersToWrite = append(layersToWrite, itemLayers...)
 			errCh <- nil
 		}()
 	}
@@ -120,9 +146,23 @@ func (i *ImageSet) Import(imgOrIndexes []imagedesc.ImageOrIndex,
 		return nil, err
 	}
 
-	err = registry.MultiWrite(imageOrIndexesToWrite, i.concurrency)
-	if err != nil {
-		return nil, err
+	if len(imageOrIndexesToWrite) > 0 {
+		existingBlobs, err := registry.MultiWrite(ctx, imageOrIndexesToWrite, i.concurrency)
+		if err != nil {
+			return nil, err
+		}
+		layersCount.present += layersPresentIn(layersToWrite, existingBlobs)
+	}
+
+	i.logger.WriteStr("%d of %d layers already present\n", layersCount.present, layersCount.total)
+
+	// Manifests already present only need the upload tag,
+	// which is used to verify the import below
+	for tag, taggable := range imageOrIndexesAlreadyPresent {
+		err = registry.WriteTag(ctx, tag, taggable)
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	errChVerifyImages := make(chan error, len(imgOrIndexes))
@@ -133,7 +173,7 @@ func (i *ImageSet) Import(imgOrIndexes []imagedesc.ImageOrIndex,
 			importThrottle.Take()
 			defer importThrottle.Done()
 
-			processedImage, err := i.tagAndVerifyItem(item, importRepo, registry)
+			processedImage, err := i.tagAndVerifyItem(ctx, item, importRepo, registry)
 			importedImages.Add(processedImage)
 			errChVerifyImages <- err
 		}()
@@ -147,6 +187,61 @@ func (i *ImageSet) Import(imgOrIndexes []imagedesc.ImageOrIndex,
 	return importedImages, nil
 }
 
+type layersPresence struct {
+	present int
+	total   int
+}
+
+// presentInDestination checks if the item is already present in the import repository,
+// its layers are not checked since writing the item checks them anyway.
+// Failing to check is not an error since the item will be written to the repository.
+func (i ImageSet) presentInDestination(ctx context.Context, item imagedesc.ImageOrIndex, importRepo regname.Repository, registry ImagesReaderWriter) bool {
+	itemDigest, err := item.Digest()
+	if err != nil {
+		return false
+	}
+
+	_, err = registry.Digest(ctx, importRepo.Digest(itemDigest.String()))
+	return err == nil
+}
+
+func layerDigests(item imagedesc.ImageOrIndex) ([]regv1.Hash, error) {
+	if item.Image == nil {
+		return nil, nil
+	}
+
+	layers, err := (*item.Image).Layers()
+	if err != nil {
+		return nil, err
+	}
+
+	var digests []regv1.Hash
+	for _, layer := range layers {
+		digest, err := layer.Digest()
+		if err != nil {
+			return nil, err
+		}
+		digests = append(digests, digest)
+	}
+	return digests, nil
+}
+
+// layersPresentIn counts how many of layers are part of existingBlobs
+func layersPresentIn(layers []regv1.Hash, existingBlobs []regv1.Hash) int {
+	existing := map[regv1.Hash]struct{}{}
+	for _, digest := range existingBlobs {
+		existing[digest] = struct{}{}
+	}
+
+	var count int
+	for _, digest := range layers {
+		if _, found := existing[digest]; found {
+			count++
+		}
+	}
+	return count
+}
+
 func checkForAnyAsyncErrors(imgOrIndexes []imagedesc.ImageOrIndex, errCh chan error) error {
 	for i := 0; i < len(imgOrIndexes); i++ {
 		err := <-errCh
@@ -157,7 +252,7 @@ func checkForAnyAsyncErrors(imgOrIndexes []imagedesc.ImageOrIndex, errCh chan er
 	return nil
 }
 
-func (i ImageSet) getImageOrImageIndexForMultiWrite(item imagedesc.ImageOrIndex, importRepo regname.Repository, registry ImagesReaderWriter) (regname.Tag, regremote.Taggable, error) {
+func (i ImageSet) getImageOrImageIndexForMultiWrite(ctx context.Context, item imagedesc.ImageOrIndex, importRepo regname.Repository, registry ImagesReaderWriter) (regname.Tag, regremote.Taggable, error) {
 	uploadTagRef, err := buildUploadTagRef(item, importRepo)
 	if err != nil {
 		return regname.Tag{}, nil, err
@@ -166,7 +261,7 @@ func (i ImageSet) getImageOrImageIndexForMultiWrite(item imagedesc.ImageOrIndex,
 	var artifactToWrite regremote.Taggable
 	switch {
 	case item.Image != nil:
-		artifactToWrite, err = i.mountableImage(*item.Image, uploadTagRef, registry)
+		artifactToWrite, err = i.mountableImage(ctx, *item.Image, uploadTagRef, registry)
 		if err != nil {
 			return regname.Tag{}, nil, err
 		}
@@ -180,14 +275,16 @@ func (i ImageSet) getImageOrImageIndexForMultiWrite(item imagedesc.ImageOrIndex,
 	return uploadTagRef, artifactToWrite, nil
 }
 
-func (ImageSet) mountableImage(imageWithRef imagedesc.ImageWithRef, uploadTagRef regname.Tag, registry ImagesReaderWriter) (regremote.Taggable, error) {
+// mountableImage uses the image from the source registry when the destination is the same
+// registry, so that its layers are mounted rather than uploaded again
+func (ImageSet) mountableImage(ctx context.Context, imageWithRef imagedesc.ImageWithRef, uploadTagRef regname.Tag, registry ImagesReaderWriter) (regremote.Taggable, error) {
 	itemRef, err := regname.NewDigest(imageWithRef.Ref())
 	if err != nil {
 		return nil, fmt.Errorf("Unable to parse reference: %s: %s", imageWithRef.Ref(), err)
 	}
 
 	if imageBlobsCanBeMounted(itemRef, uploadTagRef) {
-		descriptor, err := registry.Get(itemRef)
+		descriptor, err := registry.Get(ctx, itemRef)
 		if err != nil {
 			// If a performance improvement cannot be done, fallback to the 'non-performant' way
 			return regv1.Image(imageWithRef), nil
@@ -216,18 +313,18 @@ func buildUploadTagRef(item imagedesc.ImageOrIndex, importRepo regname.Repositor
 	return uploadTagRef, nil
 }
 
-func (i *ImageSet) tagAndVerifyItem(item imagedesc.ImageOrIndex, importRepo regname.Repository, registry ImagesReaderWriter) (ProcessedImage, error) {
+func (i *ImageSet) tagAndVerifyItem(ctx context.Context, item imagedesc.ImageOrIndex, importRepo regname.Repository, registry ImagesReaderWriter) (ProcessedImage, error) {
 	existingRef, err := regname.NewDigest(item.Ref())
 	if err != nil {
 		return ProcessedImage{}, err
 	}
 
-	importDigestRef, err := i.verifyItemCopied(item, importRepo, registry)
+	importDigestRef, err := i.verifyItemCopied(ctx, item, importRepo, registry)
 	if err != nil {
 		return ProcessedImage{}, err
 	}
 
-	err = i.tagItemCopied(item, importRepo, registry, importDigestRef)
+	err = i.tagItemCopied(ctx, item, importRepo, registry, importDigestRef)
 	if err != nil {
 		return ProcessedImage{}, fmt.Errorf("Importing image %s: %s", existingRef.Name(), err)
 	}
@@ -248,7 +345,7 @@ func (i *ImageSet) tagAndVerifyItem(item imagedesc.ImageOrIndex, importRepo regn
 	}, nil
 }
 
-func (i *ImageSet) tagItemCopied(item imagedesc.ImageOrIndex, importRepo regname.Repository, registry ImagesReaderWriter, importDigestRef regname.Digest) error {
+func (i *ImageSet) tagItemCopied(ctx context.Context, item imagedesc.ImageOrIndex, importRepo regname.Repository, registry ImagesReaderWriter, importDigestRef regname.Digest) error {
 	if item.Tag() != "" {
 		uploadOriginalTagRef, err := regname.NewTag(fmt.Sprintf("%s:%s", importRepo.Name(), item.Tag()))
 		if err != nil {
@@ -257,13 +354,13 @@ func (i *ImageSet) tagItemCopied(item imagedesc.ImageOrIndex, importRepo regname
 
 		switch {
 		case item.Image != nil:
-			err = registry.WriteTag(uploadOriginalTagRef, *item.Image)
+			err = registry.WriteTag(ctx, uploadOriginalTagRef, *item.Image)
 			if err != nil {
 				return fmt.Errorf("Importing image as %s: %s", importDigestRef.Name(), err)
 			}
 
 		case item.Index != nil:
-			err = registry.WriteTag(uploadOriginalTagRef, *item.Index)
+			err = registry.WriteTag(ctx, uploadOriginalTagRef, *item.Index)
 			if err != nil {
 				return fmt.Errorf("Importing image index as %s: %s", importDigestRef.Name(), err)
 			}
@@ -275,7 +372,7 @@ func (i *ImageSet) tagItemCopied(item imagedesc.ImageOrIndex, importRepo regname
 	return nil
 }
 
-func (i *ImageSet) verifyItemCopied(item imagedesc.ImageOrIndex, importRepo regname.Repository, registry ImagesReaderWriter) (regname.Digest, error) {
+func (i *ImageSet) verifyItemCopied(ctx context.Context, item imagedesc.ImageOrIndex, importRepo regname.Repository, registry ImagesReaderWriter) (regname.Digest, error) {
 	itemDigest, err := item.Digest()
 	if err != nil {
 		return regname.Digest{}, err
@@ -296,17 +393,17 @@ func (i *ImageSet) verifyItemCopied(item imagedesc.ImageOrIndex, importRepo regn
 	// Being a little bit paranoid here because tag ref is used for import
 	// instead of plain digest ref, because AWS ECR doesnt like digests
 	// during manifest upload.
-	err = i.verifyTagDigest(uploadTagRef, importDigestRef, registry)
+	err = i.verifyTagDigest(ctx, uploadTagRef, importDigestRef, registry)
 	if err != nil {
 		return regname.Digest{}, err
 	}
 	return importDigestRef, nil
 }
 
-func (i *ImageSet) verifyTagDigest(
+func (i *ImageSet) verifyTagDigest(ctx context.Context,
 	uploadTagRef regname.Reference, importDigestRef regname.Digest, registry ImagesReaderWriter) error {
 
-	resultURL, err := getResolvedImageURL(uploadTagRef.Name(), registry)
+	resultURL, err := getResolvedImageURL(ctx, uploadTagRef.Name(), registry)
 	if err != nil {
 		return fmt.Errorf("Verifying imported image %s: %s", uploadTagRef.Name(), err)
 	}
@@ -324,13 +421,13 @@ func (i *ImageSet) verifyTagDigest(
 	return nil
 }
 
-func getResolvedImageURL(tagRef string, registry ctlimg.ImagesMetadata) (string, error) {
+func getResolvedImageURL(ctx context.Context, tagRef string, registry ctlimg.ImagesMetadata) (string, error) {
 	tag, err := regname.NewTag(tagRef, regname.WeakValidation)
 	if err != nil {
 		return "", err
 	}
 
-	hash, err := registry.Digest(tag)
+	hash, err := registry.Digest(ctx, tag)
 	if err != nil {
 		return "", err
 	}
@@ -2,6 +2,7 @@
 package imagesetfakes
 
 import (
+	"context"
 	"sync"
 
 	"github.com/google/go-containerregistry/pkg/name"
@@ -11,10 +12,11 @@ import (
 )
 
 type FakeImagesReaderWriter struct {
-	DigestStub        func(name.Reference) (v1.Hash, error)
+	DigestStub        func(context.Context, name.Reference) (v1.Hash, error)
 	digestMutex       sync.RWMutex
 	digestArgsForCall []struct {
-		arg1 name.Reference
+		arg1 context.Context
+		arg2 name.Reference
 	}
 	digestReturns struct {
 		result1 v1.Hash
@@ -24,10 +26,11 @@ type FakeImagesReaderWriter struct {
 		result1 v1.Hash
 		result2 error
 	}
-	GenericStub        func(name.Reference) (v1.Descriptor, error)
+	GenericStub        func(context.Context, name.Reference) (v1.Descriptor, error)
 	genericMutex       sync.RWMutex
 	genericArgsForCall []struct {
-		arg1 name.Reference
+		arg1 context.Context
+		arg2 name.Reference
 	}
 	genericReturns struct {
 		result1 v1.Descriptor
@@ -37,10 +40,11 @@ type FakeImagesReaderWriter struct {
 		result1 v1.Descriptor
 		result2 error
 	}
-	GetStub        func(name.Reference) (*remote.Descriptor, error)
+	GetStub        func(context.Context, name.Reference) (*remote.Descriptor, error)
 	getMutex       sync.RWMutex
 	getArgsForCall []struct {
-		arg1 name.Reference
+		arg1 context.Context
+		arg2 name.Reference
 	}
 	getReturns struct {
 		result1 *remote.Descriptor
@@ -50,10 +54,11 @@ type FakeImagesReaderWriter struct {
 		result1 *remote.Descriptor
 		result2 error
 	}
-	ImageStub        func(name.Reference) (v1.Image, error)
+	ImageStub        func(context.Context, name.Reference) (v1.Image, error)
 	imageMutex       sync.RWMutex
 	imageArgsForCall []struct {
-		arg1 name.Reference
+		arg1 context.Context
+		arg2 name.Reference
 	}
 	imageReturns struct {
 		result1 v1.Image
@@ -63,10 +68,11 @@ type FakeImagesReaderWriter struct {
 		result1 v1.Image
 		result2 error
 	}
-	IndexStub        func(name.Reference) (v1.ImageIndex, error)
+	IndexStub        func(context.Context, name.Reference) (v1.ImageIndex, error)
 	indexMutex       sync.RWMutex
 	indexArgsForCall []struct {
-		arg1 name.Reference
+		arg1 context.Context
+		arg2 name.Reference
 	}
 	indexReturns struct {
 		result1 v1.ImageIndex
@@ -76,23 +82,41 @@ type FakeImagesReaderWriter struct {
 		result1 v1.ImageIndex
 		result2 error
 	}
-	MultiWriteStub        func(map[name.Reference]remote.Taggable, int) error
+	ListTagsStub        func(context.Context, name.Repository) ([]string, error)
+	listTagsMutex       sync.RWMutex
+	listTagsArgsForCall []struct {
+		arg1 context.Context
+		arg2 name.Repository
+	}
+	listTagsReturns struct {
+		result1 []string
+		result2 error
+	}
+	listTagsReturnsOnCall map[int]struct {
+		result1 []string
+		result2 error
+	}
+	MultiWriteStub        func(context.Context, map[name.Reference]remote.Taggable, int) ([]v1.Hash, error)
 	multiWriteMutex       sync.RWMutex
 	multiWriteArgsForCall []struct {
-		arg1 map[name.Reference]remote.Taggable
-		arg2 int
+		arg1 context.Context
+		arg2 map[name.Reference]remote.Taggable
+		arg3 int
 	}
 	multiWriteReturns struct {
-		result1 error
+		result1 []v1.Hash
+		result2 error
 	}
 	multiWriteReturnsOnCall map[int]struct {
-		result1 error
+		result1 []v1.Hash
+		result2 error
 	}
-	WriteImageStub        func(name.Reference, v1.Image) error
+	WriteImageStub        func(context.Context, name.Reference, v1.Image) error
 	writeImageMutex       sync.RWMutex
 	writeImageArgsForCall []struct {
-		arg1 name.Reference
-		arg2 v1.Image
+		arg1 context.Context
+		arg2 name.Reference
+		arg3 v1.Image
 	}
 	writeImageReturns struct {
 		result1 error
@@ -100,11 +124,12 @@ type FakeImagesReaderWriter struct {
 	writeImageReturnsOnCall map[int]struct {
 		result1 error
 	}
-	WriteIndexStub        func(name.Reference, v1.ImageIndex) error
+	WriteIndexStub        func(context.Context, name.Reference, v1.ImageIndex) error
 	writeIndexMutex       sync.RWMutex
 	writeIndexArgsForCall []struct {
-		arg1 name.Reference
-		arg2 v1.ImageIndex
+		arg1 context.Context
+		arg2 name.Reference
+		arg3 v1.ImageIndex
 	}
 	writeIndexReturns struct {
 		result1 error
@@ -112,11 +137,12 @@ type FakeImagesReaderWriter struct {
 	writeIndexReturnsOnCall map[int]struct {
 		result1 error
 	}
-	WriteTagStub        func(name.Tag, remote.Taggable) error
+	WriteTagStub        func(context.Context, name.Tag, remote.Taggable) error
 	writeTagMutex       sync.RWMutex
 	writeTagArgsForCall []struct {
-		arg1 name.Tag
-		arg2 remote.Taggable
+		arg1 context.Context
+		arg2 name.Tag
+		arg3 remote.Taggable
 	}
 	writeTagReturns struct {
 		result1 error
@@ -128,21 +154,23 @@ type FakeImagesReaderWriter struct {
 	invocationsMutex sync.RWMutex
 }
 
-func (fake *FakeImagesReaderWriter) Digest(arg1 name.Reference) (v1.Hash, error) {
+func (fake *FakeImagesReaderWriter) Digest(arg1 context.Context, arg2 name.Reference) (v1.Hash, error) {
 	fake.digestMutex.Lock()
 	ret, specificReturn := fake.digestReturnsOnCall[len(fake.digestArgsForCall)]
 	fake.digestArgsForCall = append(fake.digestArgsForCall, struct {
-		arg1 name.Reference
-	}{arg1})
-	fake.recordInvocation("Digest", []interface{}{arg1})
+		arg1 context.Context
+		arg2 name.Reference
+	}{arg1, arg2})
+	stub := fake.DigestStub
+	fakeReturns := fake.digestReturns
+	fake.recordInvocation("Digest", []interface{}{arg1, arg2})
 	fake.digestMutex.Unlock()
-	if fake.DigestStub != nil {
-		return fake.DigestStub(arg1)
+	if stub != nil {
+		return stub(arg1, arg2)
 	}
 	if specificReturn {
 		return ret.result1, ret.result2
 	}
-	fakeReturns := fake.digestReturns
 	return fakeReturns.result1, fakeReturns.result2
 }
 
@@ -152,17 +180,17 @@ func (fake *FakeImagesReaderWriter) DigestCallCount() int {
 	return len(fake.digestArgsForCall)
 }
 
-func (fake *FakeImagesReaderWriter) DigestCalls(stub func(name.Reference) (v1.Hash, error)) {
+func (fake *FakeImagesReaderWriter) DigestCalls(stub func(context.Context, name.Reference) (v1.Hash, error)) {
 	fake.digestMutex.Lock()
 	defer fake.digestMutex.Unlock()
 	fake.DigestStub = stub
 }
 
-func (fake *FakeImagesReaderWriter) DigestArgsForCall(i int) name.Reference {
+func (fake *FakeImagesReaderWriter) DigestArgsForCall(i int) (context.Context, name.Reference) {
 	fake.digestMutex.RLock()
 	defer fake.digestMutex.RUnlock()
 	argsForCall := fake.digestArgsForCall[i]
-	return argsForCall.arg1
+	return argsForCall.arg1, argsForCall.arg2
 }
 
 func (fake *FakeImagesReaderWriter) DigestReturns(result1 v1.Hash, result2 error) {
@@ -191,21 +219,23 @@ func (fake *FakeImagesReaderWriter) DigestReturnsOnCall(i int, result1 v1.Hash,
 	}{result1, result2}
 }
 
-func (fake *FakeImagesReaderWriter) Generic(arg1 name.Reference) (v1.Descriptor, error) {
+func (fake *FakeImagesReaderWriter) Generic(arg1 context.Context, arg2 name.Reference) (v1.Descriptor, error) {
 	fake.genericMutex.Lock()
 	ret, specificReturn := fake.genericReturnsOnCall[len(fake.genericArgsForCall)]
 	fake.genericArgsForCall = append(fake.genericArgsForCall, struct {
-		arg1 name.Reference
-	}{arg1})
-	fake.recordInvocation("Generic", []interface{}{arg1})
+		arg1 context.Context
+		arg2 name.Reference
+	}{arg1, arg2})
+	stub := fake.GenericStub
+	fakeReturns := fake.genericReturns
+	fake.recordInvocation("Generic", []interface{}{arg1, arg2})
 	fake.genericMutex.Unlock()
-	if fake.GenericStub != nil {
-		return fake.GenericStub(arg1)
+	if stub != nil {
+		return stub(arg1, arg2)
 	}
 	if specificReturn {
 		return ret.result1, ret.result2
 	}
-	fakeReturns := fake.genericReturns
 	return fakeReturns.result1, fakeReturns.result2
 }
 
@@ -215,17 +245,17 @@ func (fake *FakeImagesReaderWriter) GenericCallCount() int {
 	return len(fake.genericArgsForCall)
 }
 
-func (fake *FakeImagesReaderWriter) GenericCalls(stub func(name.Reference) (v1.Descriptor, error)) {
+func (fake *FakeImagesReaderWriter) GenericCalls(stub func(context.Context, name.Reference) (v1.Descriptor, error)) {
 	fake.genericMutex.Lock()
 	defer fake.genericMutex.Unlock()
 	fake.GenericStub = stub
 }
 
-func (fake *FakeImagesReaderWriter) GenericArgsForCall(i int) name.Reference {
+func (fake *FakeImagesReaderWriter) GenericArgsForCall(i int) (context.Context, name.Reference) {
 	fake.genericMutex.RLock()
 	defer fake.genericMutex.RUnlock()
 	argsForCall := fake.genericArgsForCall[i]
-	return argsForCall.arg1
+	return argsForCall.arg1, argsForCall.arg2
 }
 
 func (fake *FakeImagesReaderWriter) GenericReturns(result1 v1.Descriptor, result2 error) {
@@ -254,21 +284,23 @@ func (fake *FakeImagesReaderWriter) GenericReturnsOnCall(i int, result1 v1.Descr
 	}{result1, result2}
 }
 
-func (fake *FakeImagesReaderWriter) Get(arg1 name.Reference) (*remote.Descriptor, error) {
+func (fake *FakeImagesReaderWriter) Get(arg1 context.Context, arg2 name.Reference) (*remote.Descriptor, error) {
 	fake.getMutex.Lock()
 	ret, specificReturn := fake.getReturnsOnCall[len(fake.getArgsForCall)]
 	fake.getArgsForCall = append(fake.getArgsForCall, struct {
-		arg1 name.Reference
-	}{arg1})
-	fake.recordInvocation("Get", []interface{}{arg1})
+		arg1 context.Context
+		arg2 name.Reference
+	}{arg1, arg2})
+	stub := fake.GetStub
+	fakeReturns := fake.getReturns
+	fake.recordInvocation("Get", []interface{}{arg1, arg2})
 	fake.getMutex.Unlock()
-	if fake.GetStub != nil {
-		return fake.GetStub(arg1)
+	if stub != nil {
+		return stub(arg1, arg2)
 	}
 	if specificReturn {
 		return ret.result1, ret.result2
 	}
-	fakeReturns := fake.getReturns
 	return fakeReturns.result1, fakeReturns.result2
 }
 
@@ -278,17 +310,17 @@ func (fake *FakeImagesReaderWriter) GetCallCount() int {
 	return len(fake.getArgsForCall)
 }
 
-func (fake *FakeImagesReaderWriter) GetCalls(stub func(name.Reference) (*remote.Descriptor, error)) {
+func (fake *FakeImagesReaderWriter) GetCalls(stub func(context.Context, name.Reference) (*remote.Descriptor, error)) {
 	fake.getMutex.Lock()
 	defer fake.getMutex.Unlock()
 	fake.GetStub = stub
 }
 
-func (fake *FakeImagesReaderWriter) GetArgsForCall(i int) name.Reference {
+func (fake *FakeImagesReaderWriter) GetArgsForCall(i int) (context.Context, name.Reference) {
 	fake.getMutex.RLock()
 	defer fake.getMutex.RUnlock()
 	argsForCall := fake.getArgsForCall[i]
-	return argsForCall.arg1
+	return argsForCall.arg1, argsForCall.arg2
 }
 
 func (fake *FakeImagesReaderWriter) GetReturns(result1 *remote.Descriptor, result2 error) {
@@ -317,21 +349,23 @@ func (fake *FakeImagesReaderWriter) GetReturnsOnCall(i int, result1 *remote.Desc
 	}{result1, result2}
 }
 
-func (fake *FakeImagesReaderWriter) Image(arg1 name.Reference) (v1.Image, error) {
+func (fake *FakeImagesReaderWriter) Image(arg1 context.Context, arg2 name.Reference) (v1.Image, error) {
 	fake.imageMutex.Lock()
 	ret, specificReturn := fake.imageReturnsOnCall[len(fake.imageArgsForCall)]
 	fake.imageArgsForCall = append(fake.imageArgsForCall, struct {
-		arg1 name.Reference
-	}{arg1})
-	fake.recordInvocation("Image", []interface{}{arg1})
+		arg1 context.Context
+		arg2 name.Reference
+	}{arg1, arg2})
+	stub := fake.ImageStub
+	fakeReturns := fake.imageReturns
+	fake.recordInvocation("Image", []interface{}{arg1, arg2})
 	fake.imageMutex.Unlock()
-	if fake.ImageStub != nil {
-		return fake.ImageStub(arg1)
+	if stub != nil {
+		return stub(arg1, arg2)
 	}
 	if specificReturn {
 		return ret.result1, ret.result2
 	}
-	fakeReturns := fake.imageReturns
 	return fakeReturns.result1, fakeReturns.result2
 }
 
@@ -341,17 +375,17 @@ func (fake *FakeImagesReaderWriter) ImageCallCount() int {
 	return len(fake.imageArgsForCall)
 }
 
-func (fake *FakeImagesReaderWriter) ImageCalls(stub func(name.Reference) (v1.Image, error)) {
+func (fake *FakeImagesReaderWriter) ImageCalls(stub func(context.Context, name.Reference) (v1.Image, error)) {
 	fake.imageMutex.Lock()
 	defer fake.imageMutex.Unlock()
 	fake.ImageStub = stub
 }
 
-func (fake *FakeImagesReaderWriter) ImageArgsForCall(i int) name.Reference {
+func (fake *FakeImagesReaderWriter) ImageArgsForCall(i int) (context.Context, name.Reference) {
 	fake.imageMutex.RLock()
 	defer fake.imageMutex.RUnlock()
 	argsForCall := fake.imageArgsForCall[i]
-	return argsForCall.arg1
+	return argsForCall.arg1, argsForCall.arg2
 }
 
 func (fake *FakeImagesReaderWriter) ImageReturns(result1 v1.Image, result2 error) {
@@ -380,21 +414,23 @@ func (fake *FakeImagesReaderWriter) ImageReturnsOnCall(i int, result1 v1.Image,
 	}{result1, result2}
 }
 
-func (fake *FakeImagesReaderWriter) Index(arg1 name.Reference) (v1.ImageIndex, error) {
+func (fake *FakeImagesReaderWriter) Index(arg1 context.Context, arg2 name.Reference) (v1.ImageIndex, error) {
 	fake.indexMutex.Lock()
 	ret, specificReturn := fake.indexReturnsOnCall[len(fake.indexArgsForCall)]
 	fake.indexArgsForCall = append(fake.indexArgsForCall, struct {
-		arg1 name.Reference
-	}{arg1})
-	fake.recordInvocation("Index", []interface{}{arg1})
+		arg1 context.Context
+		arg2 name.Reference
+	}{arg1, arg2})
+	stub := fake.IndexStub
+	fakeReturns := fake.indexReturns
+	fake.recordInvocation("Index", []interface{}{arg1, arg2})
 	fake.indexMutex.Unlock()
-	if fake.IndexStub != nil {
-		return fake.IndexStub(arg1)
+	if stub != nil {
+		return stub(arg1, arg2)
 	}
 	if specificReturn {
 		return ret.result1, ret.result2
 	}
-	fakeReturns := fake.indexReturns
 	return fakeReturns.result1, fakeReturns.result2
 }
 
@@ -404,17 +440,17 @@ func (fake *FakeImagesReaderWriter) IndexCallCount() int {
 	return len(fake.indexArgsForCall)
 }
 
-func (fake *FakeImagesReaderWriter) IndexCalls(stub func(name.Reference) (v1.ImageIndex, error)) {
+func (fake *FakeImagesReaderWriter) IndexCalls(stub func(context.Context, name.Reference) (v1.ImageIndex, error)) {
 	fake.indexMutex.Lock()
 	defer fake.indexMutex.Unlock()
 	fake.IndexStub = stub
 }
 
-func (fake *FakeImagesReaderWriter) IndexArgsForCall(i int) name.Reference {
+func (fake *FakeImagesReaderWriter) IndexArgsForCall(i int) (context.Context, name.Reference) {
 	fake.indexMutex.RLock()
 	defer fake.indexMutex.RUnlock()
 	argsForCall := fake.indexArgsForCall[i]
-	return argsForCall.arg1
+	return argsForCall.arg1, argsForCall.arg2
 }
 
 func (fake *FakeImagesReaderWriter) IndexReturns(result1 v1.ImageIndex, result2 error) {
@@ -443,23 +479,90 @@ func (fake *FakeImagesReaderWriter) IndexReturnsOnCall(i int, result1 v1.ImageIn
 	}{result1, result2}
 }
 
-func (fake *FakeImagesReaderWriter) MultiWrite(arg1 map[name.Reference]remote.Taggable, arg2 int) error {
+func (fake *FakeImagesReaderWriter) ListTags(arg1 context.Context, arg2 name.Repository) ([]string, error) {
+	fake.listTagsMutex.Lock()
+	ret, specificReturn := fake.listTagsReturnsOnCall[len(fake.listTagsArgsForCall)]
+	fake.listTagsArgsForCall = append(fake.listTagsArgsForCall, struct {
+		arg1 context.Context
+		arg2 name.Repository
+	}{arg1, arg2})
+	stub := fake.ListTagsStub
+	fakeReturns := fake.listTagsReturns
+	fake.recordInvocation("ListTags", []interface{}{arg1, arg2})
+	fake.listTagsMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeImagesReaderWriter) ListTagsCallCount() int {
+	fake.listTagsMutex.RLock()
+	defer fake.listTagsMutex.RUnlock()
+	return len(fake.listTagsArgsForCall)
+}
+
+func (fake *FakeImagesReaderWriter) ListTagsCalls(stub func(context.Context, name.Repository) ([]string, error)) {
+	fake.listTagsMutex.Lock()
+	defer fake.listTagsMutex.Unlock()
+	fake.ListTagsStub = stub
+}
+
+func (fake *FakeImagesReaderWriter) ListTagsArgsForCall(i int) (context.Context, name.Repository) {
+	fake.listTagsMutex.RLock()
+	defer fake.listTagsMutex.RUnlock()
+	argsForCall := fake.listTagsArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeImagesReaderWriter) ListTagsReturns(result1 []string, result2 error) {
+	fake.listTagsMutex.Lock()
+	defer fake.listTagsMutex.Unlock()
+	fake.ListTagsStub = nil
+	fake.listTagsReturns = struct {
+		result1 []string
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeImagesReaderWriter) ListTagsReturnsOnCall(i int, result1 []string, result2 error) {
+	fake.listTagsMutex.Lock()
+	defer fake.listTagsMutex.Unlock()
+	fake.ListTagsStub = nil
+	if fake.listTagsReturnsOnCall == nil {
+		fake.listTagsReturnsOnCall = make(map[int]struct {
+			result1 []string
+			result2 error
+		})
+	}
+	fake.listTagsReturnsOnCall[i] = struct {
+		result1 []string
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeImagesReaderWriter) MultiWrite(arg1 context.Context, arg2 map[name.Reference]remote.Taggable, arg3 int) ([]v1.Hash, error) {
 	fake.multiWriteMutex.Lock()
 	ret, specificReturn := fake.multiWriteReturnsOnCall[len(fake.multiWriteArgsForCall)]
 	fake.multiWriteArgsForCall = append(fake.multiWriteArgsForCall, struct {
-		arg1 map[name.Reference]remote.Taggable
-		arg2 int
-	}{arg1, arg2})
-	fake.recordInvocation("MultiWrite", []interface{}{arg1, arg2})
+		arg1 context.Context
+		arg2 map[name.Reference]remote.Taggable
+		arg3 int
+	}{arg1, arg2, arg3})
+	stub := fake.MultiWriteStub
+	fakeReturns := fake.multiWriteReturns
+	fake.recordInvocation("MultiWrite", []interface{}{arg1, arg2, arg3})
 	fake.multiWriteMutex.Unlock()
-	if fake.MultiWriteStub != nil {
-		return fake.MultiWriteStub(arg1, arg2)
+	if stub != nil {
+		return stub(arg1, arg2, arg3)
 	}
 	if specificReturn {
-		return ret.result1
+		return ret.result1, ret.result2
 	}
-	fakeReturns := fake.multiWriteReturns
-	return fakeReturns.result1
+	return fakeReturns.result1, fakeReturns.result2
 }
 
 func (fake *FakeImagesReaderWriter) MultiWriteCallCount() int {
@@ -468,58 +571,63 @@ func (fake *FakeImagesReaderWriter) MultiWriteCallCount() int {
 	return len(fake.multiWriteArgsForCall)
 }
 
-func (fake *FakeImagesReaderWriter) MultiWriteCalls(stub func(map[name.Reference]remote.Taggable, int) error) {
+func (fake *FakeImagesReaderWriter) MultiWriteCalls(stub func(context.Context, map[name.Reference]remote.Taggable, int) ([]v1.Hash, error)) {
 	fake.multiWriteMutex.Lock()
 	defer fake.multiWriteMutex.Unlock()
 	fake.MultiWriteStub = stub
 }
 
-func (fake *FakeImagesReaderWriter) MultiWriteArgsForCall(i int) (map[name.Reference]remote.Taggable, int) {
+func (fake *FakeImagesReaderWriter) MultiWriteArgsForCall(i int) (context.Context, map[name.Reference]remote.Taggable, int) {
 	fake.multiWriteMutex.RLock()
 	defer fake.multiWriteMutex.RUnlock()
 	argsForCall := fake.multiWriteArgsForCall[i]
-	return argsForCall.arg1, argsForCall.arg2
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
 }
 
-func (fake *FakeImagesReaderWriter) MultiWriteReturns(result1 error) {
+func (fake *FakeImagesReaderWriter) MultiWriteReturns(result1 []v1.Hash, result2 error) {
 	fake.multiWriteMutex.Lock()
 	defer fake.multiWriteMutex.Unlock()
 	fake.MultiWriteStub = nil
 	fake.multiWriteReturns = struct {
-		result1 error
-	}{result1}
+		result1 []v1.Hash
+		result2 error
+	}{result1, result2}
 }
 
-func (fake *FakeImagesReaderWriter) MultiWriteReturnsOnCall(i int, result1 error) {
+func (fake *FakeImagesReaderWriter) MultiWriteReturnsOnCall(i int, result1 []v1.Hash, result2 error) {
 	fake.multiWriteMutex.Lock()
 	defer fake.multiWriteMutex.Unlock()
 	fake.MultiWriteStub = nil
 	if fake.multiWriteReturnsOnCall == nil {
 		fake.multiWriteReturnsOnCall = make(map[int]struct {
-			result1 error
+			result1 []v1.Hash
+			result2 error
 		})
 	}
 	fake.multiWriteReturnsOnCall[i] = struct {
-		result1 error
-	}{result1}
+		result1 []v1.Hash
+		result2 error
+	}{result1, result2}
 }
 
-func (fake *FakeImagesReaderWriter) WriteImage(arg1 name.Reference, arg2 v1.Image) error {
+func (fake *FakeImagesReaderWriter) WriteImage(arg1 context.Context, arg2 name.Reference, arg3 v1.Image) error {
 	fake.writeImageMutex.Lock()
 	ret, specificReturn := fake.writeImageReturnsOnCall[len(fake.writeImageArgsForCall)]
 	fake.writeImageArgsForCall = append(fake.writeImageArgsForCall, struct {
-		arg1 name.Reference
-		arg2 v1.Image
-	}{arg1, arg2})
-	fake.recordInvocation("WriteImage", []interface{}{arg1, arg2})
+		arg1 context.Context
+		arg2 name.Reference
+		arg3 v1.Image
+	}{arg1, arg2, arg3})
+	stub := fake.WriteImageStub
+	fakeReturns := fake.writeImageReturns
+	fake.recordInvocation("WriteImage", []interface{}{arg1, arg2, arg3})
 	fake.writeImageMutex.Unlock()
-	if fake.WriteImageStub != nil {
-		return fake.WriteImageStub(arg1, arg2)
+	if stub != nil {
+		return stub(arg1, arg2, arg3)
 	}
 	if specificReturn {
 		return ret.result1
 	}
-	fakeReturns := fake.writeImageReturns
 	return fakeReturns.result1
 }
 
@@ -529,17 +637,17 @@ func (fake *FakeImagesReaderWriter) WriteImageCallCount() int {
 	return len(fake.writeImageArgsForCall)
 }
 
-func (fake *FakeImagesReaderWriter) WriteImageCalls(stub func(name.Reference, v1.Image) error) {
+func (fake *FakeImagesReaderWriter) WriteImageCalls(stub func(context.Context, name.Reference, v1.Image) error) {
 	fake.writeImageMutex.Lock()
 	defer fake.writeImageMutex.Unlock()
 	fake.WriteImageStub = stub
 }
 
-func (fake *FakeImagesReaderWriter) WriteImageArgsForCall(i int) (name.Reference, v1.Image) {
+func (fake *FakeImagesReaderWriter) WriteImageArgsForCall(i int) (context.Context, name.Reference, v1.Image) {
 	fake.writeImageMutex.RLock()
 	defer fake.writeImageMutex.RUnlock()
 	argsForCall := fake.writeImageArgsForCall[i]
-	return argsForCall.arg1, argsForCall.arg2
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
 }
 
 func (fake *FakeImagesReaderWriter) WriteImageReturns(result1 error) {
@@ -565,22 +673,24 @@ func (fake *FakeImagesReaderWriter) WriteImageReturnsOnCall(i int, result1 error
 	}{result1}
 }
 
-func (fake *FakeImagesReaderWriter) WriteIndex(arg1 name.Reference, arg2 v1.ImageIndex) error {
+func (fake *FakeImagesReaderWriter) WriteIndex(arg1 context.Context, arg2 name.Reference, arg3 v1.ImageIndex) error {
 	fake.writeIndexMutex.Lock()
 	ret, specificReturn := fake.writeIndexReturnsOnCall[len(fake.writeIndexArgsForCall)]
 	fake.writeIndexArgsForCall = append(fake.writeIndexArgsForCall, struct {
-		arg1 name.Reference
-		arg2 v1.ImageIndex
-	}{arg1, arg2})
-	fake.recordInvocation("WriteIndex", []interface{}{arg1, arg2})
+		arg1 context.Context
+		arg2 name.Reference
+		arg3 v1.ImageIndex
+	}{arg1, arg2, arg3})
+	stub := fake.WriteIndexStub
+	fakeReturns := fake.writeIndexReturns
+	fake.recordInvocation("WriteIndex", []interface{}{arg1, arg2, arg3})
 	fake.writeIndexMutex.Unlock()
-	if fake.WriteIndexStub != nil {
-		return fake.WriteIndexStub(arg1, arg2)
+	if stub != nil {
+		return stub(arg1, arg2, arg3)
 	}
 	if specificReturn {
 		return ret.result1
 	}
-	fakeReturns := fake.writeIndexReturns
 	return fakeReturns.result1
 }
 
@@ -590,17 +700,17 @@ func (fake *FakeImagesReaderWriter) WriteIndexCallCount() int {
 	return len(fake.writeIndexArgsForCall)
 }
 
-func (fake *FakeImagesReaderWriter) WriteIndexCalls(stub func(name.Reference, v1.ImageIndex) error) {
+func (fake *FakeImagesReaderWriter) WriteIndexCalls(stub func(context.Context, name.Reference, v1.ImageIndex) error) {
 	fake.writeIndexMutex.Lock()
 	defer fake.writeIndexMutex.Unlock()
 	fake.WriteIndexStub = stub
 }
 
-func (fake *FakeImagesReaderWriter) WriteIndexArgsForCall(i int) (name.Reference, v1.ImageIndex) {
+func (fake *FakeImagesReaderWriter) WriteIndexArgsForCall(i int) (context.Context, name.Reference, v1.ImageIndex) {
 	fake.writeIndexMutex.RLock()
 	defer fake.writeIndexMutex.RUnlock()
 	argsForCall := fake.writeIndexArgsForCall[i]
-	return argsForCall.arg1, argsForCall.arg2
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
 }
 
 func (fake *FakeImagesReaderWriter) WriteIndexReturns(result1 error) {
@@ -626,22 +736,24 @@ func (fake *FakeImagesReaderWriter) WriteIndexReturnsOnCall(i int, result1 error
 	}{result1}
 }
 
-func (fake *FakeImagesReaderWriter) WriteTag(arg1 name.Tag, arg2 remote.Taggable) error {
+func (fake *FakeImagesReaderWriter) WriteTag(arg1 context.Context, arg2 name.Tag, arg3 remote.Taggable) error {
 	fake.writeTagMutex.Lock()
 	ret, specificReturn := fake.writeTagReturnsOnCall[len(fake.writeTagArgsForCall)]
 	fake.writeTagArgsForCall = append(fake.writeTagArgsForCall, struct {
-		arg1 name.Tag
-		arg2 remote.Taggable
-	}{arg1, arg2})
-	fake.recordInvocation("WriteTag", []interface{}{arg1, arg2})
+		arg1 context.Context
+		arg2 name.Tag
+		arg3 remote.Taggable
+	}{arg1, arg2, arg3})
+	stub := fake.WriteTagStub
+	fakeReturns := fake.writeTagReturns
+	fake.recordInvocation("WriteTag", []interface{}{arg1, arg2, arg3})
 	fake.writeTagMutex.Unlock()
-	if fake.WriteTagStub != nil {
-		return fake.WriteTagStub(arg1, arg2)
+	if stub != nil {
+		return stub(arg1, arg2, arg3)
 	}
 	if specificReturn {
 		return ret.result1
 	}
-	fakeReturns := fake.writeTagReturns
 	return fakeReturns.result1
 }
 
@@ -651,17 +763,17 @@ func (fake *FakeImagesReaderWriter) WriteTagCallCount() int {
 	return len(fake.writeTagArgsForCall)
 }
 
-func (fake *FakeImagesReaderWriter) WriteTagCalls(stub func(name.Tag, remote.Taggable) error) {
+func (fake *FakeImagesReaderWriter) WriteTagCalls(stub func(context.Context, name.Tag, remote.Taggable) error) {
 	fake.writeTagMutex.Lock()
 	defer fake.writeTagMutex.Unlock()
 	fake.WriteTagStub = stub
 }
 
-func (fake *FakeImagesReaderWriter) WriteTagArgsForCall(i int) (name.Tag, remote.Taggable) {
+func (fake *FakeImagesReaderWriter) WriteTagArgsForCall(i int) (context.Context, name.Tag, remote.Taggable) {
 	fake.writeTagMutex.RLock()
 	defer fake.writeTagMutex.RUnlock()
 	argsForCall := fake.writeTagArgsForCall[i]
-	return argsForCall.arg1, argsForCall.arg2
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
 }
 
 func (fake *FakeImagesReaderWriter) WriteTagReturns(result1 error) {
@@ -700,6 +812,8 @@ func (fake *FakeImagesReaderWriter) Invocations() map[string][][]interface{} {
 	defer fake.imageMutex.RUnlock()
 	fake.indexMutex.RLock()
 	defer fake.indexMutex.RUnlock()
+	fake.listTagsMutex.RLock()
+	defer fake.listTagsMutex.RUnlock()
 	fake.multiWriteMutex.RLock()
 	defer fake.multiWriteMutex.RUnlock()
 	fake.writeImageMutex.RLock()
@@ -4,6 +4,7 @@
 package imageset
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"os"
@@ -24,8 +25,8 @@ func NewTarImageSet(imageSet ImageSet, concurrency int, logger *ctlimg.LoggerPre
 	return TarImageSet{imageSet, concurrency, logger}
 }
 
-func (i TarImageSet) Export(foundImages *UnprocessedImageRefs, outputPath string, registry ImagesReaderWriter, imageLayerWriterCheck imagetar.ImageLayerWriterFilter) (*imagedesc.ImageRefDescriptors, error) {
-	ids, err := i.imageSet.Export(foundImages, registry)
+func (i TarImageSet) Export(ctx context.Context, foundImages *UnprocessedImageRefs, outputPath string, registry ImagesReaderWriter, imageLayerWriterCheck imagetar.ImageLayerWriterFilter) (*imagedesc.ImageRefDescriptors, error) {
+	ids, err := i.imageSet.Export(ctx, foundImages, registry)
 	if err != nil {
 		return nil, err
 	}
@@ -51,7 +52,7 @@ func (i TarImageSet) Export(foundImages *UnprocessedImageRefs, outputPath string
 	return ids, imagetar.NewTarWriter(ids, outputFileOpener, opts, i.logger, imageLayerWriterCheck).Write()
 }
 
-func (i *TarImageSet) Import(path string,
+func (i *TarImageSet) Import(ctx context.Context, path string,
 	importRepo regname.Repository, registry ImagesReaderWriter) (*ProcessedImages, error) {
 
 	imgOrIndexes, err := imagetar.NewTarReader(path).Read()
@@ -59,6 +60,6 @@ func (i *TarImageSet) Import(path string,
 		return nil, err
 	}
 
-	processedImages, err := i.imageSet.Import(imgOrIndexes, importRepo, registry)
+	processedImages, err := i.imageSet.Import(ctx, imgOrIndexes, importRepo, registry)
 	return processedImages, err
 }
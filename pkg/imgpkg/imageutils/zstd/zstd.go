@@ -0,0 +1,57 @@
+// Copyright 2021 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package zstd
+
+import (
+	"io"
+
+	"github.com/k14s/imgpkg/pkg/imgpkg/imageutils/and"
+	"github.com/klauspost/compress/zstd"
+)
+
+// ReadCloser reads uncompressed input data from the io.ReadCloser and
+// returns an io.ReadCloser from which zstd compressed data may be read.
+func ReadCloser(r io.ReadCloser) io.ReadCloser {
+	pr, pw := io.Pipe()
+
+	go func() {
+		zw, err := zstd.NewWriter(pw)
+		if err != nil {
+			r.Close()
+			pw.CloseWithError(err)
+			return
+		}
+
+		_, err = io.Copy(zw, r)
+		if err != nil {
+			r.Close()
+			zw.Close()
+			pw.CloseWithError(err)
+			return
+		}
+
+		// Close zstd writer to flush remaining frames
+		err = zw.Close()
+		r.Close()
+		pw.CloseWithError(err)
+	}()
+
+	return pr
+}
+
+// UnzipReadCloser reads zstd compressed input data from the io.ReadCloser and
+// returns an io.ReadCloser from which uncompressed data may be read.
+func UnzipReadCloser(r io.ReadCloser) (io.ReadCloser, error) {
+	zr, err := zstd.NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+	return &and.ReadCloser{
+		Reader: zr,
+		CloseFunc: func() error {
+			zr.Close()
+			return r.Close()
+		},
+	}, nil
+}
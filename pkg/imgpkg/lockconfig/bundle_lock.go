@@ -7,7 +7,6 @@ import (
 	"fmt"
 	"io/ioutil"
 
-	regname "github.com/google/go-containerregistry/pkg/name"
 	"sigs.k8s.io/yaml"
 )
 
@@ -36,6 +35,10 @@ func NewBundleLockFromPath(path string) (BundleLock, error) {
 }
 
 func NewBundleLockFromBytes(data []byte) (BundleLock, error) {
+	return newBundleLockFromBytes(data, false)
+}
+
+func newBundleLockFromBytes(data []byte, allowTags bool) (BundleLock, error) {
 	var lock BundleLock
 
 	err := yaml.UnmarshalStrict(data, &lock)
@@ -43,7 +46,7 @@ func NewBundleLockFromBytes(data []byte) (BundleLock, error) {
 		return lock, fmt.Errorf("Unmarshaling bundle lock: %s", err)
 	}
 
-	err = lock.Validate()
+	err = lock.validate(allowTags)
 	if err != nil {
 		return lock, fmt.Errorf("Validating bundle lock: %s", err)
 	}
@@ -52,16 +55,20 @@ func NewBundleLockFromBytes(data []byte) (BundleLock, error) {
 }
 
 func (b BundleLock) Validate() error {
+	return b.validate(false)
+}
+
+func (b BundleLock) validate(allowTags bool) error {
 	if b.APIVersion != BundleLockAPIVersion {
 		return fmt.Errorf("Validating apiVersion: Unknown version (known: %s)", BundleLockAPIVersion)
 	}
 	if b.Kind != BundleLockKind {
 		return fmt.Errorf("Validating kind: Unknown kind (known: %s)", BundleLockKind)
 	}
-	if _, err := regname.NewDigest(b.Bundle.Image); err != nil {
-		return fmt.Errorf("Expected ref to be in digest form, got '%s'", b.Bundle.Image)
+	if allowTags {
+		return validateRefs([]string{b.Bundle.Image})
 	}
-	return nil
+	return validateDigestRefs([]string{b.Bundle.Image})
 }
 
 func (b BundleLock) AsBytes() ([]byte, error) {
@@ -0,0 +1,118 @@
+// Copyright 2021 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package lockconfig
+
+import (
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	BundlesLockKind       = "BundlesLock"
+	BundlesLockAPIVersion = "imgpkg.carvel.dev/v1alpha1"
+)
+
+// BundlesLock lists related bundles so that they can be copied or pulled together
+type BundlesLock struct {
+	LockVersion
+	Bundles []NamedBundleRef `json:"bundles"` // This generated yaml, but due to lib we need to use `json`
+}
+
+type NamedBundleRef struct {
+	// Name identifies the bundle within the lock, e.g. it is
+	// the directory the bundle is pulled into
+	Name  string `json:"name"`            // This generated yaml, but due to lib we need to use `json`
+	Image string `json:"image,omitempty"` // This generated yaml, but due to lib we need to use `json`
+	Tag   string `json:"tag,omitempty"`   // This generated yaml, but due to lib we need to use `json`
+}
+
+func NewBundlesLockFromPath(path string) (BundlesLock, error) {
+	bs, err := ioutil.ReadFile(path)
+	if err != nil {
+		return BundlesLock{}, fmt.Errorf("Reading path %s: %s", path, err)
+	}
+
+	return NewBundlesLockFromBytes(bs)
+}
+
+func NewBundlesLockFromBytes(data []byte) (BundlesLock, error) {
+	return newBundlesLockFromBytes(data, false)
+}
+
+func newBundlesLockFromBytes(data []byte, allowTags bool) (BundlesLock, error) {
+	var lock BundlesLock
+
+	err := yaml.UnmarshalStrict(data, &lock)
+	if err != nil {
+		return lock, fmt.Errorf("Unmarshaling bundles lock: %s", err)
+	}
+
+	err = lock.validate(allowTags)
+	if err != nil {
+		return lock, fmt.Errorf("Validating bundles lock: %s", err)
+	}
+
+	return lock, nil
+}
+
+func (b BundlesLock) Validate() error {
+	return b.validate(false)
+}
+
+func (b BundlesLock) validate(allowTags bool) error {
+	if b.APIVersion != BundlesLockAPIVersion {
+		return fmt.Errorf("Validating apiVersion: Unknown version (known: %s)", BundlesLockAPIVersion)
+	}
+	if b.Kind != BundlesLockKind {
+		return fmt.Errorf("Validating kind: Unknown kind (known: %s)", BundlesLockKind)
+	}
+
+	var refs []string
+	seenNames := map[string]struct{}{}
+	for _, bundleRef := range b.Bundles {
+		if bundleRef.Name == "" || bundleRef.Name == "." || bundleRef.Name == ".." || strings.ContainsAny(bundleRef.Name, `/\`) {
+			return fmt.Errorf("Expected bundle name to be usable as a directory name, got '%s'", bundleRef.Name)
+		}
+		if _, seen := seenNames[bundleRef.Name]; seen {
+			return fmt.Errorf("Expected bundle names to be unique, got '%s' more than once", bundleRef.Name)
+		}
+		seenNames[bundleRef.Name] = struct{}{}
+		refs = append(refs, bundleRef.Image)
+	}
+	if allowTags {
+		return validateRefs(refs)
+	}
+	return validateDigestRefs(refs)
+}
+
+func (b BundlesLock) AsBytes() ([]byte, error) {
+	err := b.Validate()
+	if err != nil {
+		return nil, fmt.Errorf("Validating bundles lock: %s", err)
+	}
+
+	bs, err := yaml.Marshal(b)
+	if err != nil {
+		return nil, fmt.Errorf("Marshaling config: %s", err)
+	}
+
+	return []byte(fmt.Sprintf("---\n%s", bs)), nil
+}
+
+func (b BundlesLock) WriteToPath(path string) error {
+	bs, err := b.AsBytes()
+	if err != nil {
+		return err
+	}
+
+	err = ioutil.WriteFile(path, bs, 0600)
+	if err != nil {
+		return fmt.Errorf("Writing bundles config: %s", err)
+	}
+
+	return nil
+}
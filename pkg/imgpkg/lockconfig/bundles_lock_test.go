@@ -0,0 +1,121 @@
+// Copyright 2021 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package lockconfig_test
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/k14s/imgpkg/pkg/imgpkg/lockconfig"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewBundlesLockFromBytes(t *testing.T) {
+	t.Run("when bundle reference is not resolved, it errors", func(t *testing.T) {
+		data := `
+apiVersion: imgpkg.carvel.dev/v1alpha1
+kind: BundlesLock
+bundles:
+- name: app
+  image: repo/app:v1
+`
+
+		_, err := lockconfig.NewBundlesLockFromBytes([]byte(data))
+		require.EqualError(t, err, "Validating bundles lock: Expected ref to be in digest form, got 'repo/app:v1'")
+	})
+
+	t.Run("when the kind is not BundlesLock, it errors", func(t *testing.T) {
+		data := `
+apiVersion: imgpkg.carvel.dev/v1alpha1
+kind: BundleLock
+bundles: []
+`
+
+		_, err := lockconfig.NewBundlesLockFromBytes([]byte(data))
+		require.EqualError(t, err, "Validating bundles lock: Validating kind: Unknown kind (known: BundlesLock)")
+	})
+
+	t.Run("when a bundle name cannot be used as a directory name, it errors", func(t *testing.T) {
+		for _, name := range []string{"", "..", "nested/app"} {
+			data := `
+apiVersion: imgpkg.carvel.dev/v1alpha1
+kind: BundlesLock
+bundles:
+- name: "` + name + `"
+  image: repo/app@sha256:4c8b96d4fffdfae29258d94a22ae4ad1fe36139d47288b8960d9958d1e63a9d0
+`
+
+			_, err := lockconfig.NewBundlesLockFromBytes([]byte(data))
+			require.EqualError(t, err, "Validating bundles lock: Expected bundle name to be usable as a directory name, got '"+name+"'")
+		}
+	})
+
+	t.Run("when bundle names are repeated, it errors", func(t *testing.T) {
+		data := `
+apiVersion: imgpkg.carvel.dev/v1alpha1
+kind: BundlesLock
+bundles:
+- name: app
+  image: repo/app@sha256:4c8b96d4fffdfae29258d94a22ae4ad1fe36139d47288b8960d9958d1e63a9d0
+- name: app
+  image: repo/other@sha256:45f3926bca9fc42adb650fef2a41250d77841dde49afc8adc7c0c633b3d5f27a
+`
+
+		_, err := lockconfig.NewBundlesLockFromBytes([]byte(data))
+		require.EqualError(t, err, "Validating bundles lock: Expected bundle names to be unique, got 'app' more than once")
+	})
+}
+
+func TestBundlesLockRoundTrip(t *testing.T) {
+	data := `
+apiVersion: imgpkg.carvel.dev/v1alpha1
+kind: BundlesLock
+bundles:
+- name: app
+  image: some.image.io/app@sha256:4c8b96d4fffdfae29258d94a22ae4ad1fe36139d47288b8960d9958d1e63a9d0
+  tag: v1.0.0
+- name: db
+  image: some.image.io/db@sha256:45f3926bca9fc42adb650fef2a41250d77841dde49afc8adc7c0c633b3d5f27a
+`
+	expectedOutput := `---
+apiVersion: imgpkg.carvel.dev/v1alpha1
+bundles:
+- image: some.image.io/app@sha256:4c8b96d4fffdfae29258d94a22ae4ad1fe36139d47288b8960d9958d1e63a9d0
+  name: app
+  tag: v1.0.0
+- image: some.image.io/db@sha256:45f3926bca9fc42adb650fef2a41250d77841dde49afc8adc7c0c633b3d5f27a
+  name: db
+kind: BundlesLock
+`
+
+	subject, err := lockconfig.NewBundlesLockFromBytes([]byte(data))
+	require.NoError(t, err)
+
+	assert.Equal(t, []lockconfig.NamedBundleRef{
+		{Name: "app", Image: "some.image.io/app@sha256:4c8b96d4fffdfae29258d94a22ae4ad1fe36139d47288b8960d9958d1e63a9d0", Tag: "v1.0.0"},
+		{Name: "db", Image: "some.image.io/db@sha256:45f3926bca9fc42adb650fef2a41250d77841dde49afc8adc7c0c633b3d5f27a"},
+	}, subject.Bundles)
+
+	outputPath := filepath.Join(t.TempDir(), "bundles.yml")
+	require.NoError(t, subject.WriteToPath(outputPath))
+
+	output, err := ioutil.ReadFile(outputPath)
+	require.NoError(t, err)
+	assert.Equal(t, expectedOutput, string(output))
+
+	reparsed, err := lockconfig.NewBundlesLockFromPath(outputPath)
+	require.NoError(t, err)
+	assert.Equal(t, subject, reparsed)
+
+	t.Run("reading it as a lock of unknown kind returns a bundles lock", func(t *testing.T) {
+		bundleLock, imagesLock, bundlesLock, err := lockconfig.NewLockFromPath(outputPath, lockconfig.ReadOpts{})
+		require.NoError(t, err)
+		assert.Nil(t, bundleLock)
+		assert.Nil(t, imagesLock)
+		require.NotNil(t, bundlesLock)
+		assert.Equal(t, subject, *bundlesLock)
+	})
+}
@@ -5,6 +5,11 @@ package lockconfig
 
 import (
 	"fmt"
+	"io/ioutil"
+	"strings"
+
+	regname "github.com/google/go-containerregistry/pkg/name"
+	"sigs.k8s.io/yaml"
 )
 
 type LockVersion struct {
@@ -12,14 +17,81 @@ type LockVersion struct {
 	Kind       string `json:"kind"`       // This generated yaml, but due to lib we need to use `json`
 }
 
-func NewLockFromPath(path string) (*BundleLock, *ImagesLock, error) {
-	bundleLock, err := NewBundleLockFromPath(path)
-	if err == nil {
-		return &bundleLock, nil, nil
+// ReadOpts configures how lock files provided as input are read
+type ReadOpts struct {
+	// AllowTags accepts tag-only refs (e.g. nginx:1.19) in addition to
+	// digest refs; callers are expected to resolve them before use
+	AllowTags bool
+}
+
+// NewLockFromPath reads a lock file of any known kind;
+// only the return value matching its kind is set
+func NewLockFromPath(path string, opts ReadOpts) (*BundleLock, *ImagesLock, *BundlesLock, error) {
+	bs, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("Reading path %s: %s", path, err)
+	}
+
+	var version LockVersion
+
+	err = yaml.Unmarshal(bs, &version)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("Trying to read lock file: %s", err)
+	}
+
+	switch version.Kind {
+	case BundleLockKind:
+		bundleLock, err := newBundleLockFromBytes(bs, opts.AllowTags)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		return &bundleLock, nil, nil, nil
+
+	case ImagesLockKind:
+		imagesLock, err := newImagesLockFromBytes(bs, opts.AllowTags)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		return nil, &imagesLock, nil, nil
+
+	case BundlesLockKind:
+		bundlesLock, err := newBundlesLockFromBytes(bs, opts.AllowTags)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		return nil, nil, &bundlesLock, nil
+
+	default:
+		return nil, nil, nil, fmt.Errorf("Trying to read lock file: Unknown kind '%s' (known: %s, %s, %s)",
+			version.Kind, BundleLockKind, ImagesLockKind, BundlesLockKind)
+	}
+}
+
+// validateDigestRefs checks that every ref is pinned to a digest,
+// reporting all of the refs that are not
+func validateDigestRefs(refs []string) error {
+	var tagRefs []string
+	for _, ref := range refs {
+		if _, err := regname.NewDigest(ref); err != nil {
+			tagRefs = append(tagRefs, fmt.Sprintf("'%s'", ref))
+		}
 	}
-	imagesLock, err := NewImagesLockFromPath(path)
-	if err == nil {
-		return nil, &imagesLock, nil
+
+	switch len(tagRefs) {
+	case 0:
+		return nil
+	case 1:
+		return fmt.Errorf("Expected ref to be in digest form, got %s", tagRefs[0])
+	default:
+		return fmt.Errorf("Expected refs to be in digest form, got %s", strings.Join(tagRefs, ", "))
+	}
+}
+
+func validateRefs(refs []string) error {
+	for _, ref := range refs {
+		if _, err := regname.ParseReference(ref); err != nil {
+			return fmt.Errorf("Expected ref to be a valid image reference, got '%s': %s", ref, err)
+		}
 	}
-	return nil, nil, fmt.Errorf("Trying to read bundle or images lock file: %s", err)
+	return nil
 }
@@ -7,7 +7,6 @@ import (
 	"fmt"
 	"io/ioutil"
 
-	regname "github.com/google/go-containerregistry/pkg/name"
 	"sigs.k8s.io/yaml"
 )
 
@@ -22,7 +21,11 @@ type ImagesLock struct {
 }
 
 type ImageRef struct {
-	Image       string            `json:"image,omitempty"`       // This generated yaml, but due to lib we need to use `json`
+	Image string `json:"image,omitempty"` // This generated yaml, but due to lib we need to use `json`
+	// Name and Tag are hints about the image origin (e.g. nginx and 1.19)
+	// that are carried along for tools reading the lock file
+	Name        string            `json:"name,omitempty"`        // This generated yaml, but due to lib we need to use `json`
+	Tag         string            `json:"tag,omitempty"`         // This generated yaml, but due to lib we need to use `json`
 	Annotations map[string]string `json:"annotations,omitempty"` // This generated yaml, but due to lib we need to use `json`
 	locations   []string
 }
@@ -37,6 +40,16 @@ func NewImagesLockFromPath(path string) (ImagesLock, error) {
 }
 
 func NewImagesLockFromBytes(data []byte) (ImagesLock, error) {
+	return newImagesLockFromBytes(data, false)
+}
+
+// NewImagesLockFromBytesWithOpts reads an images lock the same way
+// as NewImagesLockFromBytes, but refs are validated according to opts
+func NewImagesLockFromBytesWithOpts(data []byte, opts ReadOpts) (ImagesLock, error) {
+	return newImagesLockFromBytes(data, opts.AllowTags)
+}
+
+func newImagesLockFromBytes(data []byte, allowTags bool) (ImagesLock, error) {
 	var lock ImagesLock
 
 	err := yaml.UnmarshalStrict(data, &lock)
@@ -44,7 +57,7 @@ func NewImagesLockFromBytes(data []byte) (ImagesLock, error) {
 		return lock, fmt.Errorf("Unmarshaling images lock: %s", err)
 	}
 
-	err = lock.Validate()
+	err = lock.validate(allowTags)
 	if err != nil {
 		return lock, fmt.Errorf("Validating images lock: %s", err)
 	}
@@ -62,18 +75,24 @@ func (i *ImagesLock) AddImageRef(ref ImageRef) {
 }
 
 func (i ImagesLock) Validate() error {
+	return i.validate(false)
+}
+
+func (i ImagesLock) validate(allowTags bool) error {
 	if i.APIVersion != ImagesLockAPIVersion {
 		return fmt.Errorf("Validating apiVersion: Unknown version (known: %s)", ImagesLockAPIVersion)
 	}
 	if i.Kind != ImagesLockKind {
 		return fmt.Errorf("Validating kind: Unknown kind (known: %s)", ImagesLockKind)
 	}
+	var refs []string
 	for _, imageRef := range i.Images {
-		if _, err := regname.NewDigest(imageRef.Image); err != nil {
-			return fmt.Errorf("Expected ref to be in digest form, got '%s'", imageRef.Image)
-		}
+		refs = append(refs, imageRef.Image)
 	}
-	return nil
+	if allowTags {
+		return validateRefs(refs)
+	}
+	return validateDigestRefs(refs)
 }
 
 func (i ImagesLock) AsBytes() ([]byte, error) {
@@ -91,6 +110,8 @@ func (i ImagesLock) AsBytes() ([]byte, error) {
 	updatedImagesLock := i
 	updatedImagesLock.Images = imgRefs
 
+	// Keys (including annotations) are sorted during marshaling
+	// making output stable across writes of the same lock
 	bs, err := yaml.Marshal(updatedImagesLock)
 	if err != nil {
 		return nil, fmt.Errorf("Marshaling config: %s", err)
@@ -121,6 +142,8 @@ func (i ImageRef) DeepCopy() ImageRef {
 
 	return ImageRef{
 		Image:       i.Image,
+		Name:        i.Name,
+		Tag:         i.Tag,
 		locations:   append([]string{}, i.locations...),
 		Annotations: annotations,
 	}
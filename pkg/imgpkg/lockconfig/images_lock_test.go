@@ -4,6 +4,8 @@
 package lockconfig_test
 
 import (
+	"io/ioutil"
+	"path/filepath"
 	"testing"
 
 	"github.com/k14s/imgpkg/pkg/imgpkg/lockconfig"
@@ -24,6 +26,20 @@ images:
 		require.EqualError(t, err, "Validating images lock: Expected ref to be in digest form, got 'nginx:v1'")
 	})
 
+	t.Run("When several image references are not resolved, it lists all of them", func(t *testing.T) {
+		data := `
+apiVersion: imgpkg.carvel.dev/v1alpha1
+kind: ImagesLock
+images:
+- image: nginx:v1
+- image: some.image.io/plain@sha256:45f3926bca9fc42adb650fef2a41250d77841dde49afc8adc7c0c633b3d5f27a
+- image: redis
+`
+
+		_, err := lockconfig.NewImagesLockFromBytes([]byte(data))
+		require.EqualError(t, err, "Validating images lock: Expected refs to be in digest form, got 'nginx:v1', 'redis'")
+	})
+
 	t.Run("when yaml contain keys that are unknown, it errors", func(t *testing.T) {
 		data := `
 apiVersion: imgpkg.carvel.dev/v1alpha1
@@ -37,6 +53,116 @@ another-key: invalid
 	})
 }
 
+func TestNewLockFromPathWithTags(t *testing.T) {
+	data := `
+apiVersion: imgpkg.carvel.dev/v1alpha1
+kind: ImagesLock
+images:
+- image: nginx:v1
+- image: some.image.io/plain@sha256:45f3926bca9fc42adb650fef2a41250d77841dde49afc8adc7c0c633b3d5f27a
+`
+	lockPath := filepath.Join(t.TempDir(), "images.yml")
+	require.NoError(t, ioutil.WriteFile(lockPath, []byte(data), 0600))
+
+	t.Run("by default it rejects tag-only refs", func(t *testing.T) {
+		_, _, _, err := lockconfig.NewLockFromPath(lockPath, lockconfig.ReadOpts{})
+		require.EqualError(t, err, "Validating images lock: Expected ref to be in digest form, got 'nginx:v1'")
+	})
+
+	t.Run("when tags are allowed it accepts tag-only refs", func(t *testing.T) {
+		_, imagesLock, _, err := lockconfig.NewLockFromPath(lockPath, lockconfig.ReadOpts{AllowTags: true})
+		require.NoError(t, err)
+		require.NotNil(t, imagesLock)
+		require.Len(t, imagesLock.Images, 2)
+		assert.Equal(t, "nginx:v1", imagesLock.Images[0].Image)
+
+		t.Run("but it does not write them", func(t *testing.T) {
+			_, err := imagesLock.AsBytes()
+			require.EqualError(t, err, "Validating images lock: Expected ref to be in digest form, got 'nginx:v1'")
+		})
+	})
+
+	t.Run("when tags are allowed it still rejects invalid refs", func(t *testing.T) {
+		invalidLockPath := filepath.Join(t.TempDir(), "images.yml")
+		require.NoError(t, ioutil.WriteFile(invalidLockPath, []byte(`
+apiVersion: imgpkg.carvel.dev/v1alpha1
+kind: ImagesLock
+images:
+- image: "not a ref"
+`), 0600))
+
+		_, _, _, err := lockconfig.NewLockFromPath(invalidLockPath, lockconfig.ReadOpts{AllowTags: true})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "Expected ref to be a valid image reference, got 'not a ref'")
+	})
+}
+
+func TestImagesLockRoundTrip(t *testing.T) {
+	data := `
+apiVersion: imgpkg.carvel.dev/v1alpha1
+kind: ImagesLock
+images:
+- image: some.image.io/nginx@sha256:4c8b96d4fffdfae29258d94a22ae4ad1fe36139d47288b8960d9958d1e63a9d0
+  name: nginx
+  tag: "1.19"
+  annotations:
+    zz.example.com/last: value
+    aa.example.com/first: value
+    mm.example.com/middle: "true"
+- image: some.image.io/plain@sha256:45f3926bca9fc42adb650fef2a41250d77841dde49afc8adc7c0c633b3d5f27a
+`
+	expectedOutput := `---
+apiVersion: imgpkg.carvel.dev/v1alpha1
+images:
+- annotations:
+    aa.example.com/first: value
+    mm.example.com/middle: "true"
+    zz.example.com/last: value
+  image: some.image.io/nginx@sha256:4c8b96d4fffdfae29258d94a22ae4ad1fe36139d47288b8960d9958d1e63a9d0
+  name: nginx
+  tag: "1.19"
+- image: some.image.io/plain@sha256:45f3926bca9fc42adb650fef2a41250d77841dde49afc8adc7c0c633b3d5f27a
+kind: ImagesLock
+`
+
+	subject, err := lockconfig.NewImagesLockFromBytes([]byte(data))
+	require.NoError(t, err)
+
+	require.Len(t, subject.Images, 2)
+	assert.Equal(t, "nginx", subject.Images[0].Name)
+	assert.Equal(t, "1.19", subject.Images[0].Tag)
+	assert.Equal(t, map[string]string{
+		"aa.example.com/first":  "value",
+		"mm.example.com/middle": "true",
+		"zz.example.com/last":   "value",
+	}, subject.Images[0].Annotations)
+
+	outputPath := filepath.Join(t.TempDir(), "images.yml")
+	require.NoError(t, subject.WriteToPath(outputPath))
+
+	output, err := ioutil.ReadFile(outputPath)
+	require.NoError(t, err)
+	assert.Equal(t, expectedOutput, string(output))
+
+	t.Run("writing a parsed lock again produces the same bytes", func(t *testing.T) {
+		reparsed, err := lockconfig.NewImagesLockFromPath(outputPath)
+		require.NoError(t, err)
+
+		for i := 0; i < 5; i++ {
+			bs, err := reparsed.AsBytes()
+			require.NoError(t, err)
+			assert.Equal(t, expectedOutput, string(bs))
+		}
+	})
+
+	t.Run("copies of image refs keep name, tag and annotations", func(t *testing.T) {
+		imgRef := subject.Images[0].DeepCopy()
+		assert.Equal(t, subject.Images[0].Name, imgRef.Name)
+		assert.Equal(t, subject.Images[0].Tag, imgRef.Tag)
+		assert.Equal(t, subject.Images[0].Annotations, imgRef.Annotations)
+	})
+}
+
 func TestAddImageRef(t *testing.T) {
 	data := `
 apiVersion: imgpkg.carvel.dev/v1alpha1
@@ -4,7 +4,9 @@
 package plainimage
 
 import (
+	"context"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"strings"
@@ -18,32 +20,40 @@ import (
 type Contents struct {
 	paths         []string
 	excludedPaths []string
+	tarStream     io.Reader
+	compression   ctlimg.Compression
+	limits        ContentsLimits
 }
 
 type ImagesWriter interface {
-	WriteImage(regname.Reference, regv1.Image) error
+	WriteImage(context.Context, regname.Reference, regv1.Image) error
 }
 
-func NewContents(paths []string, excludedPaths []string) Contents {
-	return Contents{paths: paths, excludedPaths: excludedPaths}
+func NewContents(paths []string, excludedPaths []string, compression ctlimg.Compression) Contents {
+	return Contents{paths: paths, excludedPaths: excludedPaths, compression: compression}
 }
 
-func (i Contents) Push(uploadRef regname.Tag, labels map[string]string, writer ImagesWriter, ui ui.UI) (string, error) {
-	err := i.validate()
-	if err != nil {
-		return "", err
-	}
+// NewContentsFromTar uses the tar read from tarStream as is instead of files
+func NewContentsFromTar(tarStream io.Reader, compression ctlimg.Compression) Contents {
+	return Contents{tarStream: tarStream, compression: compression}
+}
 
-	tarImg := ctlimg.NewTarImage(i.paths, i.excludedPaths, InfoLog{ui})
+// WithLimits aborts the push before the tar is built when
+// the files found in paths exceed any of the limits
+func (i Contents) WithLimits(limits ContentsLimits) Contents {
+	i.limits = limits
+	return i
+}
 
-	img, err := tarImg.AsFileImage(labels)
+func (i Contents) Push(ctx context.Context, uploadRef regname.Tag, labels map[string]string, writer ImagesWriter, ui ui.UI) (string, error) {
+	img, err := i.fileImage(labels, ui)
 	if err != nil {
 		return "", err
 	}
 
 	defer img.Remove()
 
-	err = writer.WriteImage(uploadRef, img)
+	err = writer.WriteImage(ctx, uploadRef, img)
 	if err != nil {
 		return "", fmt.Errorf("Writing '%s': %s", uploadRef.Name(), err)
 	}
@@ -56,8 +66,26 @@ func (i Contents) Push(uploadRef regname.Tag, labels map[string]string, writer I
 	return fmt.Sprintf("%s@%s", uploadRef.Context(), digest), nil
 }
 
+func (i Contents) fileImage(labels map[string]string, ui ui.UI) (*ctlimg.FileImage, error) {
+	if i.tarStream != nil {
+		return ctlimg.NewPrebuiltTarImage(i.tarStream, i.compression, InfoLog{ui}).AsFileImage(labels)
+	}
+
+	err := i.validate()
+	if err != nil {
+		return nil, err
+	}
+
+	return ctlimg.NewTarImage(i.paths, i.excludedPaths, i.compression, InfoLog{ui}).AsFileImage(labels)
+}
+
 func (i Contents) validate() error {
-	return i.checkRepeatedPaths()
+	err := i.checkRepeatedPaths()
+	if err != nil {
+		return err
+	}
+
+	return i.checkLimits()
 }
 
 func (i Contents) checkRepeatedPaths() error {
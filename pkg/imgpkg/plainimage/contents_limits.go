@@ -0,0 +1,177 @@
+// Copyright 2021 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package plainimage
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// maxReportedPaths is how many of the largest paths are named when a limit is exceeded
+const maxReportedPaths = 5
+
+// ContentsLimits caps the files gathered from paths before they are
+// added to the image, a zero value means that there is no limit
+type ContentsLimits struct {
+	// MaxSize is the total size in bytes of the files
+	MaxSize int64
+	// MaxFiles is the number of files and symlinks
+	MaxFiles int
+}
+
+// IsSet returns true when any limit is configured
+func (l ContentsLimits) IsSet() bool {
+	return l.MaxSize > 0 || l.MaxFiles > 0
+}
+
+// pathUsage is the size and number of files found under a top level path
+type pathUsage struct {
+	path  string
+	size  int64
+	files int
+}
+
+func (i Contents) checkLimits() error {
+	if !i.limits.IsSet() {
+		return nil
+	}
+
+	usages, err := i.topLevelPathsUsage()
+	if err != nil {
+		return err
+	}
+
+	var total pathUsage
+	for _, usage := range usages {
+		total.size += usage.size
+		total.files += usage.files
+	}
+
+	if i.limits.MaxSize > 0 && total.size > i.limits.MaxSize {
+		sort.SliceStable(usages, func(a, b int) bool { return usages[a].size > usages[b].size })
+
+		var lines []string
+		for _, usage := range largestUsages(usages) {
+			lines = append(lines, fmt.Sprintf("- %s (%s)", usage.path, formatSize(usage.size)))
+		}
+		return fmt.Errorf("Expected files to total at most %s, but they total %s. Largest paths:\n%s",
+			formatSize(i.limits.MaxSize), formatSize(total.size), strings.Join(lines, "\n"))
+	}
+
+	if i.limits.MaxFiles > 0 && total.files > i.limits.MaxFiles {
+		sort.SliceStable(usages, func(a, b int) bool { return usages[a].files > usages[b].files })
+
+		var lines []string
+		for _, usage := range largestUsages(usages) {
+			unit := "files"
+			if usage.files == 1 {
+				unit = "file"
+			}
+			lines = append(lines, fmt.Sprintf("- %s (%d %s)", usage.path, usage.files, unit))
+		}
+		return fmt.Errorf("Expected at most %d files, but found %d. Paths with the most files:\n%s",
+			i.limits.MaxFiles, total.files, strings.Join(lines, "\n"))
+	}
+
+	return nil
+}
+
+// topLevelPathsUsage walks paths the same way the tar is built (skipping excluded paths)
+// and accounts every file to the provided path or to its top level entry for directories,
+// so that a large directory (e.g. node_modules) is reported instead of its many files
+func (i Contents) topLevelPathsUsage() ([]pathUsage, error) {
+	var usages []pathUsage
+	usagesByPath := map[string]int{}
+
+	addFile := func(topLevelPath string, info os.FileInfo) {
+		idx, found := usagesByPath[topLevelPath]
+		if !found {
+			idx = len(usages)
+			usagesByPath[topLevelPath] = idx
+			usages = append(usages, pathUsage{path: topLevelPath})
+		}
+		if info.Mode().IsRegular() {
+			usages[idx].size += info.Size()
+		}
+		usages[idx].files++
+	}
+
+	for _, flagPath := range i.paths {
+		info, err := os.Stat(flagPath)
+		if err != nil {
+			return nil, err
+		}
+
+		if !info.IsDir() {
+			if !i.isExcluded(filepath.Base(flagPath)) {
+				addFile(flagPath, info)
+			}
+			continue
+		}
+
+		err = filepath.Walk(flagPath, func(currPath string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+
+			relPath, err := filepath.Rel(flagPath, currPath)
+			if err != nil {
+				return err
+			}
+
+			if i.isExcluded(relPath) {
+				if info.IsDir() {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if info.IsDir() {
+				return nil
+			}
+
+			topLevelPath := filepath.Join(flagPath, strings.SplitN(relPath, string(filepath.Separator), 2)[0])
+			addFile(topLevelPath, info)
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return usages, nil
+}
+
+func (i Contents) isExcluded(relPath string) bool {
+	for _, path := range i.excludedPaths {
+		if path == relPath {
+			return true
+		}
+	}
+	return false
+}
+
+func largestUsages(usages []pathUsage) []pathUsage {
+	if len(usages) > maxReportedPaths {
+		return usages[:maxReportedPaths]
+	}
+	return usages
+}
+
+func formatSize(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+
+	div, exp := int64(unit), 0
+	for n := size / unit; n >= unit && exp < 3; n /= unit {
+		div *= unit
+		exp++
+	}
+
+	return fmt.Sprintf("%.1f %ciB", float64(size)/float64(div), "KMGT"[exp])
+}
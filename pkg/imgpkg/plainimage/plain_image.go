@@ -4,6 +4,7 @@
 package plainimage
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/cppforlife/go-cli-ui/ui"
@@ -80,7 +81,7 @@ func (i *PlainImage) Tag() string {
 	return "" // was a digest ref, so no tag
 }
 
-func (i *PlainImage) Fetch() (regv1.Image, error) {
+func (i *PlainImage) Fetch(ctx context.Context) (regv1.Image, error) {
 	var err error
 	if i.fetchedImage != nil {
 		return i.fetchedImage, nil
@@ -97,7 +98,7 @@ func (i *PlainImage) Fetch() (regv1.Image, error) {
 		return nil, err
 	}
 
-	imgs, err := ctlimg.NewImages(i.parsedRef, i.registry).Images()
+	imgs, err := ctlimg.NewImages(i.parsedRef, i.registry).Images(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("Collecting images: %s", err)
 	}
@@ -118,8 +119,8 @@ func (i *PlainImage) Fetch() (regv1.Image, error) {
 	return i.fetchedImage, nil
 }
 
-func (i *PlainImage) Pull(outputPath string, ui ui.UI) error {
-	img, err := i.Fetch()
+func (i *PlainImage) Pull(ctx context.Context, outputPath string, ui ui.UI) error {
+	img, err := i.Fetch(ctx)
 	if err != nil {
 		return err
 	}
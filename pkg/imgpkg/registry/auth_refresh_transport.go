@@ -0,0 +1,217 @@
+// Copyright 2021 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package registry
+
+import (
+	"net/http"
+	"strings"
+	"sync"
+
+	regauthn "github.com/google/go-containerregistry/pkg/authn"
+	regname "github.com/google/go-containerregistry/pkg/name"
+	regtran "github.com/google/go-containerregistry/pkg/v1/remote/transport"
+)
+
+// authRefreshTransport re-authenticates with the registry when a request carrying
+// a bearer token is rejected with 401 Unauthorized, which happens when the token
+// expires during long operations (e.g. GCR tokens are valid for about an hour).
+//
+// ggcr's bearer transport already exchanges a new token when the rejection carries
+// a challenge, but it does so with the credentials resolved when the operation started.
+// Those are often as short-lived as the token (e.g. access tokens issued by credential
+// helpers such as gcloud's), so that exchange is rejected as well. Instead, this transport
+// resolves the keychain again before exchanging a new token, and it retries the rejected
+// request with a rewound body (ggcr sends the already consumed body again). Requests whose
+// body cannot be sent again are not retried, but following requests use the new token.
+type authRefreshTransport struct {
+	transport http.RoundTripper
+	keychain  regauthn.Keychain
+
+	lock      sync.Mutex
+	refreshed map[string]refreshedAuth
+	refreshes int
+}
+
+// refreshedAuth replaces the rejected credentials sent by the caller
+type refreshedAuth struct {
+	staleAuthHeader string
+	transport       http.RoundTripper
+}
+
+var _ http.RoundTripper = &authRefreshTransport{}
+
+func newAuthRefreshTransport(transport http.RoundTripper, keychain regauthn.Keychain) *authRefreshTransport {
+	return &authRefreshTransport{transport: transport, keychain: keychain, refreshed: map[string]refreshedAuth{}}
+}
+
+func (t *authRefreshTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	authHeader := req.Header.Get("Authorization")
+
+	scopes, ok := requestScopes(req)
+	if !ok || !strings.HasPrefix(authHeader, "Bearer ") {
+		return t.transport.RoundTrip(req)
+	}
+
+	key := req.URL.Host + " " + strings.Join(scopes, " ")
+
+	// Once refreshed, the token still held by the caller is known to be stale,
+	// so requests are sent using the refreshed one instead
+	tran := t.transport
+	if refreshed, found := t.refreshedAuth(key); found && refreshed.staleAuthHeader == authHeader {
+		tran = refreshed.transport
+		req = req.Clone(req.Context())
+	}
+
+	resp, err := tran.RoundTrip(req)
+	if err != nil || resp.StatusCode != http.StatusUnauthorized {
+		return resp, err
+	}
+
+	newTran, err := t.refresh(req, authHeader, key, scopes, tran)
+	if err != nil {
+		// Keep the original response so that the rejection is reported
+		return resp, nil
+	}
+
+	retryReq, ok := rewoundRequest(req)
+	if !ok {
+		return resp, nil
+	}
+
+	resp.Body.Close()
+
+	return newTran.RoundTrip(retryReq)
+}
+
+// Refreshes returns how many times authentication was refreshed so far
+func (t *authRefreshTransport) Refreshes() int {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	return t.refreshes
+}
+
+func (t *authRefreshTransport) refreshedAuth(key string) (refreshedAuth, bool) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	refreshed, found := t.refreshed[key]
+	return refreshed, found
+}
+
+// refresh authenticates again unless another request already did it
+// since staleTran was used, in which case the newer transport is returned
+func (t *authRefreshTransport) refresh(req *http.Request, staleAuthHeader, key string, scopes []string, staleTran http.RoundTripper) (http.RoundTripper, error) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if current, found := t.refreshed[key]; found && current.transport != staleTran && current.staleAuthHeader == staleAuthHeader {
+		return current.transport, nil
+	}
+
+	regOpts := []regname.Option{regname.WeakValidation}
+	if req.URL.Scheme == "http" {
+		regOpts = append(regOpts, regname.Insecure)
+	}
+
+	registry, err := regname.NewRegistry(req.URL.Host, regOpts...)
+	if err != nil {
+		return nil, err
+	}
+
+	auth, err := t.keychain.Resolve(registry)
+	if err != nil {
+		return nil, err
+	}
+
+	// Tokens obtained by newTran expire as well, and their refresh needs fresh credentials too
+	newTran, err := regtran.NewWithContext(req.Context(), registry, &keychainAuthenticator{keychain: t.keychain, resource: registry, initial: auth}, t.transport, scopes)
+	if err != nil {
+		return nil, err
+	}
+
+	t.refreshed[key] = refreshedAuth{staleAuthHeader: staleAuthHeader, transport: newTran}
+	t.refreshes++
+
+	return newTran, nil
+}
+
+// keychainAuthenticator resolves the keychain every time it is asked for credentials,
+// which bearer transports only do when exchanging a token, starting with initial
+type keychainAuthenticator struct {
+	keychain regauthn.Keychain
+	resource regauthn.Resource
+
+	lock    sync.Mutex
+	initial regauthn.Authenticator
+}
+
+var _ regauthn.Authenticator = &keychainAuthenticator{}
+
+func (a *keychainAuthenticator) Authorization() (*regauthn.AuthConfig, error) {
+	a.lock.Lock()
+	initial := a.initial
+	a.initial = nil
+	a.lock.Unlock()
+
+	if initial != nil {
+		return initial.Authorization()
+	}
+
+	auth, err := a.keychain.Resolve(a.resource)
+	if err != nil {
+		return nil, err
+	}
+	return auth.Authorization()
+}
+
+// requestScopes returns the scopes needed by a request to the
+// registry API, it returns false for any other request (e.g. token exchanges)
+func requestScopes(req *http.Request) ([]string, bool) {
+	path := strings.TrimPrefix(req.URL.Path, "/v2/")
+	if path == req.URL.Path {
+		return nil, false
+	}
+
+	var repo string
+	for _, kind := range []string{"/blobs/", "/manifests/", "/tags/"} {
+		if idx := strings.LastIndex(path, kind); idx > 0 {
+			repo = path[:idx]
+			break
+		}
+	}
+	if repo == "" {
+		return nil, false
+	}
+
+	action := regtran.PushScope
+	if req.Method == http.MethodGet || req.Method == http.MethodHead {
+		action = regtran.PullScope
+	}
+
+	scopes := []string{"repository:" + repo + ":" + action}
+	if from := req.URL.Query().Get("from"); from != "" {
+		scopes = append(scopes, "repository:"+from+":"+regtran.PullScope)
+	}
+
+	return scopes, true
+}
+
+// rewoundRequest returns a copy of req that can be sent again,
+// which is only possible when its body (if any) can be read again
+func rewoundRequest(req *http.Request) (*http.Request, bool) {
+	retryReq := req.Clone(req.Context())
+	if req.Body == nil || req.Body == http.NoBody {
+		return retryReq, true
+	}
+	if req.GetBody == nil {
+		return nil, false
+	}
+
+	body, err := req.GetBody()
+	if err != nil {
+		return nil, false
+	}
+	retryReq.Body = body
+
+	return retryReq, true
+}
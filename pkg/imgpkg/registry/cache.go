@@ -0,0 +1,69 @@
+// Copyright 2021 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package registry
+
+import (
+	"container/list"
+	"sync"
+)
+
+// lruCache keeps up to size entries evicting the least recently used one.
+// A nil *lruCache never caches anything.
+type lruCache struct {
+	size    int
+	entries map[string]*list.Element
+	order   *list.List
+	lock    sync.Mutex
+}
+
+type lruCacheEntry struct {
+	key   string
+	value interface{}
+}
+
+func newLRUCache(size int) *lruCache {
+	if size <= 0 {
+		return nil
+	}
+	return &lruCache{size: size, entries: map[string]*list.Element{}, order: list.New()}
+}
+
+func (c *lruCache) Get(key string) (interface{}, bool) {
+	if c == nil {
+		return nil, false
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	elem, found := c.entries[key]
+	if !found {
+		return nil, false
+	}
+	c.order.MoveToFront(elem)
+	return elem.Value.(*lruCacheEntry).value, true
+}
+
+func (c *lruCache) Add(key string, value interface{}) {
+	if c == nil {
+		return
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if elem, found := c.entries[key]; found {
+		elem.Value.(*lruCacheEntry).value = value
+		c.order.MoveToFront(elem)
+		return
+	}
+
+	c.entries[key] = c.order.PushFront(&lruCacheEntry{key, value})
+
+	if c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*lruCacheEntry).key)
+	}
+}
@@ -0,0 +1,132 @@
+// Copyright 2021 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package registry
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+
+	regv1 "github.com/google/go-containerregistry/pkg/v1"
+)
+
+// cachedResponse keeps the bytes of a manifest or config blob
+type cachedResponse struct {
+	header http.Header
+	body   []byte
+}
+
+// cacheTransport caches the manifests fetched by digest and the config blobs
+// they reference. Only raw bytes are cached (keyed by the repository and digest),
+// so that every image or index is still built with the options (e.g. context)
+// of the call that requests it.
+type cacheTransport struct {
+	transport http.RoundTripper
+	cache     *lruCache
+
+	configsLock sync.Mutex
+	configs     map[string]struct{}
+}
+
+var _ http.RoundTripper = &cacheTransport{}
+
+func newCacheTransport(transport http.RoundTripper, cache *lruCache) *cacheTransport {
+	return &cacheTransport{transport: transport, cache: cache, configs: map[string]struct{}{}}
+}
+
+func (t *cacheTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	key, isManifest, cacheable := t.cacheKey(req)
+	if !cacheable {
+		return t.transport.RoundTrip(req)
+	}
+
+	if cached, found := t.cache.Get(key); found {
+		return cached.(cachedResponse).response(req), nil
+	}
+
+	resp, err := t.transport.RoundTrip(req)
+	if err != nil || resp.StatusCode != http.StatusOK {
+		return resp, err
+	}
+
+	body, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	cached := cachedResponse{header: resp.Header.Clone(), body: body}
+	t.cache.Add(key, cached)
+
+	if isManifest {
+		t.addConfig(key, body)
+	}
+
+	return cached.response(req), nil
+}
+
+// cacheKey returns the key used to cache the response to req, only GET requests
+// for manifests referenced by digest and for their config blobs are cacheable
+// since their content is immutable. Redirected requests (e.g. blobs served from
+// a storage bucket) are cached under the URL that was originally requested.
+func (t *cacheTransport) cacheKey(req *http.Request) (string, bool, bool) {
+	if t.cache == nil || req.Method != http.MethodGet || req.Header.Get("Range") != "" {
+		return "", false, false
+	}
+
+	origReq := req
+	for origReq.Response != nil && origReq.Response.Request != nil {
+		origReq = origReq.Response.Request
+	}
+
+	key := origReq.URL.Host + origReq.URL.Path
+
+	idx := strings.LastIndex(origReq.URL.Path, "/manifests/")
+	if idx >= 0 {
+		_, err := regv1.NewHash(origReq.URL.Path[idx+len("/manifests/"):])
+		return key, true, err == nil
+	}
+
+	t.configsLock.Lock()
+	defer t.configsLock.Unlock()
+
+	_, isConfig := t.configs[key]
+	return key, false, isConfig
+}
+
+// addConfig allows the config blob referenced by the manifest to be cached
+func (t *cacheTransport) addConfig(manifestKey string, manifest []byte) {
+	var partialManifest struct {
+		Config struct {
+			Digest string `json:"digest"`
+		} `json:"config"`
+	}
+	err := json.Unmarshal(manifest, &partialManifest)
+	if err != nil || partialManifest.Config.Digest == "" {
+		return
+	}
+
+	idx := strings.LastIndex(manifestKey, "/manifests/")
+
+	t.configsLock.Lock()
+	defer t.configsLock.Unlock()
+	t.configs[manifestKey[:idx]+"/blobs/"+partialManifest.Config.Digest] = struct{}{}
+}
+
+func (c cachedResponse) response(req *http.Request) *http.Response {
+	return &http.Response{
+		Status:        "200 OK",
+		StatusCode:    http.StatusOK,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        c.header.Clone(),
+		Body:          ioutil.NopCloser(bytes.NewReader(c.body)),
+		ContentLength: int64(len(c.body)),
+		Request:       req,
+	}
+}
@@ -0,0 +1,102 @@
+// Copyright 2021 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package registry
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+const redacted = "<redacted>"
+
+// Logger receives the debug information about every request sent to the registry
+type Logger interface {
+	Debugf(msg string, args ...interface{})
+	// DebugEnabled returns true when Debugf writes anything,
+	// otherwise requests are not formatted for the logger at all
+	DebugEnabled() bool
+}
+
+type attemptKey struct{}
+
+func withAttempt(ctx context.Context, attempt int) context.Context {
+	return context.WithValue(ctx, attemptKey{}, attempt)
+}
+
+// debugTransport logs requests and responses
+// making sure that credentials are never printed
+type debugTransport struct {
+	transport http.RoundTripper
+	logger    Logger
+}
+
+var _ http.RoundTripper = debugTransport{}
+
+func (t debugTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	attempt, ok := req.Context().Value(attemptKey{}).(int)
+	if !ok {
+		attempt = 1
+	}
+
+	reqURL := redactURL(req.URL)
+	t.logger.Debugf("--> %s %s (attempt %d)\n%s", req.Method, reqURL, attempt, redactHeaders(req.Header))
+
+	resp, err := t.transport.RoundTrip(req)
+	if err != nil {
+		t.logger.Debugf("<-- %s %s failed: %s\n", req.Method, reqURL, err)
+		return resp, err
+	}
+
+	t.logger.Debugf("<-- %s %s %s\n%s", req.Method, reqURL, resp.Status, redactHeaders(resp.Header))
+	return resp, nil
+}
+
+func redactURL(u *url.URL) string {
+	redactedURL := *u
+	if redactedURL.User != nil {
+		redactedURL.User = url.User(redacted)
+	}
+
+	query := redactedURL.Query()
+	for key := range query {
+		switch strings.ToLower(key) {
+		case "access_token", "refresh_token", "token", "password", "client_secret":
+			query.Set(key, redacted)
+		}
+	}
+	redactedURL.RawQuery = query.Encode()
+
+	return redactedURL.String()
+}
+
+func redactHeaders(headers http.Header) string {
+	var keys []string
+	for key := range headers {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var result strings.Builder
+	for _, key := range keys {
+		for _, val := range headers[key] {
+			switch http.CanonicalHeaderKey(key) {
+			case "Authorization", "Proxy-Authorization":
+				// Keep the scheme (e.g. Basic, Bearer) to help troubleshooting
+				if pieces := strings.SplitN(val, " ", 2); len(pieces) == 2 {
+					val = pieces[0] + " " + redacted
+				} else {
+					val = redacted
+				}
+			case "Cookie", "Set-Cookie":
+				val = redacted
+			}
+			result.WriteString(fmt.Sprintf("    %s: %s\n", key, val))
+		}
+	}
+	return result.String()
+}
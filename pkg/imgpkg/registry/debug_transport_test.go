@@ -0,0 +1,62 @@
+// Copyright 2021 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package registry_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"testing"
+
+	regname "github.com/google/go-containerregistry/pkg/name"
+	ctlimg "github.com/k14s/imgpkg/pkg/imgpkg/image"
+	"github.com/k14s/imgpkg/pkg/imgpkg/registry"
+	"github.com/k14s/imgpkg/test/helpers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegistryDebugLogging(t *testing.T) {
+	fakeRegistry := helpers.NewFakeRegistry(t)
+	defer fakeRegistry.CleanUp()
+	image := fakeRegistry.WithRandomImage("library/image")
+	fakeRegistry.WithBasicAuth("some-user", "some-password")
+	fakeRegistry.Build()
+
+	ref, err := regname.ParseReference(image.RefDigest)
+	require.NoError(t, err)
+
+	t.Run("when log level is debug, it logs every request redacting the credentials", func(t *testing.T) {
+		output := &bytes.Buffer{}
+		logger := ctlimg.NewLogger(output).WithLevel(ctlimg.LogLevelDebug).NewPrefixedWriter("registry | ")
+
+		reg, err := registry.NewRegistry(registry.Opts{Username: "some-user", Password: "some-password", Logger: logger})
+		require.NoError(t, err)
+
+		_, err = reg.Digest(context.Background(), ref)
+		require.NoError(t, err)
+
+		assert.Regexp(t, `registry \| --> GET http://`+fakeRegistry.Host()+`/v2/ \(attempt 1\)`, output.String())
+		assert.Regexp(t, `registry \| <-- GET http://`+fakeRegistry.Host()+`/v2/ 401 Unauthorized`, output.String())
+		assert.Regexp(t, `registry \| --> HEAD http://`+fakeRegistry.Host()+`/v2/library/image/manifests/`+image.Digest+` \(attempt 1\)`, output.String())
+		assert.Regexp(t, `registry \| <-- HEAD http://`+fakeRegistry.Host()+`/v2/library/image/manifests/`+image.Digest+` 200 OK`, output.String())
+		assert.Contains(t, output.String(), "Authorization: Basic <redacted>")
+
+		assert.NotContains(t, output.String(), "some-password")
+		assert.NotContains(t, output.String(), base64.StdEncoding.EncodeToString([]byte("some-user:some-password")))
+	})
+
+	t.Run("when log level is not debug, it does not log requests", func(t *testing.T) {
+		output := &bytes.Buffer{}
+		logger := ctlimg.NewLogger(output).NewPrefixedWriter("registry | ")
+
+		reg, err := registry.NewRegistry(registry.Opts{Username: "some-user", Password: "some-password", Logger: logger})
+		require.NoError(t, err)
+
+		_, err = reg.Digest(context.Background(), ref)
+		require.NoError(t, err)
+
+		assert.Empty(t, output.String())
+	})
+}
@@ -0,0 +1,75 @@
+// Copyright 2021 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package registry
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync"
+
+	regv1 "github.com/google/go-containerregistry/pkg/v1"
+)
+
+type existingBlobsKey struct{}
+
+// existingBlobs collects the blobs found while writing, using the existence
+// checks (HEAD requests) that ggcr sends before uploading each blob
+type existingBlobs struct {
+	lock    sync.Mutex
+	digests map[regv1.Hash]struct{}
+}
+
+func withExistingBlobs(ctx context.Context, blobs *existingBlobs) context.Context {
+	return context.WithValue(ctx, existingBlobsKey{}, blobs)
+}
+
+func (b *existingBlobs) add(digest regv1.Hash) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.digests[digest] = struct{}{}
+}
+
+func (b *existingBlobs) all() []regv1.Hash {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	var digests []regv1.Hash
+	for digest := range b.digests {
+		digests = append(digests, digest)
+	}
+	return digests
+}
+
+// existingBlobsTransport records the blobs found by existence checks
+// sent with a context that carries existingBlobs
+type existingBlobsTransport struct {
+	transport http.RoundTripper
+}
+
+var _ http.RoundTripper = existingBlobsTransport{}
+
+func (t existingBlobsTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.transport.RoundTrip(req)
+	if err != nil || req.Method != http.MethodHead || resp.StatusCode != http.StatusOK {
+		return resp, err
+	}
+
+	blobs, ok := req.Context().Value(existingBlobsKey{}).(*existingBlobs)
+	if !ok {
+		return resp, err
+	}
+
+	idx := strings.LastIndex(req.URL.Path, "/blobs/")
+	if idx < 0 {
+		return resp, err
+	}
+
+	digest, hashErr := regv1.NewHash(req.URL.Path[idx+len("/blobs/"):])
+	if hashErr == nil {
+		blobs.add(digest)
+	}
+
+	return resp, err
+}
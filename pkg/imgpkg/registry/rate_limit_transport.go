@@ -0,0 +1,106 @@
+// Copyright 2021 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package registry
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// defaultTooManyRequestsBackoff is used when the registry
+// does not specify when requests can be sent again
+const defaultTooManyRequestsBackoff = 1 * time.Second
+
+// rateLimitTransport delays requests so that every goroutine sharing it
+// sends at most the configured number of requests per second. Requests wait
+// for their turn instead of failing, and everyone backs off when the registry
+// responds with 429 Too Many Requests.
+type rateLimitTransport struct {
+	transport http.RoundTripper
+	limiter   *rateLimiter
+}
+
+var _ http.RoundTripper = rateLimitTransport{}
+
+func (t rateLimitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	err := t.limiter.Wait(req.Context())
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := t.transport.RoundTrip(req)
+	if err == nil && resp.StatusCode == http.StatusTooManyRequests {
+		t.limiter.Pause(retryAfter(resp.Header))
+	}
+	return resp, err
+}
+
+// retryAfter returns how long to wait based on the Retry-After header
+// which contains either a number of seconds or a date
+func retryAfter(header http.Header) time.Duration {
+	val := header.Get("Retry-After")
+	if seconds, err := strconv.Atoi(val); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if date, err := http.ParseTime(val); err == nil {
+		if wait := time.Until(date); wait > 0 {
+			return wait
+		}
+	}
+	return defaultTooManyRequestsBackoff
+}
+
+// rateLimiter is a token bucket holding a single token
+// which is refilled every interval
+type rateLimiter struct {
+	interval time.Duration
+
+	next     time.Time
+	nextLock sync.Mutex
+}
+
+func newRateLimiter(requestsPerSecond float64) *rateLimiter {
+	return &rateLimiter{interval: time.Duration(float64(time.Second) / requestsPerSecond)}
+}
+
+// Wait blocks until a request can be sent or ctx is done
+func (l *rateLimiter) Wait(ctx context.Context) error {
+	l.nextLock.Lock()
+	now := time.Now()
+	slot := l.next
+	if slot.Before(now) {
+		slot = now
+	}
+	l.next = slot.Add(l.interval)
+	l.nextLock.Unlock()
+
+	delay := slot.Sub(now)
+	if delay <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Pause prevents requests from being sent for the given duration
+func (l *rateLimiter) Pause(duration time.Duration) {
+	l.nextLock.Lock()
+	defer l.nextLock.Unlock()
+
+	until := time.Now().Add(duration)
+	if l.next.Before(until) {
+		l.next = until
+	}
+}
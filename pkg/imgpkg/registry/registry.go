@@ -4,8 +4,10 @@
 package registry
 
 import (
+	"context"
 	"crypto/tls"
 	"crypto/x509"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"net"
@@ -13,9 +15,11 @@ import (
 	"os"
 	"time"
 
+	regauthn "github.com/google/go-containerregistry/pkg/authn"
 	regname "github.com/google/go-containerregistry/pkg/name"
 	regv1 "github.com/google/go-containerregistry/pkg/v1"
 	regremote "github.com/google/go-containerregistry/pkg/v1/remote"
+	regtran "github.com/google/go-containerregistry/pkg/v1/remote/transport"
 	"github.com/k14s/imgpkg/pkg/imgpkg/util"
 )
 
@@ -30,11 +34,27 @@ type Opts struct {
 	Password string
 	Token    string
 	Anon     bool
+
+	// UserAgent is sent to the registry in addition to the go-containerregistry one
+	UserAgent string
+
+	// CacheSize is the number of manifests and configs kept in memory,
+	// only content referenced by digest is cached (0 disables caching)
+	CacheSize int
+
+	// RequestsPerSecond limits requests sent by every goroutine
+	// using the registry, requests wait for their turn (0 disables limiting)
+	RequestsPerSecond float64
+
+	// Logger when provided receives every request sent to the registry
+	Logger Logger
 }
 
 type Registry struct {
-	opts    []regremote.Option
-	refOpts []regname.Option
+	opts        []regremote.Option
+	refOpts     []regname.Option
+	keychain    regauthn.Keychain
+	authRefresh *authRefreshTransport
 }
 
 func NewRegistry(opts Opts) (Registry, error) {
@@ -48,34 +68,53 @@ func NewRegistry(opts Opts) (Registry, error) {
 		refOpts = append(refOpts, regname.Insecure)
 	}
 
+	keychain := Keychain(
+		KeychainOpts{
+			Username: opts.Username,
+			Password: opts.Password,
+			Token:    opts.Token,
+			Anon:     opts.Anon,
+		},
+		os.Environ,
+	)
+
+	var tran http.RoundTripper = existingBlobsTransport{transport: httpTran}
+	if opts.Logger != nil && opts.Logger.DebugEnabled() {
+		tran = debugTransport{transport: tran, logger: opts.Logger}
+	}
+	if opts.RequestsPerSecond > 0 {
+		tran = rateLimitTransport{transport: tran, limiter: newRateLimiter(opts.RequestsPerSecond)}
+	}
+
+	tran = newCacheTransport(tran, newLRUCache(opts.CacheSize))
+
+	authRefreshTran := newAuthRefreshTransport(tran, keychain)
+
 	regRemoteOptions := []regremote.Option{
-		regremote.WithTransport(httpTran),
-		regremote.WithAuthFromKeychain(Keychain(
-			KeychainOpts{
-				Username: opts.Username,
-				Password: opts.Password,
-				Token:    opts.Token,
-				Anon:     opts.Anon,
-			},
-			os.Environ),
-		),
+		regremote.WithTransport(authRefreshTran),
+		regremote.WithAuthFromKeychain(keychain),
+	}
+	if len(opts.UserAgent) > 0 {
+		regRemoteOptions = append(regRemoteOptions, regremote.WithUserAgent(opts.UserAgent))
 	}
 	if opts.IncludeNonDistributableLayers {
 		regRemoteOptions = append(regRemoteOptions, regremote.WithNondistributable)
 	}
 
 	return Registry{
-		opts:    regRemoteOptions,
-		refOpts: refOpts,
+		opts:        regRemoteOptions,
+		refOpts:     refOpts,
+		keychain:    keychain,
+		authRefresh: authRefreshTran,
 	}, nil
 }
 
-func (r Registry) Generic(ref regname.Reference) (regv1.Descriptor, error) {
+func (r Registry) Generic(ctx context.Context, ref regname.Reference) (regv1.Descriptor, error) {
 	overriddenRef, err := regname.ParseReference(ref.String(), r.refOpts...)
 	if err != nil {
 		return regv1.Descriptor{}, err
 	}
-	desc, err := regremote.Get(overriddenRef, r.opts...)
+	desc, err := r.Get(ctx, overriddenRef)
 	if err != nil {
 		return regv1.Descriptor{}, err
 	}
@@ -83,46 +122,71 @@ func (r Registry) Generic(ref regname.Reference) (regv1.Descriptor, error) {
 	return desc.Descriptor, nil
 }
 
-func (r Registry) Get(ref regname.Reference) (*regremote.Descriptor, error) {
-	return regremote.Get(ref, r.opts...)
+func (r Registry) Get(ctx context.Context, ref regname.Reference) (*regremote.Descriptor, error) {
+	desc, err := regremote.Get(ref, r.remoteOpts(ctx)...)
+	if err != nil {
+		return nil, r.authErr(ref.Context().Registry, err)
+	}
+
+	return desc, nil
 }
 
-func (r Registry) Digest(ref regname.Reference) (regv1.Hash, error) {
+func (r Registry) Digest(ctx context.Context, ref regname.Reference) (regv1.Hash, error) {
 	overriddenRef, err := regname.ParseReference(ref.String(), r.refOpts...)
 	if err != nil {
 		return regv1.Hash{}, err
 	}
-	desc, err := regremote.Head(overriddenRef, r.opts...)
+	desc, err := regremote.Head(overriddenRef, r.remoteOpts(ctx)...)
 	if err != nil {
-		return regv1.Hash{}, err
+		return regv1.Hash{}, r.authErr(overriddenRef.Context().Registry, err)
 	}
 
 	return desc.Digest, nil
 }
 
-func (r Registry) Image(ref regname.Reference) (regv1.Image, error) {
+func (r Registry) Image(ctx context.Context, ref regname.Reference) (regv1.Image, error) {
 	overriddenRef, err := regname.ParseReference(ref.String(), r.refOpts...)
 	if err != nil {
 		return nil, err
 	}
 
-	return regremote.Image(overriddenRef, r.opts...)
+	img, err := regremote.Image(overriddenRef, r.remoteOpts(ctx)...)
+	if err != nil {
+		return nil, r.authErr(overriddenRef.Context().Registry, err)
+	}
+
+	return img, nil
 }
 
-func (r Registry) MultiWrite(imageOrIndexesToUpload map[regname.Reference]regremote.Taggable, concurrency int) error {
-	return util.Retry(func() error {
-		return regremote.MultiWrite(imageOrIndexesToUpload, append(r.opts, regremote.WithJobs(concurrency))...)
+// MultiWrite writes the images and indexes, it returns the digests of the blobs
+// that were already present in the destination and thus were not uploaded again
+func (r Registry) MultiWrite(ctx context.Context, imageOrIndexesToUpload map[regname.Reference]regremote.Taggable, concurrency int) ([]regv1.Hash, error) {
+	var registry regname.Registry
+	for ref := range imageOrIndexesToUpload {
+		registry = ref.Context().Registry
+		break
+	}
+
+	blobs := &existingBlobs{digests: map[regv1.Hash]struct{}{}}
+
+	err := r.retry(withExistingBlobs(ctx, blobs), registry, func(opts []regremote.Option) error {
+		return regremote.MultiWrite(imageOrIndexesToUpload, append(opts, regremote.WithJobs(concurrency))...)
 	})
+	if err != nil {
+		return nil, err
+	}
+
+	return blobs.all(), nil
 }
 
-func (r Registry) WriteImage(ref regname.Reference, img regv1.Image) error {
+func (r Registry) WriteImage(ctx context.Context, ref regname.Reference, img regv1.Image) error {
 	overriddenRef, err := regname.ParseReference(ref.String(), r.refOpts...)
 	if err != nil {
 		return err
 	}
 
-	err = util.Retry(func() error {
-		return regremote.Write(overriddenRef, img, r.opts...)
+	err = r.retry(ctx, overriddenRef.Context().Registry, func(opts []regremote.Option) error {
+		return regremote.Write(overriddenRef, img, opts...)
 	})
 	if err != nil {
 		return fmt.Errorf("Writing image: %s", err)
@@ -131,22 +195,28 @@ func (r Registry) WriteImage(ref regname.Reference, img regv1.Image) error {
 	return nil
 }
 
-func (r Registry) Index(ref regname.Reference) (regv1.ImageIndex, error) {
+func (r Registry) Index(ctx context.Context, ref regname.Reference) (regv1.ImageIndex, error) {
 	overriddenRef, err := regname.ParseReference(ref.String(), r.refOpts...)
 	if err != nil {
 		return nil, err
 	}
-	return regremote.Index(overriddenRef, r.opts...)
+
+	idx, err := regremote.Index(overriddenRef, r.remoteOpts(ctx)...)
+	if err != nil {
+		return nil, r.authErr(overriddenRef.Context().Registry, err)
+	}
+
+	return idx, nil
 }
 
-func (r Registry) WriteIndex(ref regname.Reference, idx regv1.ImageIndex) error {
+func (r Registry) WriteIndex(ctx context.Context, ref regname.Reference, idx regv1.ImageIndex) error {
 	overriddenRef, err := regname.ParseReference(ref.String(), r.refOpts...)
 	if err != nil {
 		return err
 	}
 
-	err = util.Retry(func() error {
-		return regremote.WriteIndex(overriddenRef, idx, r.opts...)
+	err = r.retry(ctx, overriddenRef.Context().Registry, func(opts []regremote.Option) error {
+		return regremote.WriteIndex(overriddenRef, idx, opts...)
 	})
 	if err != nil {
 		return fmt.Errorf("Writing image index: %s", err)
@@ -155,14 +225,14 @@ func (r Registry) WriteIndex(ref regname.Reference, idx regv1.ImageIndex) error
 	return nil
 }
 
-func (r Registry) WriteTag(ref regname.Tag, taggagle regremote.Taggable) error {
+func (r Registry) WriteTag(ctx context.Context, ref regname.Tag, taggagle regremote.Taggable) error {
 	overriddenRef, err := regname.NewTag(ref.String(), r.refOpts...)
 	if err != nil {
 		return err
 	}
 
-	err = util.Retry(func() error {
-		return regremote.Tag(overriddenRef, taggagle, r.opts...)
+	err = r.retry(ctx, overriddenRef.Context().Registry, func(opts []regremote.Option) error {
+		return regremote.Tag(overriddenRef, taggagle, opts...)
 	})
 	if err != nil {
 		return fmt.Errorf("Tagging image: %s", err)
@@ -171,12 +241,99 @@ func (r Registry) WriteTag(ref regname.Tag, taggagle regremote.Taggable) error {
 	return nil
 }
 
-func (r Registry) ListTags(repo regname.Repository) ([]string, error) {
+func (r Registry) Delete(ctx context.Context, ref regname.Reference) error {
+	overriddenRef, err := regname.ParseReference(ref.String(), r.refOpts...)
+	if err != nil {
+		return err
+	}
+
+	err = r.retry(ctx, overriddenRef.Context().Registry, func(opts []regremote.Option) error {
+		return regremote.Delete(overriddenRef, opts...)
+	})
+	if err != nil {
+		return fmt.Errorf("Deleting image: %s", err)
+	}
+
+	return nil
+}
+
+func (r Registry) ListTags(ctx context.Context, repo regname.Repository) ([]string, error) {
 	overriddenRepo, err := regname.NewRepository(repo.Name(), r.refOpts...)
 	if err != nil {
 		return nil, err
 	}
-	return regremote.List(overriddenRepo, r.opts...)
+	tags, err := regremote.List(overriddenRepo, r.remoteOpts(ctx)...)
+	if err != nil {
+		return nil, r.authErr(overriddenRepo.Registry, err)
+	}
+	return tags, nil
+}
+
+// retry retries doFunc providing the current attempt number to the requests,
+// authentication failures are not retried since they would fail again, unless
+// authentication was refreshed during the attempt (e.g. an expired token was
+// rejected in the middle of an upload that could not be retried on its own)
+func (r Registry) retry(ctx context.Context, registry regname.Registry, doFunc func(opts []regremote.Option) error) error {
+	attempt := 0
+	return util.RetryWithContext(ctx, func() error {
+		attempt++
+		refreshes := r.authRefreshes()
+		opts := append([]regremote.Option{}, r.opts...)
+		err := doFunc(append(opts, regremote.WithContext(withAttempt(ctx, attempt))))
+		if authErr := r.authErr(registry, err); authErr != err {
+			if r.authRefreshes() > refreshes {
+				return authErr
+			}
+			return util.NonRetryableError{Message: authErr.Error()}
+		}
+		return err
+	})
+}
+
+// remoteOpts returns the options used by requests, sent with ctx
+func (r Registry) remoteOpts(ctx context.Context) []regremote.Option {
+	return append(append([]regremote.Option{}, r.opts...), regremote.WithContext(ctx))
+}
+
+func (r Registry) authRefreshes() int {
+	if r.authRefresh == nil {
+		return 0
+	}
+	return r.authRefresh.Refreshes()
+}
+
+// authErr explains which registry rejected the request and how to provide credentials
+// when err is caused by an unauthorized or forbidden response, otherwise err is returned as is
+func (r Registry) authErr(registry regname.Registry, err error) error {
+	var tranErr *regtran.Error
+	if !errors.As(err, &tranErr) {
+		return err
+	}
+
+	const credentialsHint = "--registry-username/--registry-password flags ($IMGPKG_USERNAME/$IMGPKG_PASSWORD) or IMGPKG_REGISTRY_HOSTNAME/IMGPKG_REGISTRY_USERNAME/IMGPKG_REGISTRY_PASSWORD env variables"
+
+	switch tranErr.StatusCode {
+	case http.StatusUnauthorized, http.StatusForbidden:
+		if r.isAnonymous(registry) {
+			return fmt.Errorf("Anonymous access to registry '%s' was denied: %s (hint: Provide credentials via %s)",
+				registry.RegistryStr(), err, credentialsHint)
+		}
+		if tranErr.StatusCode == http.StatusForbidden {
+			return fmt.Errorf("Access to registry '%s' was denied: %s (hint: Check that credentials provided via %s have access to the repository)",
+				registry.RegistryStr(), err, credentialsHint)
+		}
+		return fmt.Errorf("Authenticating to registry '%s' failed: %s (hint: Check credentials provided via %s)",
+			registry.RegistryStr(), err, credentialsHint)
+	default:
+		return err
+	}
+}
+
+// isAnonymous returns true when no credentials are found for registry,
+// either because --registry-anon was provided or the keychain fell back to anonymous access
+func (r Registry) isAnonymous(registry regname.Registry) bool {
+	auth, err := r.keychain.Resolve(registry)
+	return err == nil && auth == regauthn.Anonymous
 }
 
 func newHTTPTransport(opts Opts) (*http.Transport, error) {
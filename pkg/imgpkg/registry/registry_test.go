@@ -0,0 +1,486 @@
+// Copyright 2021 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package registry_test
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	regname "github.com/google/go-containerregistry/pkg/name"
+	regregistry "github.com/google/go-containerregistry/pkg/registry"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	regremote "github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/k14s/imgpkg/pkg/imgpkg/registry"
+	"github.com/k14s/imgpkg/test/helpers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegistryUserAgent(t *testing.T) {
+	var userAgents []string
+	var userAgentsLock sync.Mutex
+
+	registryHandler := regregistry.New(regregistry.Logger(log.New(io.Discard, "", 0)))
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		userAgentsLock.Lock()
+		userAgents = append(userAgents, request.Header.Get("User-Agent"))
+		userAgentsLock.Unlock()
+
+		registryHandler.ServeHTTP(writer, request)
+	}))
+	defer server.Close()
+
+	serverURL, err := url.Parse(server.URL)
+	require.NoError(t, err)
+
+	repo, err := regname.NewRepository(serverURL.Host + "/some/repo")
+	require.NoError(t, err)
+
+	reg, err := registry.NewRegistry(registry.Opts{UserAgent: "imgpkg/1.2.3 ci-job-42"})
+	require.NoError(t, err)
+
+	// Error is expected since the repository does not exist
+	_, _ = reg.ListTags(context.Background(), repo)
+
+	require.NotEmpty(t, userAgents)
+	for _, userAgent := range userAgents {
+		assert.True(t, strings.HasPrefix(userAgent, "imgpkg/1.2.3 ci-job-42"), "expected User-Agent '%s' to start with imgpkg/1.2.3 ci-job-42", userAgent)
+	}
+}
+
+func TestRegistryCache(t *testing.T) {
+	var manifestRequests []string
+	var requestsLock sync.Mutex
+
+	registryHandler := regregistry.New(regregistry.Logger(log.New(io.Discard, "", 0)))
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		if strings.Contains(request.URL.Path, "/manifests/") || strings.Contains(request.URL.Path, "/blobs/") {
+			requestsLock.Lock()
+			manifestRequests = append(manifestRequests, request.URL.Path)
+			requestsLock.Unlock()
+		}
+		registryHandler.ServeHTTP(writer, request)
+	}))
+	defer server.Close()
+
+	serverURL, err := url.Parse(server.URL)
+	require.NoError(t, err)
+
+	image1Tag, image1Digest := writeRandomImage(t, serverURL.Host+"/repo/image1")
+	_, image2Digest := writeRandomImage(t, serverURL.Host+"/repo/image2")
+
+	requestsCount := func(f func()) int {
+		requestsLock.Lock()
+		manifestRequests = nil
+		requestsLock.Unlock()
+
+		f()
+
+		requestsLock.Lock()
+		defer requestsLock.Unlock()
+		return len(manifestRequests)
+	}
+
+	fetchImageWithConfig := func(reg registry.Registry, ref regname.Reference) {
+		img, err := reg.Image(context.Background(), ref)
+		require.NoError(t, err)
+		_, err = img.ConfigFile()
+		require.NoError(t, err)
+	}
+
+	t.Run("fetching the same digest twice only hits the registry once", func(t *testing.T) {
+		reg, err := registry.NewRegistry(registry.Opts{CacheSize: 10})
+		require.NoError(t, err)
+
+		assert.NotZero(t, requestsCount(func() { fetchImageWithConfig(reg, image1Digest) }))
+		assert.Zero(t, requestsCount(func() { fetchImageWithConfig(reg, image1Digest) }))
+
+		assert.Zero(t, requestsCount(func() {
+			_, err := reg.Get(context.Background(), image1Digest)
+			require.NoError(t, err)
+		}))
+		assert.Zero(t, requestsCount(func() {
+			_, err := reg.Generic(context.Background(), image1Digest)
+			require.NoError(t, err)
+		}))
+	})
+
+	t.Run("cached images are fetched with the context of the caller", func(t *testing.T) {
+		reg, err := registry.NewRegistry(registry.Opts{CacheSize: 10})
+		require.NoError(t, err)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cachedImg, err := reg.Image(ctx, image1Digest)
+		require.NoError(t, err)
+		_, err = cachedImg.ConfigFile()
+		require.NoError(t, err)
+		cancel()
+
+		img, err := reg.Image(context.Background(), image1Digest)
+		require.NoError(t, err)
+		layers, err := img.Layers()
+		require.NoError(t, err)
+		require.NotEmpty(t, layers)
+		layerContents, err := layers[0].Compressed()
+		require.NoError(t, err)
+		require.NoError(t, layerContents.Close())
+
+		_, err = reg.Image(ctx, image1Digest)
+		require.Error(t, err)
+	})
+
+	t.Run("tag references are never cached", func(t *testing.T) {
+		reg, err := registry.NewRegistry(registry.Opts{CacheSize: 10})
+		require.NoError(t, err)
+
+		assert.NotZero(t, requestsCount(func() { fetchImageWithConfig(reg, image1Tag) }))
+		assert.NotZero(t, requestsCount(func() { fetchImageWithConfig(reg, image1Tag) }))
+	})
+
+	t.Run("least recently used entries are evicted when cache is full", func(t *testing.T) {
+		// Fits the manifest and config of a single image
+		reg, err := registry.NewRegistry(registry.Opts{CacheSize: 2})
+		require.NoError(t, err)
+
+		fetchImageWithConfig(reg, image1Digest)
+		fetchImageWithConfig(reg, image2Digest)
+
+		assert.Zero(t, requestsCount(func() { fetchImageWithConfig(reg, image2Digest) }))
+		assert.NotZero(t, requestsCount(func() { fetchImageWithConfig(reg, image1Digest) }))
+	})
+
+	t.Run("when cache size is not provided, nothing is cached", func(t *testing.T) {
+		reg, err := registry.NewRegistry(registry.Opts{})
+		require.NoError(t, err)
+
+		fetchImageWithConfig(reg, image1Digest)
+		assert.NotZero(t, requestsCount(func() { fetchImageWithConfig(reg, image1Digest) }))
+	})
+}
+
+func TestRegistryAuthErrors(t *testing.T) {
+	t.Run("when basic auth credentials are rejected, it names the registry and suggests how to provide credentials", func(t *testing.T) {
+		fakeRegistry := helpers.NewFakeRegistry(t)
+		defer fakeRegistry.CleanUp()
+		image := fakeRegistry.WithRandomImage("library/image")
+		fakeRegistry.WithBasicAuth("some-user", "some-password")
+		fakeRegistry.Build()
+
+		reg, err := registry.NewRegistry(registry.Opts{Username: "some-user", Password: "wrong-password"})
+		require.NoError(t, err)
+
+		ref, err := regname.ParseReference(image.RefDigest)
+		require.NoError(t, err)
+
+		_, err = reg.Image(context.Background(), ref)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "Authenticating to registry '"+fakeRegistry.Host()+"' failed")
+		assert.Contains(t, err.Error(), "401 Unauthorized")
+		assert.Contains(t, err.Error(), "--registry-username/--registry-password")
+		assert.Contains(t, err.Error(), "$IMGPKG_USERNAME/$IMGPKG_PASSWORD")
+		assert.Contains(t, err.Error(), "IMGPKG_REGISTRY_HOSTNAME/IMGPKG_REGISTRY_USERNAME/IMGPKG_REGISTRY_PASSWORD")
+	})
+
+	t.Run("when basic auth credentials are rejected while writing, it does not retry", func(t *testing.T) {
+		fakeRegistry := helpers.NewFakeRegistry(t)
+		defer fakeRegistry.CleanUp()
+		fakeRegistry.WithBasicAuth("some-user", "some-password")
+		fakeRegistry.Build()
+
+		reg, err := registry.NewRegistry(registry.Opts{Username: "some-user", Password: "wrong-password"})
+		require.NoError(t, err)
+
+		ref, err := regname.ParseReference(fakeRegistry.ReferenceOnTestServer("library/image:latest"))
+		require.NoError(t, err)
+
+		img, err := random.Image(100, 1)
+		require.NoError(t, err)
+
+		start := time.Now()
+		err = reg.WriteImage(context.Background(), ref, img)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "Authenticating to registry '"+fakeRegistry.Host()+"' failed")
+		assert.NotContains(t, err.Error(), "Retried 5 times")
+		assert.Less(t, int64(time.Since(start)), int64(time.Second))
+	})
+
+	t.Run("when anonymous access is used against registry requiring basic auth, it says anonymous access was denied", func(t *testing.T) {
+		fakeRegistry := helpers.NewFakeRegistry(t)
+		defer fakeRegistry.CleanUp()
+		image := fakeRegistry.WithRandomImage("library/image")
+		fakeRegistry.WithBasicAuth("some-user", "some-password")
+		fakeRegistry.Build()
+
+		reg, err := registry.NewRegistry(registry.Opts{Anon: true})
+		require.NoError(t, err)
+
+		ref, err := regname.ParseReference(image.RefDigest)
+		require.NoError(t, err)
+
+		_, err = reg.Digest(context.Background(), ref)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "Anonymous access to registry '"+fakeRegistry.Host()+"' was denied")
+		assert.Contains(t, err.Error(), "--registry-username/--registry-password")
+	})
+
+	t.Run("when bearer token is rejected, it names the registry and suggests how to provide credentials", func(t *testing.T) {
+		fakeRegistry := helpers.NewFakeRegistry(t)
+		defer fakeRegistry.CleanUp()
+		image := fakeRegistry.WithRandomImage("library/image")
+		fakeRegistry.WithIdentityToken("some-id-token")
+		fakeRegistry.Build()
+
+		reg, err := registry.NewRegistry(registry.Opts{Username: "some-user", Password: "wrong-password"})
+		require.NoError(t, err)
+
+		ref, err := regname.ParseReference(image.RefDigest)
+		require.NoError(t, err)
+
+		_, err = reg.Image(context.Background(), ref)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "Authenticating to registry '"+fakeRegistry.Host()+"' failed")
+		assert.Contains(t, err.Error(), "401 Unauthorized")
+	})
+
+	t.Run("when anonymous access is used against registry with bearer challenge, it says anonymous access was denied", func(t *testing.T) {
+		fakeRegistry := helpers.NewFakeRegistry(t)
+		defer fakeRegistry.CleanUp()
+		image := fakeRegistry.WithRandomImage("library/image")
+		fakeRegistry.WithIdentityToken("some-id-token")
+		fakeRegistry.Build()
+
+		reg, err := registry.NewRegistry(registry.Opts{Anon: true})
+		require.NoError(t, err)
+
+		ref, err := regname.ParseReference(image.RefDigest)
+		require.NoError(t, err)
+
+		_, err = reg.Image(context.Background(), ref)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "Anonymous access to registry '"+fakeRegistry.Host()+"' was denied")
+	})
+}
+
+func TestRegistryAuthRefresh(t *testing.T) {
+	t.Run("when the token and credentials expire while reading an image, it resolves credentials again and reads every layer", func(t *testing.T) {
+		fakeRegistry := helpers.NewFakeRegistry(t)
+		defer fakeRegistry.CleanUp()
+		image := fakeRegistry.WithRandomImage("library/image")
+		tokenExchanges := fakeRegistry.WithExpiringIdentityTokens(2)
+		fakeRegistry.Build()
+
+		useRotatingCredentialHelper(t, fakeRegistry.Host())
+		reg, err := registry.NewRegistry(registry.Opts{})
+		require.NoError(t, err)
+
+		ref, err := regname.ParseReference(image.RefDigest)
+		require.NoError(t, err)
+
+		img, err := reg.Image(context.Background(), ref)
+		require.NoError(t, err)
+
+		_, err = img.ConfigFile()
+		require.NoError(t, err)
+
+		layers, err := img.Layers()
+		require.NoError(t, err)
+		for _, layer := range layers {
+			reader, err := layer.Compressed()
+			require.NoError(t, err)
+			_, err = io.Copy(io.Discard, reader)
+			require.NoError(t, err)
+			require.NoError(t, reader.Close())
+		}
+
+		assert.Greater(t, tokenExchanges(), 1, "expected the token to be refreshed")
+	})
+
+	t.Run("when the token and credentials expire while writing an image, it resolves credentials again and writes the image", func(t *testing.T) {
+		fakeRegistry := helpers.NewFakeRegistry(t)
+		defer fakeRegistry.CleanUp()
+		tokenExchanges := fakeRegistry.WithExpiringIdentityTokens(10)
+		fakeRegistry.Build()
+
+		useRotatingCredentialHelper(t, fakeRegistry.Host())
+		reg, err := registry.NewRegistry(registry.Opts{})
+		require.NoError(t, err)
+
+		img, err := random.Image(500, 5)
+		require.NoError(t, err)
+		digest, err := img.Digest()
+		require.NoError(t, err)
+
+		ref, err := regname.ParseReference(fakeRegistry.ReferenceOnTestServer("library/written@" + digest.String()))
+		require.NoError(t, err)
+
+		require.NoError(t, reg.WriteImage(context.Background(), ref, img))
+
+		_, err = reg.Digest(context.Background(), ref)
+		require.NoError(t, err)
+
+		assert.Greater(t, tokenExchanges(), 1, "expected the token to be refreshed")
+	})
+}
+
+// useRotatingCredentialHelper configures a docker credential helper for host
+// that returns a new (random) identity token every time it is asked for credentials
+func useRotatingCredentialHelper(t *testing.T, host string) {
+	dir := t.TempDir()
+
+	helper := `#!/bin/sh
+echo '{"Username": "<token>", "Secret": "identity-token-'"$(od -An -N8 -tx8 /dev/urandom | tr -d ' ')"'"}'
+`
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "docker-credential-imgpkg-test"), []byte(helper), 0700))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "config.json"), []byte(`{"credHelpers": {"`+host+`": "imgpkg-test"}}`), 0600))
+
+	setEnv(t, "DOCKER_CONFIG", dir)
+	setEnv(t, "PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+}
+
+func setEnv(t *testing.T, key, value string) {
+	prevValue, wasSet := os.LookupEnv(key)
+	require.NoError(t, os.Setenv(key, value))
+
+	t.Cleanup(func() {
+		if wasSet {
+			os.Setenv(key, prevValue)
+		} else {
+			os.Unsetenv(key)
+		}
+	})
+}
+
+func TestRegistryRequestsPerSecond(t *testing.T) {
+	var requestTimes []time.Time
+	var requestsLock sync.Mutex
+	var tooManyRequests bool
+
+	registryHandler := regregistry.New(regregistry.Logger(log.New(io.Discard, "", 0)))
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		requestsLock.Lock()
+		requestTimes = append(requestTimes, time.Now())
+		respondTooManyRequests := tooManyRequests
+		tooManyRequests = false
+		requestsLock.Unlock()
+
+		if respondTooManyRequests {
+			writer.Header().Set("Retry-After", "1")
+			writer.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		registryHandler.ServeHTTP(writer, request)
+	}))
+	defer server.Close()
+
+	serverURL, err := url.Parse(server.URL)
+	require.NoError(t, err)
+
+	_, imageDigest := writeRandomImage(t, serverURL.Host+"/repo/image")
+
+	resetRequestTimes := func() {
+		requestsLock.Lock()
+		defer requestsLock.Unlock()
+		requestTimes = nil
+	}
+
+	t.Run("spaces requests sent concurrently according to the limit", func(t *testing.T) {
+		resetRequestTimes()
+
+		const requestsPerSecond = 20
+		interval := time.Second / requestsPerSecond
+
+		reg, err := registry.NewRegistry(registry.Opts{RequestsPerSecond: requestsPerSecond})
+		require.NoError(t, err)
+
+		var wg sync.WaitGroup
+		for i := 0; i < 5; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_, err := reg.Digest(context.Background(), imageDigest)
+				assert.NoError(t, err)
+			}()
+		}
+		wg.Wait()
+
+		requestsLock.Lock()
+		defer requestsLock.Unlock()
+
+		// Every Digest call pings the registry and then requests the manifest
+		require.Len(t, requestTimes, 10)
+
+		sort.Slice(requestTimes, func(i, j int) bool { return requestTimes[i].Before(requestTimes[j]) })
+		for i := 1; i < len(requestTimes); i++ {
+			// Allow some jitter between the request being sent and received
+			assert.GreaterOrEqual(t, int64(requestTimes[i].Sub(requestTimes[i-1])), int64(interval*8/10),
+				fmt.Sprintf("expected request %d to be sent at least %s after the previous one", i, interval))
+		}
+	})
+
+	t.Run("when registry responds with 429, it waits for Retry-After before sending further requests", func(t *testing.T) {
+		resetRequestTimes()
+
+		reg, err := registry.NewRegistry(registry.Opts{RequestsPerSecond: 100})
+		require.NoError(t, err)
+
+		requestsLock.Lock()
+		tooManyRequests = true
+		requestsLock.Unlock()
+
+		_, err = reg.Digest(context.Background(), imageDigest)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "429")
+
+		_, err = reg.Digest(context.Background(), imageDigest)
+		require.NoError(t, err)
+
+		requestsLock.Lock()
+		defer requestsLock.Unlock()
+
+		require.True(t, len(requestTimes) > 1)
+		assert.GreaterOrEqual(t, int64(requestTimes[1].Sub(requestTimes[0])), int64(900*time.Millisecond))
+	})
+
+	t.Run("when limit is not set, it does not delay requests", func(t *testing.T) {
+		reg, err := registry.NewRegistry(registry.Opts{})
+		require.NoError(t, err)
+
+		start := time.Now()
+		for i := 0; i < 10; i++ {
+			_, err := reg.Digest(context.Background(), imageDigest)
+			require.NoError(t, err)
+		}
+		assert.Less(t, int64(time.Since(start)), int64(time.Second))
+	})
+}
+
+func writeRandomImage(t *testing.T, repo string) (regname.Tag, regname.Digest) {
+	img, err := random.Image(500, 1)
+	require.NoError(t, err)
+
+	tag, err := regname.NewTag(repo + ":latest")
+	require.NoError(t, err)
+	require.NoError(t, regremote.Write(tag, img))
+
+	digest, err := img.Digest()
+	require.NoError(t, err)
+
+	digestRef, err := regname.NewDigest(repo + "@" + digest.String())
+	require.NoError(t, err)
+
+	return tag, digestRef
+}
@@ -4,6 +4,8 @@
 package util
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"time"
 
@@ -19,6 +21,11 @@ func (n NonRetryableError) Error() string {
 }
 
 func Retry(doFunc func() error) error {
+	return RetryWithContext(context.Background(), doFunc)
+}
+
+// RetryWithContext behaves like Retry, but stops retrying once ctx is done
+func RetryWithContext(ctx context.Context, doFunc func() error) error {
 	var lastErr error
 
 	for i := 0; i < 5; i++ {
@@ -38,7 +45,14 @@ func Retry(doFunc func() error) error {
 			return nonRetryableError
 		}
 
-		time.Sleep(1 * time.Second)
+		select {
+		case <-ctx.Done():
+			if errors.Is(lastErr, ctx.Err()) {
+				return lastErr
+			}
+			return fmt.Errorf("%s (%w)", lastErr, ctx.Err())
+		case <-time.After(1 * time.Second):
+		}
 	}
 	return fmt.Errorf("Retried 5 times: %s", lastErr)
 }
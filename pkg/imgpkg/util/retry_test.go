@@ -4,6 +4,7 @@
 package util
 
 import (
+	"context"
 	"errors"
 	"strings"
 	"testing"
@@ -60,3 +61,22 @@ func TestNonRetryableErrorDoesNotRetry(t *testing.T) {
 		t.Fatalf("Expected error message to contain %s, but got: %s", expectedError, err)
 	}
 }
+
+func TestRetryWithContextStopsOnceContextIsCancelled(t *testing.T) {
+	numOfRetries := 0
+	ctx, cancel := context.WithCancel(context.Background())
+
+	err := RetryWithContext(ctx, func() error {
+		numOfRetries++
+		cancel()
+		return errors.New("An error occurred")
+	})
+
+	if numOfRetries != 1 {
+		t.Fatalf("Expected to retry 1 times, but ran %d", numOfRetries)
+	}
+
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("Expected error to wrap context.Canceled, but got: %s", err)
+	}
+}
@@ -0,0 +1,274 @@
+// Copyright 2021 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package v1
+
+import (
+	"context"
+	"fmt"
+
+	regname "github.com/google/go-containerregistry/pkg/name"
+	regv1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/k14s/imgpkg/pkg/imgpkg/bundle"
+	"github.com/k14s/imgpkg/pkg/imgpkg/imagedesc"
+	ctlimgset "github.com/k14s/imgpkg/pkg/imgpkg/imageset"
+	"github.com/k14s/imgpkg/pkg/imgpkg/lockconfig"
+	"github.com/k14s/imgpkg/pkg/imgpkg/plainimage"
+	"github.com/k14s/imgpkg/pkg/imgpkg/registry"
+)
+
+// CopyOpts contains what is copied, where it is copied to and how
+type CopyOpts struct {
+	// Source of the copy, exactly one of ImageRef, BundleRef, BundleLock,
+	// ImagesLock, BundlesLock or TarPath is expected. Images in locks
+	// are expected to be referenced by digest.
+	ImageRef    string
+	BundleRef   string
+	BundleLock  *lockconfig.BundleLock
+	ImagesLock  *lockconfig.ImagesLock
+	BundlesLock *lockconfig.BundlesLock
+	// TarPath is a tarball previously written by copying to ToTar
+	TarPath string
+
+	// Destination of the copy, either ToRepos or ToTar is expected
+	ToRepos []string
+	ToTar   string
+
+	// Concurrency is the number of images copied at the same time (1 when not set)
+	Concurrency int
+
+	// IncludeNonDistributable also copies non-distributable layers, the registry
+	// is expected to be created with IncludeNonDistributableLayers as well
+	IncludeNonDistributable bool
+
+	// PreserveTags applies every tag of the source repository, that points to the
+	// copied image or bundle, to ToRepos (ImageRef or BundleRef only)
+	PreserveTags bool
+
+	// FailFast stops at the first image referenced by a bundle that cannot be copied,
+	// otherwise every other image is copied, but not the bundles referencing it
+	FailFast bool
+}
+
+// CopyResult describes the copy into each repository (none when copying to a tarball)
+type CopyResult struct {
+	Repos []CopyRepoResult
+	// NonDistributableLayers are the digests of the non-distributable layers of
+	// the copied images, they were only copied with IncludeNonDistributable
+	NonDistributableLayers []string
+}
+
+// CopyRepoResult describes the copy into a single repository
+type CopyRepoResult struct {
+	Repo   string
+	Images []CopiedImage
+	// Err is set when copying into Repo failed, Images
+	// still lists the images that were copied, if any
+	Err error
+}
+
+// CopiedImage describes where an image (or bundle) was copied to
+type CopiedImage struct {
+	// SourceDigestRef is the full reference to the copied image, e.g. repo@sha256:...
+	SourceDigestRef string
+	// DigestRef is the full reference to the image in the destination repository
+	DigestRef string
+	Tag       string
+	IsBundle  bool
+}
+
+// Copy copies images and bundles from repositories or a tarball into repositories
+// or a tarball, registry requests are aborted once ctx is done
+func Copy(ctx context.Context, opts CopyOpts, reg registry.Registry, logger Logger) (CopyResult, error) {
+	err := opts.validate()
+	if err != nil {
+		return CopyResult{}, err
+	}
+
+	if opts.Concurrency < 1 {
+		opts.Concurrency = 1
+	}
+
+	prefixedLogger := newPrefixedLogger(logger)
+	imageSet := ctlimgset.NewImageSet(opts.Concurrency, prefixedLogger)
+	tarImageSet := ctlimgset.NewTarImageSet(imageSet, opts.Concurrency, prefixedLogger)
+
+	if opts.TarPath != "" {
+		return copyFromTar(ctx, opts, tarImageSet, reg)
+	}
+
+	repoSrc := copyRepoSrc{
+		CopyOpts:    opts,
+		logger:      prefixedLogger,
+		imageSet:    imageSet,
+		tarImageSet: tarImageSet,
+		registry:    reg,
+	}
+
+	if opts.ToTar != "" {
+		return repoSrc.CopyToTar(ctx, opts.ToTar)
+	}
+	return repoSrc.CopyToRepos(ctx, opts.ToRepos)
+}
+
+func (o CopyOpts) validate() error {
+	var srcs int
+	for _, isSet := range []bool{o.ImageRef != "", o.BundleRef != "", o.BundleLock != nil, o.ImagesLock != nil, o.BundlesLock != nil, o.TarPath != ""} {
+		if isSet {
+			srcs++
+		}
+	}
+
+	switch {
+	case srcs != 1:
+		return fmt.Errorf("Expected either an image, a bundle, a lock or a tar as a source")
+	case (len(o.ToRepos) > 0) == (o.ToTar != ""):
+		return fmt.Errorf("Expected either repositories or a tar as a destination")
+	case o.TarPath != "" && o.ToTar != "":
+		return fmt.Errorf("Cannot use a tar source with a tar destination")
+	case o.PreserveTags && (len(o.ToRepos) == 0 || (o.ImageRef == "" && o.BundleRef == "")):
+		return fmt.Errorf("Expected tags to only be preserved when copying an image or a bundle to repositories")
+	}
+	return nil
+}
+
+// copyFromTar imports the tarball into every repository.
+// Failing to import into one repository does not prevent importing into the others.
+func copyFromTar(ctx context.Context, opts CopyOpts, tarImageSet ctlimgset.TarImageSet, reg registry.Registry) (CopyResult, error) {
+	var result CopyResult
+	foundLayers := false
+
+	for _, repo := range opts.ToRepos {
+		var processedImages *ctlimgset.ProcessedImages
+
+		importRepo, err := regname.NewRepository(repo)
+		if err != nil {
+			err = fmt.Errorf("Building import repository ref: %s", err)
+		} else {
+			processedImages, err = tarImageSet.Import(ctx, opts.TarPath, importRepo, reg)
+		}
+
+		// Every repository gets the same images, so the first import is enough
+		if err == nil && !foundLayers {
+			result.NonDistributableLayers = nonDistributableLayers(processedImagesLayers(processedImages))
+			foundLayers = true
+		}
+
+		repoResult, err := newCopyRepoResult(ctx, repo, processedImages, err, reg)
+		if err != nil {
+			return CopyResult{}, err
+		}
+		result.Repos = append(result.Repos, repoResult)
+	}
+
+	return result, nil
+}
+
+// newCopyRepoResult describes the processed images of a copy into repo that failed with copyErr, if any
+func newCopyRepoResult(ctx context.Context, repo string, processedImages *ctlimgset.ProcessedImages, copyErr error, reg ctlimgset.ImagesReaderWriter) (CopyRepoResult, error) {
+	result := CopyRepoResult{Repo: repo, Images: []CopiedImage{}, Err: copyErr}
+	if processedImages == nil {
+		return result, nil
+	}
+
+	for _, item := range processedImages.All() {
+		plainImg := plainimage.NewFetchedPlainImageWithTag(item.DigestRef, item.UnprocessedImageRef.Tag, item.Image, item.ImageIndex)
+
+		isBundle, err := bundle.NewBundleFromPlainImage(plainImg, reg).IsBundle(ctx)
+		if err != nil {
+			return CopyRepoResult{}, fmt.Errorf("Check if '%s' is bundle: %s", item.DigestRef, err)
+		}
+
+		result.Images = append(result.Images, CopiedImage{
+			SourceDigestRef: item.UnprocessedImageRef.DigestRef,
+			DigestRef:       item.DigestRef,
+			Tag:             item.UnprocessedImageRef.Tag,
+			IsBundle:        isBundle,
+		})
+	}
+
+	return result, nil
+}
+
+// nonDistributableLayers returns the digest of every non-distributable layer, once
+func nonDistributableLayers(everyLayer []imagedesc.ImageLayerDescriptor) []string {
+	digests := []string{}
+	seenLayers := map[string]struct{}{}
+
+	for _, layer := range everyLayer {
+		if layer.IsDistributable() {
+			continue
+		}
+		if _, seen := seenLayers[layer.Digest]; seen {
+			continue
+		}
+		seenLayers[layer.Digest] = struct{}{}
+		digests = append(digests, layer.Digest)
+	}
+	return digests
+}
+
+func processedImagesLayers(processedImages *ctlimgset.ProcessedImages) []imagedesc.ImageLayerDescriptor {
+	everyLayer := []imagedesc.ImageLayerDescriptor{}
+	for _, image := range processedImages.All() {
+		if image.ImageIndex != nil {
+			layers := everyLayerForAnImageIndex(image.ImageIndex)
+			everyLayer = append(everyLayer, layers...)
+		} else if image.Image != nil {
+			layers := everyLayerForAnImage(image.Image)
+			everyLayer = append(everyLayer, layers...)
+		}
+	}
+	return everyLayer
+}
+
+func everyLayerForAnImageIndex(imageIndex regv1.ImageIndex) []imagedesc.ImageLayerDescriptor {
+	everyLayer := []imagedesc.ImageLayerDescriptor{}
+	indexManifest, err := imageIndex.IndexManifest()
+	if err != nil {
+		return []imagedesc.ImageLayerDescriptor{}
+	}
+	for _, descriptor := range indexManifest.Manifests {
+		if descriptor.MediaType.IsIndex() {
+			imageIndex, err := imageIndex.ImageIndex(descriptor.Digest)
+			if err != nil {
+				continue
+			}
+			layersForImageIndex := everyLayerForAnImageIndex(imageIndex)
+			everyLayer = append(everyLayer, layersForImageIndex...)
+		} else {
+			image, err := imageIndex.Image(descriptor.Digest)
+			if err != nil {
+				continue
+			}
+			layersForImage := everyLayerForAnImage(image)
+			everyLayer = append(everyLayer, layersForImage...)
+		}
+	}
+	return everyLayer
+}
+
+func everyLayerForAnImage(image regv1.Image) []imagedesc.ImageLayerDescriptor {
+	var everyLayer []imagedesc.ImageLayerDescriptor
+
+	layers, err := image.Layers()
+	if err != nil {
+		return everyLayer
+	}
+
+	for _, layer := range layers {
+		mediaType, err := layer.MediaType()
+		if err != nil {
+			continue
+		}
+		digest, err := layer.Digest()
+		if err != nil {
+			continue
+		}
+		everyLayer = append(everyLayer, imagedesc.ImageLayerDescriptor{
+			MediaType: string(mediaType),
+			Digest:    digest.String(),
+		})
+	}
+	return everyLayer
+}
@@ -0,0 +1,649 @@
+// Copyright 2020 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package v1
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/k14s/imgpkg/pkg/imgpkg/imagedesc"
+	"github.com/k14s/imgpkg/pkg/imgpkg/imagetar"
+
+	regname "github.com/google/go-containerregistry/pkg/name"
+	regremote "github.com/google/go-containerregistry/pkg/v1/remote"
+	ctlbundle "github.com/k14s/imgpkg/pkg/imgpkg/bundle"
+	ctlimg "github.com/k14s/imgpkg/pkg/imgpkg/image"
+	ctlimgset "github.com/k14s/imgpkg/pkg/imgpkg/imageset"
+	"github.com/k14s/imgpkg/pkg/imgpkg/lockconfig"
+	"github.com/k14s/imgpkg/pkg/imgpkg/plainimage"
+)
+
+// copyRepoSrc copies images and bundles found in repositories
+type copyRepoSrc struct {
+	CopyOpts
+	logger      *ctlimg.LoggerPrefixWriter
+	imageSet    ctlimgset.ImageSet
+	tarImageSet ctlimgset.TarImageSet
+	registry    ctlimgset.ImagesReaderWriter
+}
+
+func (c copyRepoSrc) CopyToTar(ctx context.Context, dstPath string) (CopyResult, error) {
+	srcImages, err := c.getSourceImages(ctx)
+	if err != nil {
+		return CopyResult{}, err
+	}
+
+	// A tarball without the bundle is of no use, so nothing is written
+	if len(srcImages.imgErrs) > 0 {
+		return CopyResult{}, partialCopyError{imgErrs: srcImages.imgErrs}
+	}
+
+	// Bundles (including nested ones) are pinned to digests using
+	// the destination repository, which is not known yet when copying to a tarball
+	for _, imgRef := range srcImages.all().All() {
+		_, hasTagRefs, err := ctlbundle.NewBundle(imgRef.DigestRef, c.registry).ResolvedImagesLock(ctx)
+		if err != nil {
+			if ctlbundle.IsNotBundleError(err) {
+				continue
+			}
+			return CopyResult{}, err
+		}
+		if hasTagRefs {
+			return CopyResult{}, fmt.Errorf("Expected images referenced by bundle '%s' to use digests when copying to a tar "+
+				"(hint: Copy the bundle to a repository first, which pins them to digests)", imgRef.DigestRef)
+		}
+	}
+
+	ids, err := c.tarImageSet.Export(ctx, srcImages.all(), dstPath, c.registry, imagetar.NewImageLayerWriterCheck(c.IncludeNonDistributable))
+	if err != nil {
+		return CopyResult{}, err
+	}
+
+	return CopyResult{NonDistributableLayers: nonDistributableLayers(imageRefDescriptorsLayers(ids))}, nil
+}
+
+// CopyToRepos reads the source images once and imports them into every repository.
+// Failing to copy into one repository does not prevent copying into the others.
+// Unless FailFast is set, images referenced by bundles that cannot be reached do not
+// prevent copying the other images, but the bundles themselves are not copied.
+func (c copyRepoSrc) CopyToRepos(ctx context.Context, repos []string) (CopyResult, error) {
+	srcImages, err := c.getSourceImages(ctx)
+	if err != nil {
+		return CopyResult{}, err
+	}
+
+	ids, err := c.imageSet.Export(ctx, srcImages.all(), c.registry)
+	if err != nil {
+		return CopyResult{}, err
+	}
+
+	var layerProvider imagedesc.LayerProvider = ids
+
+	if len(repos) > 1 {
+		spoolDirPath, err := ioutil.TempDir("", "imgpkg-copy-layers")
+		if err != nil {
+			return CopyResult{}, err
+		}
+
+		defer os.RemoveAll(spoolDirPath)
+
+		layerProvider = imagedesc.NewSpoolingLayerProvider(ids, spoolDirPath)
+	}
+
+	result := CopyResult{NonDistributableLayers: nonDistributableLayers(imageRefDescriptorsLayers(ids))}
+
+	for _, repo := range repos {
+		processedImages, err := c.importToRepo(ctx, imagedesc.NewDescribedReader(ids, layerProvider), repo)
+		if err == nil && len(srcImages.imgErrs) > 0 {
+			err = partialCopyError{imgErrs: srcImages.imgErrs, copiedImages: processedImages}
+		}
+
+		repoResult, err := newCopyRepoResult(ctx, repo, processedImages, err, c.registry)
+		if err != nil {
+			return CopyResult{}, err
+		}
+		result.Repos = append(result.Repos, repoResult)
+	}
+
+	return result, nil
+}
+
+// importToRepo imports bundles only after every other image was imported,
+// so that a bundle is never available in the repository without its images
+func (c copyRepoSrc) importToRepo(ctx context.Context, reader imagedesc.DescribedReader, repo string) (*ctlimgset.ProcessedImages, error) {
+	importRepo, err := regname.NewRepository(repo)
+	if err != nil {
+		return nil, fmt.Errorf("Building import repository ref: %s", err)
+	}
+
+	var images, bundles []imagedesc.ImageOrIndex
+	for _, item := range reader.Read() {
+		isBundle, err := c.isBundle(ctx, item)
+		if err != nil {
+			return nil, err
+		}
+
+		if isBundle {
+			bundles = append(bundles, item)
+		} else {
+			images = append(images, item)
+		}
+	}
+
+	processedImages, err := c.imageSet.Import(ctx, images, importRepo, c.registry)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(bundles) > 0 {
+		processedBundles, err := c.importBundles(ctx, bundles, importRepo)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, processedBundle := range processedBundles.All() {
+			processedImages.Add(processedBundle)
+		}
+	}
+
+	if c.PreserveTags {
+		err = c.preserveTags(ctx, processedImages, importRepo)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return processedImages, nil
+}
+
+// isBundle checks the exported image itself, so that nested bundles are found as well
+func (c copyRepoSrc) isBundle(ctx context.Context, item imagedesc.ImageOrIndex) (bool, error) {
+	if item.Image == nil {
+		return false, nil
+	}
+
+	plainImg := plainimage.NewFetchedPlainImageWithTag(item.Ref(), item.Tag(), *item.Image, nil)
+
+	isBundle, err := ctlbundle.NewBundleFromPlainImage(plainImg, c.registry).IsBundle(ctx)
+	if err != nil {
+		return false, fmt.Errorf("Check if '%s' is bundle: %s", item.Ref(), err)
+	}
+	return isBundle, nil
+}
+
+// importBundles imports bundles as is, except for bundles whose Images Lock references
+// images by tag. Those are rewritten to reference the copied images in importRepo
+// by digest (keeping each tag as a hint), so that the copied bundle is fully pinned.
+// Nested bundles are imported before the bundles referencing them, and a bundle
+// referencing a rewritten bundle is rewritten as well to reference its new digest.
+func (c copyRepoSrc) importBundles(ctx context.Context, bundles []imagedesc.ImageOrIndex, importRepo regname.Repository) (*ctlimgset.ProcessedImages, error) {
+	bundlesByDigest := map[string][]imagedesc.ImageOrIndex{}
+	imagesLocks := map[string]lockconfig.ImagesLock{}
+	hasTagRefs := map[string]bool{}
+	var digests []string
+
+	for _, item := range bundles {
+		digest, err := refDigest(item.Ref())
+		if err != nil {
+			return nil, err
+		}
+
+		if _, found := bundlesByDigest[digest]; !found {
+			imagesLock, tagRefs, err := ctlbundle.NewBundle(item.Ref(), c.registry).ResolvedImagesLock(ctx)
+			if err != nil {
+				return nil, err
+			}
+
+			imagesLocks[digest] = imagesLock
+			hasTagRefs[digest] = tagRefs
+			digests = append(digests, digest)
+		}
+		bundlesByDigest[digest] = append(bundlesByDigest[digest], item)
+	}
+
+	orderedDigests, err := innermostBundlesFirst(digests, imagesLocks)
+	if err != nil {
+		return nil, err
+	}
+
+	processedBundles := ctlimgset.NewProcessedImages()
+	// pinnedDigests maps digests of source bundles to the digests of their rewritten copies
+	pinnedDigests := map[string]string{}
+	var bundlesAsIs []imagedesc.ImageOrIndex
+
+	for _, digest := range orderedDigests {
+		imagesLock := imagesLocks[digest]
+
+		referencesPinned, err := referencesAnyDigest(imagesLock, pinnedDigests)
+		if err != nil {
+			return nil, err
+		}
+		if !hasTagRefs[digest] && !referencesPinned {
+			bundlesAsIs = append(bundlesAsIs, bundlesByDigest[digest]...)
+			continue
+		}
+
+		// Bundles referenced by the pinned bundle are expected to be in the repository first
+		err = c.importBundlesAsIs(ctx, bundlesAsIs, importRepo, processedBundles)
+		if err != nil {
+			return nil, err
+		}
+		bundlesAsIs = nil
+
+		for _, item := range bundlesByDigest[digest] {
+			bundle := ctlbundle.NewBundle(item.Ref(), c.registry)
+
+			processedBundle, err := c.importPinnedBundle(ctx, bundle, item.Tag(), imagesLock, pinnedDigests, importRepo)
+			if err != nil {
+				return nil, fmt.Errorf("Pinning images of bundle '%s': %s", item.Ref(), err)
+			}
+			processedBundles.Add(processedBundle)
+
+			pinnedDigests[digest], err = refDigest(processedBundle.DigestRef)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	err = c.importBundlesAsIs(ctx, bundlesAsIs, importRepo, processedBundles)
+	if err != nil {
+		return nil, err
+	}
+
+	return processedBundles, nil
+}
+
+func (c copyRepoSrc) importBundlesAsIs(ctx context.Context, bundles []imagedesc.ImageOrIndex, importRepo regname.Repository, processedBundles *ctlimgset.ProcessedImages) error {
+	if len(bundles) == 0 {
+		return nil
+	}
+
+	importedBundles, err := c.imageSet.Import(ctx, bundles, importRepo, c.registry)
+	if err != nil {
+		return err
+	}
+
+	for _, importedBundle := range importedBundles.All() {
+		processedBundles.Add(importedBundle)
+	}
+	return nil
+}
+
+// importPinnedBundle writes the bundle to importRepo with its Images Lock pointing
+// to the images copied into importRepo (or to the rewritten copies of nested bundles)
+func (c copyRepoSrc) importPinnedBundle(ctx context.Context, bundle *ctlbundle.Bundle, tag string, imagesLock lockconfig.ImagesLock, pinnedDigests map[string]string, importRepo regname.Repository) (ctlimgset.ProcessedImage, error) {
+	var imageRefs []lockconfig.ImageRef
+	for _, imageRef := range imagesLock.Images {
+		digest, err := refDigest(imageRef.Image)
+		if err != nil {
+			return ctlimgset.ProcessedImage{}, err
+		}
+		if pinnedDigest, found := pinnedDigests[digest]; found {
+			digest = pinnedDigest
+		}
+
+		imageRef = imageRef.DeepCopy()
+		imageRef.Image = importRepo.Digest(digest).Name()
+		imageRefs = append(imageRefs, imageRef)
+	}
+	imagesLock.Images = imageRefs
+
+	pinnedImg, err := bundle.ImageWithImagesLock(ctx, imagesLock)
+	if err != nil {
+		return ctlimgset.ProcessedImage{}, err
+	}
+
+	defer pinnedImg.Remove()
+
+	digest, err := pinnedImg.Digest()
+	if err != nil {
+		return ctlimgset.ProcessedImage{}, err
+	}
+	importDigestRef := importRepo.Digest(digest.String())
+
+	c.logger.WriteStr("importing %s with images pinned to digests -> %s...\n", bundle.DigestRef(), importDigestRef.Name())
+
+	err = c.registry.WriteImage(ctx, importRepo.Tag(fmt.Sprintf("imgpkg-%s-%s", digest.Algorithm, digest.Hex)), pinnedImg)
+	if err != nil {
+		return ctlimgset.ProcessedImage{}, err
+	}
+
+	if tag != "" {
+		err = c.registry.WriteTag(ctx, importRepo.Tag(tag), pinnedImg)
+		if err != nil {
+			return ctlimgset.ProcessedImage{}, err
+		}
+	}
+
+	// The pinned image is removed once written, so the imported one is used instead
+	importedImg, err := c.registry.Image(ctx, importDigestRef)
+	if err != nil {
+		return ctlimgset.ProcessedImage{}, err
+	}
+
+	return ctlimgset.ProcessedImage{
+		UnprocessedImageRef: ctlimgset.UnprocessedImageRef{DigestRef: bundle.DigestRef(), Tag: tag},
+		DigestRef:           importDigestRef.Name(),
+		Image:               importedImg,
+	}, nil
+}
+
+// innermostBundlesFirst orders bundle digests so that every bundle
+// comes after the bundles referenced by its Images Lock
+func innermostBundlesFirst(digests []string, imagesLocks map[string]lockconfig.ImagesLock) ([]string, error) {
+	var orderedDigests []string
+	visited := map[string]struct{}{}
+
+	var visit func(digest string) error
+	visit = func(digest string) error {
+		if _, found := visited[digest]; found {
+			return nil
+		}
+		visited[digest] = struct{}{}
+
+		for _, imageRef := range imagesLocks[digest].Images {
+			imageDigest, err := refDigest(imageRef.Image)
+			if err != nil {
+				return err
+			}
+			if _, isBundle := imagesLocks[imageDigest]; isBundle {
+				err = visit(imageDigest)
+				if err != nil {
+					return err
+				}
+			}
+		}
+
+		orderedDigests = append(orderedDigests, digest)
+		return nil
+	}
+
+	for _, digest := range digests {
+		err := visit(digest)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return orderedDigests, nil
+}
+
+func referencesAnyDigest(imagesLock lockconfig.ImagesLock, digests map[string]string) (bool, error) {
+	for _, imageRef := range imagesLock.Images {
+		digest, err := refDigest(imageRef.Image)
+		if err != nil {
+			return false, err
+		}
+		if _, found := digests[digest]; found {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func refDigest(ref string) (string, error) {
+	digestRef, err := regname.NewDigest(ref)
+	if err != nil {
+		return "", err
+	}
+	return digestRef.DigestStr(), nil
+}
+
+// preserveTags applies every tag of the source repository,
+// that points to a copied image, to the destination repository
+func (c copyRepoSrc) preserveTags(ctx context.Context, processedImages *ctlimgset.ProcessedImages, importRepo regname.Repository) error {
+	srcRef := c.ImageRef
+	if srcRef == "" {
+		srcRef = c.BundleRef
+	}
+
+	parsedSrcRef, err := regname.ParseReference(srcRef, regname.WeakValidation)
+	if err != nil {
+		return err
+	}
+	srcRepo := parsedSrcRef.Context()
+
+	copiedImagesByDigest := map[string]ctlimgset.ProcessedImage{}
+	for _, item := range processedImages.All() {
+		digestRef, err := regname.NewDigest(item.UnprocessedImageRef.DigestRef)
+		if err != nil {
+			return err
+		}
+		if digestRef.Context().Name() == srcRepo.Name() {
+			copiedImagesByDigest[digestRef.DigestStr()] = item
+		}
+	}
+
+	tags, err := c.registry.ListTags(ctx, srcRepo)
+	if err != nil {
+		return fmt.Errorf("Listing tags of '%s': %s", srcRepo.Name(), err)
+	}
+
+	preservedTags := 0
+	for _, tag := range tags {
+		digest, err := c.registry.Digest(ctx, srcRepo.Tag(tag))
+		if err != nil {
+			return fmt.Errorf("Resolving tag '%s': %s", srcRepo.Tag(tag).Name(), err)
+		}
+
+		item, found := copiedImagesByDigest[digest.String()]
+		if !found {
+			continue
+		}
+
+		var taggable regremote.Taggable = item.Image
+		if item.ImageIndex != nil {
+			taggable = item.ImageIndex
+		}
+
+		c.logger.WriteStr("tagging %s as %s\n", item.DigestRef, importRepo.Tag(tag).Name())
+
+		err = c.registry.WriteTag(ctx, importRepo.Tag(tag), taggable)
+		if err != nil {
+			return err
+		}
+		preservedTags++
+	}
+
+	if preservedTags == 0 {
+		c.logger.WriteStr("no tags found in '%s' to preserve\n", srcRepo.Name())
+	}
+
+	return nil
+}
+
+// sourceImages are the images to copy. Bundles are kept separately as they are
+// imported last, and are left out when any image they reference cannot be reached
+type sourceImages struct {
+	imageRefs  *ctlimgset.UnprocessedImageRefs
+	bundleRefs *ctlimgset.UnprocessedImageRefs
+	imgErrs    []ctlbundle.ImageError
+}
+
+func newSourceImages() *sourceImages {
+	return &sourceImages{
+		imageRefs:  ctlimgset.NewUnprocessedImageRefs(),
+		bundleRefs: ctlimgset.NewUnprocessedImageRefs(),
+	}
+}
+
+func (s *sourceImages) addBundle(bundleRef ctlimgset.UnprocessedImageRef, imageRefs []lockconfig.ImageRef, imgErrs []ctlbundle.ImageError) {
+	for _, img := range imageRefs {
+		s.imageRefs.Add(ctlimgset.UnprocessedImageRef{DigestRef: img.PrimaryLocation()})
+	}
+
+	if len(imgErrs) > 0 {
+		s.imgErrs = append(s.imgErrs, imgErrs...)
+		return
+	}
+
+	s.bundleRefs.Add(bundleRef)
+}
+
+func (s *sourceImages) all() *ctlimgset.UnprocessedImageRefs {
+	result := ctlimgset.NewUnprocessedImageRefs()
+	for _, imgRef := range s.imageRefs.All() {
+		result.Add(imgRef)
+	}
+	for _, bundleRef := range s.bundleRefs.All() {
+		result.Add(bundleRef)
+	}
+	return result
+}
+
+func (c copyRepoSrc) getSourceImages(ctx context.Context) (*sourceImages, error) {
+	srcImages := newSourceImages()
+
+	switch {
+	case c.BundleLock != nil:
+		_, imageRefs, imgErrs, err := c.getBundleImageRefs(ctx, c.BundleLock.Bundle.Image)
+		if err != nil {
+			return nil, err
+		}
+
+		srcImages.addBundle(ctlimgset.UnprocessedImageRef{
+			DigestRef: c.BundleLock.Bundle.Image,
+			Tag:       c.BundleLock.Bundle.Tag,
+		}, imageRefs, imgErrs)
+
+		return srcImages, nil
+
+	case c.ImagesLock != nil:
+		for _, img := range c.ImagesLock.Images {
+			plainImg := plainimage.NewPlainImage(img.Image, c.registry)
+
+			ok, err := ctlbundle.NewBundleFromPlainImage(plainImg, c.registry).IsBundle(ctx)
+			if err != nil {
+				return nil, err
+			}
+			if ok {
+				return nil, fmt.Errorf("Unable to copy bundles using an Images Lock file (hint: Create a bundle with these images)")
+			}
+
+			srcImages.imageRefs.Add(ctlimgset.UnprocessedImageRef{DigestRef: plainImg.DigestRef()})
+		}
+		return srcImages, nil
+
+	case c.BundlesLock != nil:
+		for _, bundleRef := range c.BundlesLock.Bundles {
+			_, imageRefs, imgErrs, err := c.getBundleImageRefs(ctx, bundleRef.Image)
+			if err != nil {
+				return nil, fmt.Errorf("Reading bundle '%s': %s", bundleRef.Name, err)
+			}
+
+			srcImages.addBundle(ctlimgset.UnprocessedImageRef{
+				DigestRef: bundleRef.Image,
+				Tag:       bundleRef.Tag,
+			}, imageRefs, imgErrs)
+		}
+		return srcImages, nil
+
+	case c.ImageRef != "":
+		plainImg := plainimage.NewPlainImage(c.ImageRef, c.registry)
+
+		err := validateImageKind(ctx, plainImg, false, c.registry)
+		if err != nil {
+			return nil, err
+		}
+
+		srcImages.imageRefs.Add(ctlimgset.UnprocessedImageRef{DigestRef: plainImg.DigestRef()})
+		return srcImages, nil
+
+	default:
+		bundle, imageRefs, imgErrs, err := c.getBundleImageRefs(ctx, c.BundleRef)
+		if err != nil {
+			return nil, err
+		}
+
+		srcImages.addBundle(ctlimgset.UnprocessedImageRef{DigestRef: bundle.DigestRef(), Tag: bundle.Tag()}, imageRefs, imgErrs)
+
+		return srcImages, nil
+	}
+}
+
+func (c copyRepoSrc) getBundleImageRefs(ctx context.Context, bundleRef string) (*ctlbundle.Bundle, []lockconfig.ImageRef, []ctlbundle.ImageError, error) {
+	plainImg := plainimage.NewPlainImage(bundleRef, c.registry)
+
+	err := validateImageKind(ctx, plainImg, true, c.registry)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	bundle := ctlbundle.NewBundleFromPlainImage(plainImg, c.registry)
+
+	var imgLock *ctlbundle.ImagesLock
+	var imgErrs []ctlbundle.ImageError
+
+	if c.FailFast {
+		imgLock, err = bundle.AllImagesLock(ctx, c.Concurrency)
+	} else {
+		imgLock, imgErrs, err = bundle.AllReachableImagesLock(ctx, c.Concurrency)
+	}
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	imageRefs, err := imgLock.LocationPrunedImageRefs(ctx, c.Concurrency)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("Pruning image ref locations: %s", err)
+	}
+	return bundle, imageRefs, imgErrs, nil
+}
+
+// partialCopyError lists the images referenced by bundles that could not be copied,
+// along with the images that were copied (bundles themselves are not copied)
+type partialCopyError struct {
+	imgErrs      []ctlbundle.ImageError
+	copiedImages *ctlimgset.ProcessedImages
+}
+
+func (e partialCopyError) Error() string {
+	imgErrs := append([]ctlbundle.ImageError{}, e.imgErrs...)
+	sort.Slice(imgErrs, func(i, j int) bool { return imgErrs[i].Image < imgErrs[j].Image })
+
+	var lines []string
+	for _, imgErr := range imgErrs {
+		lines = append(lines, "- "+imgErr.Error())
+	}
+
+	if e.copiedImages != nil {
+		lines = append(lines, fmt.Sprintf("Copied %d image(s):", len(e.copiedImages.All())))
+		for _, img := range e.copiedImages.All() {
+			lines = append(lines, fmt.Sprintf("- '%s' to '%s'", img.UnprocessedImageRef.DigestRef, img.DigestRef))
+		}
+	}
+
+	return fmt.Sprintf("Unable to copy %d image(s) referenced by bundle(s), so the bundle(s) were not copied:\n%s", len(e.imgErrs), strings.Join(lines, "\n"))
+}
+
+// validateImageKind returns ErrIsNotBundle when a bundle is expected but plainImg
+// is a plain image, and ErrIsBundle when a plain image is expected but plainImg is a bundle
+func validateImageKind(ctx context.Context, plainImg *plainimage.PlainImage, expectBundle bool, reg ctlimg.ImagesMetadata) error {
+	isBundle, err := ctlbundle.NewBundleFromPlainImage(plainImg, reg).IsBundle(ctx)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case expectBundle && !isBundle:
+		return ErrIsNotBundle{}
+	case !expectBundle && isBundle:
+		return ErrIsBundle{}
+	}
+	return nil
+}
+
+func imageRefDescriptorsLayers(ids *imagedesc.ImageRefDescriptors) []imagedesc.ImageLayerDescriptor {
+	layers := []imagedesc.ImageLayerDescriptor{}
+	for _, descriptor := range ids.Descriptors() {
+		if descriptor.Image != nil {
+			layers = append(layers, (*descriptor.Image).Layers...)
+		}
+	}
+	return layers
+}
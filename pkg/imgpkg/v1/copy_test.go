@@ -0,0 +1,955 @@
+// Copyright 2020 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package v1_test
+
+import (
+	"archive/tar"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	regregistry "github.com/google/go-containerregistry/pkg/registry"
+	regv1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	regremote "github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/k14s/imgpkg/pkg/imgpkg/bundle"
+	"github.com/k14s/imgpkg/pkg/imgpkg/imagetar"
+	"github.com/k14s/imgpkg/pkg/imgpkg/lockconfig"
+	"github.com/k14s/imgpkg/pkg/imgpkg/registry"
+	v1 "github.com/k14s/imgpkg/pkg/imgpkg/v1"
+	"github.com/k14s/imgpkg/test/helpers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"sigs.k8s.io/yaml"
+)
+
+func TestCopyToTarBundle(t *testing.T) {
+	bundleName := "library/bundle"
+	fakeRegistry := helpers.NewFakeRegistry(t)
+	bundleWithImages := fakeRegistry.WithBundleFromPath(bundleName, "test_assets/bundle").
+		WithEveryImageFromPath("test_assets/image_with_config", map[string]string{})
+
+	defer fakeRegistry.CleanUp()
+
+	opts := v1.CopyOpts{BundleRef: fakeRegistry.ReferenceOnTestServer(bundleName)}
+	reg := fakeRegistry.Build()
+
+	t.Run("Tar should contain every layer", func(t *testing.T) {
+		opts := opts
+		opts.ToTar = filepath.Join(t.TempDir(), "bundle.tar")
+
+		result, err := v1.Copy(context.Background(), opts, reg, nil)
+		require.NoError(t, err)
+		assert.Empty(t, result.Repos)
+
+		assertTarballContainsEveryLayer(t, opts.ToTar)
+	})
+
+	t.Run("When a bundle contains a bundle, it copies all layers to tar", func(t *testing.T) {
+		bundleWithNested := fakeRegistry.
+			WithBundleFromPath("library/with-nested-bundle", "test_assets/bundle").
+			WithImageRefs([]lockconfig.ImageRef{
+				{Image: bundleWithImages.RefDigest},
+			})
+		reg := fakeRegistry.Build()
+
+		opts := v1.CopyOpts{
+			BundleRef: fakeRegistry.ReferenceOnTestServer(bundleWithNested.BundleName + "@" + bundleWithNested.Digest),
+			ToTar:     filepath.Join(t.TempDir(), "bundle.tar"),
+		}
+
+		_, err := v1.Copy(context.Background(), opts, reg, nil)
+		require.NoError(t, err)
+
+		assertTarballContainsOnlyDistributableLayers(opts.ToTar, t)
+	})
+
+	t.Run("When a bundle lock is provided, it copies all layers to tar", func(t *testing.T) {
+		bundleLock, err := lockconfig.NewBundleLockFromBytes([]byte(fmt.Sprintf(`
+apiVersion: imgpkg.carvel.dev/v1alpha1
+kind: BundleLock
+bundle:
+  image: %s
+`, bundleWithImages.RefDigest)))
+		require.NoError(t, err)
+
+		opts := v1.CopyOpts{BundleLock: &bundleLock, ToTar: filepath.Join(t.TempDir(), "bundle.tar")}
+
+		_, err = v1.Copy(context.Background(), opts, reg, nil)
+		require.NoError(t, err)
+
+		assertTarballContainsEveryLayer(t, opts.ToTar)
+	})
+}
+
+func TestCopyToTarBundleContainingNonDistributableLayers(t *testing.T) {
+	bundleName := "library/bundle"
+	fakeRegistry := helpers.NewFakeRegistry(t)
+	defer fakeRegistry.CleanUp()
+	randomImage := fakeRegistry.WithRandomImage("library/image_with_config")
+	randomImageWithNonDistributableLayer := fakeRegistry.
+		WithRandomImage("library/image_with_non_dist_layer").WithNonDistributableLayer()
+
+	fakeRegistry.WithBundleFromPath(bundleName, "test_assets/bundle_with_mult_images").
+		WithImageRefs([]lockconfig.ImageRef{
+			{Image: randomImage.RefDigest},
+			{Image: randomImageWithNonDistributableLayer.RefDigest},
+		})
+
+	opts := v1.CopyOpts{BundleRef: fakeRegistry.ReferenceOnTestServer(bundleName)}
+	reg := fakeRegistry.Build()
+
+	t.Run("Tar should contain every distributable layer only", func(t *testing.T) {
+		opts := opts
+		opts.ToTar = filepath.Join(t.TempDir(), "bundle.tar")
+
+		_, err := v1.Copy(context.Background(), opts, reg, nil)
+		require.NoError(t, err)
+
+		assertTarballContainsOnlyDistributableLayers(opts.ToTar, t)
+	})
+
+	t.Run("Skipped non-distributable layers are returned", func(t *testing.T) {
+		opts := opts
+		opts.ToTar = filepath.Join(t.TempDir(), "bundle.tar")
+
+		result, err := v1.Copy(context.Background(), opts, reg, nil)
+		require.NoError(t, err)
+
+		assert.Equal(t, []string{nonDistributableLayerDigest(t, randomImageWithNonDistributableLayer)}, result.NonDistributableLayers)
+	})
+
+	t.Run("When IncludeNonDistributable is set the tarball should contain every layer", func(t *testing.T) {
+		opts := opts
+		opts.IncludeNonDistributable = true
+		opts.ToTar = filepath.Join(t.TempDir(), "bundle.tar")
+
+		result, err := v1.Copy(context.Background(), opts, reg, nil)
+		require.NoError(t, err)
+
+		assertTarballContainsEveryLayer(t, opts.ToTar)
+		assert.Equal(t, []string{nonDistributableLayerDigest(t, randomImageWithNonDistributableLayer)}, result.NonDistributableLayers)
+	})
+
+	t.Run("When a bundle contains a bundle with non distributable layer, it copies all layers to tar", func(t *testing.T) {
+		assets := &helpers.Assets{T: t}
+		bundleBuilder := helpers.NewBundleDir(t, assets)
+		defer assets.CleanCreatedFolders()
+		imageLockYAML := fmt.Sprintf(`---
+apiVersion: imgpkg.carvel.dev/v1alpha1
+kind: ImagesLock
+images:
+- image: %s@%s
+`, fakeRegistry.ReferenceOnTestServer(bundleName), fakeRegistry.
+			WithBundleFromPath(bundleName, "test_assets/bundle_with_mult_images").Digest)
+		bundleDir := bundleBuilder.CreateBundleDir(helpers.BundleYAML, imageLockYAML)
+		bundleWithNested := fakeRegistry.WithBundleFromPath("library/with-nested-bundle", bundleDir)
+		reg := fakeRegistry.Build()
+
+		opts := v1.CopyOpts{
+			BundleRef: fakeRegistry.ReferenceOnTestServer(bundleWithNested.BundleName + "@" + bundleWithNested.Digest),
+			ToTar:     filepath.Join(t.TempDir(), "bundle.tar"),
+		}
+
+		_, err := v1.Copy(context.Background(), opts, reg, nil)
+		require.NoError(t, err)
+
+		assertTarballContainsOnlyDistributableLayers(opts.ToTar, t)
+	})
+}
+
+func TestCopyToTarImage(t *testing.T) {
+	imageName := "library/image"
+	fakeRegistry := helpers.NewFakeRegistry(t)
+	fakeRegistry.WithImageFromPath(imageName, "test_assets/image_with_config", map[string]string{})
+	defer fakeRegistry.CleanUp()
+
+	opts := v1.CopyOpts{ImageRef: fakeRegistry.ReferenceOnTestServer(imageName)}
+	reg := fakeRegistry.Build()
+
+	t.Run("Tar should contain every layer", func(t *testing.T) {
+		opts := opts
+		opts.ToTar = filepath.Join(t.TempDir(), "image.tar")
+
+		_, err := v1.Copy(context.Background(), opts, reg, nil)
+		require.NoError(t, err)
+
+		assertTarballContainsEveryLayer(t, opts.ToTar)
+	})
+
+	t.Run("When IncludeNonDistributable is set the tarball should contain every layer", func(t *testing.T) {
+		opts := opts
+		opts.IncludeNonDistributable = true
+		opts.ToTar = filepath.Join(t.TempDir(), "image.tar")
+
+		result, err := v1.Copy(context.Background(), opts, reg, nil)
+		require.NoError(t, err)
+
+		assertTarballContainsEveryLayer(t, opts.ToTar)
+		assert.Empty(t, result.NonDistributableLayers)
+	})
+
+	t.Run("When the image is a bundle, it returns ErrIsBundle", func(t *testing.T) {
+		bundle := fakeRegistry.WithBundleFromPath("library/bundle", "test_assets/bundle")
+		reg := fakeRegistry.Build()
+
+		opts := v1.CopyOpts{ImageRef: bundle.RefDigest, ToTar: filepath.Join(t.TempDir(), "image.tar")}
+
+		_, err := v1.Copy(context.Background(), opts, reg, nil)
+		require.Error(t, err)
+		assert.True(t, v1.IsBundleError(err), "expected bundle error, got: %s", err)
+	})
+}
+
+func TestCopyToTarImageContainingNonDistributableLayers(t *testing.T) {
+	imageName := "library/image"
+	fakeRegistry := helpers.NewFakeRegistry(t)
+	fakeRegistry.WithImageFromPath(imageName, "test_assets/image_with_config", map[string]string{}).
+		WithNonDistributableLayer()
+	defer fakeRegistry.CleanUp()
+
+	opts := v1.CopyOpts{ImageRef: fakeRegistry.ReferenceOnTestServer(imageName)}
+	reg := fakeRegistry.Build()
+
+	t.Run("Tar should contain every distributable layer only", func(t *testing.T) {
+		opts := opts
+		opts.ToTar = filepath.Join(t.TempDir(), "image.tar")
+
+		_, err := v1.Copy(context.Background(), opts, reg, nil)
+		require.NoError(t, err)
+
+		assertTarballContainsOnlyDistributableLayers(opts.ToTar, t)
+	})
+
+	t.Run("When IncludeNonDistributable is set the tarball should contain every layer", func(t *testing.T) {
+		opts := opts
+		opts.IncludeNonDistributable = true
+		opts.ToTar = filepath.Join(t.TempDir(), "image.tar")
+
+		_, err := v1.Copy(context.Background(), opts, reg, nil)
+		require.NoError(t, err)
+
+		assertTarballContainsEveryLayer(t, opts.ToTar)
+	})
+}
+
+func TestCopyToTarImageIndex(t *testing.T) {
+	imageName := "library/image"
+	fakeRegistry := helpers.NewFakeRegistry(t)
+	fakeRegistry.WithARandomImageIndex(imageName)
+	defer fakeRegistry.CleanUp()
+
+	opts := v1.CopyOpts{ImageRef: fakeRegistry.ReferenceOnTestServer(imageName)}
+	reg := fakeRegistry.Build()
+
+	t.Run("Tar should contain every layer", func(t *testing.T) {
+		opts := opts
+		opts.ToTar = filepath.Join(t.TempDir(), "index.tar")
+
+		_, err := v1.Copy(context.Background(), opts, reg, nil)
+		require.NoError(t, err)
+
+		assertTarballContainsEveryLayer(t, opts.ToTar)
+	})
+
+	t.Run("When IncludeNonDistributable is set the tarball should contain every layer", func(t *testing.T) {
+		opts := opts
+		opts.IncludeNonDistributable = true
+		opts.ToTar = filepath.Join(t.TempDir(), "index.tar")
+
+		result, err := v1.Copy(context.Background(), opts, reg, nil)
+		require.NoError(t, err)
+
+		assertTarballContainsEveryLayer(t, opts.ToTar)
+		assert.Empty(t, result.NonDistributableLayers)
+	})
+}
+
+func TestCopyFromTar(t *testing.T) {
+	fakeRegistry := helpers.NewFakeRegistry(t)
+	defer fakeRegistry.CleanUp()
+	image := fakeRegistry.WithRandomImage("library/image")
+	bundle := fakeRegistry.WithBundleFromPath("library/bundle", "test_assets/bundle").
+		WithImageRefs([]lockconfig.ImageRef{{Image: image.RefDigest}})
+	reg := fakeRegistry.Build()
+
+	tarPath := filepath.Join(t.TempDir(), "bundle.tar")
+	_, err := v1.Copy(context.Background(), v1.CopyOpts{BundleRef: bundle.RefDigest, ToTar: tarPath}, reg, nil)
+	require.NoError(t, err)
+
+	t.Run("imports the tarball into every repository", func(t *testing.T) {
+		destinationRepos := []string{
+			fakeRegistry.ReferenceOnTestServer("library/copied-from-tar1"),
+			fakeRegistry.ReferenceOnTestServer("library/copied-from-tar2"),
+		}
+		logger := &recordingLogger{}
+
+		result, err := v1.Copy(context.Background(), v1.CopyOpts{TarPath: tarPath, ToRepos: destinationRepos}, reg, logger)
+		require.NoError(t, err)
+		require.Len(t, result.Repos, 2)
+
+		for i, repoResult := range result.Repos {
+			require.NoError(t, repoResult.Err)
+			assert.Equal(t, destinationRepos[i], repoResult.Repo)
+			assert.ElementsMatch(t, []v1.CopiedImage{
+				{SourceDigestRef: bundle.RefDigest, DigestRef: destinationRepos[i] + "@" + bundle.Digest, IsBundle: true},
+				{SourceDigestRef: image.RefDigest, DigestRef: destinationRepos[i] + "@" + image.Digest},
+			}, repoResult.Images)
+		}
+		assert.Contains(t, logger.String(), "importing 2 images...")
+	})
+
+	t.Run("when importing into one repository fails, it still imports into the others", func(t *testing.T) {
+		destinationRepo := fakeRegistry.ReferenceOnTestServer("library/copied-from-tar")
+
+		result, err := v1.Copy(context.Background(), v1.CopyOpts{TarPath: tarPath, ToRepos: []string{"Invalid Repo", destinationRepo}}, reg, nil)
+		require.NoError(t, err)
+		require.Len(t, result.Repos, 2)
+
+		require.Error(t, result.Repos[0].Err)
+		assert.Contains(t, result.Repos[0].Err.Error(), "Building import repository ref")
+		assert.Empty(t, result.Repos[0].Images)
+
+		require.NoError(t, result.Repos[1].Err)
+		assert.Len(t, result.Repos[1].Images, 2)
+	})
+}
+
+func TestCopyToRepoBundleContainingANestedBundle(t *testing.T) {
+	bundleName := "library/bundle"
+	fakeRegistry := helpers.NewFakeRegistry(t)
+	defer fakeRegistry.CleanUp()
+	randomImage := fakeRegistry.WithRandomImage("library/image_with_config")
+	randomImage2 := fakeRegistry.WithRandomImage("library/image_with_config_2")
+
+	bundleWithTwoImages := fakeRegistry.WithBundleFromPath(bundleName, "test_assets/bundle_with_mult_images").
+		WithImageRefs([]lockconfig.ImageRef{
+			{Image: randomImage.RefDigest},
+			{Image: randomImage2.RefDigest},
+		})
+
+	bundleWithNestedBundle := fakeRegistry.WithBundleFromPath("library/bundle-with-nested-bundle",
+		"test_assets/bundle_with_mult_images").WithImageRefs([]lockconfig.ImageRef{
+		{Image: bundleWithTwoImages.RefDigest},
+	})
+
+	reg := fakeRegistry.Build()
+	destRepo := fakeRegistry.ReferenceOnTestServer("library/bundle-copy")
+	expectedImages := []v1.CopiedImage{
+		{SourceDigestRef: bundleWithNestedBundle.RefDigest, DigestRef: destRepo + "@" + bundleWithNestedBundle.Digest, IsBundle: true},
+		{SourceDigestRef: bundleWithTwoImages.RefDigest, DigestRef: destRepo + "@" + bundleWithTwoImages.Digest, IsBundle: true},
+		{SourceDigestRef: randomImage.RefDigest, DigestRef: destRepo + "@" + randomImage.Digest},
+		{SourceDigestRef: randomImage2.RefDigest, DigestRef: destRepo + "@" + randomImage2.Digest},
+	}
+
+	t.Run("it copies every image to repo", func(t *testing.T) {
+		opts := v1.CopyOpts{BundleRef: bundleWithNestedBundle.RefDigest, ToRepos: []string{destRepo}}
+
+		result, err := v1.Copy(context.Background(), opts, reg, nil)
+		require.NoError(t, err)
+
+		require.Len(t, result.Repos, 1)
+		require.NoError(t, result.Repos[0].Err)
+		assert.ElementsMatch(t, expectedImages, result.Repos[0].Images)
+	})
+
+	t.Run("When a bundle lock is provided, it copies every image to repo", func(t *testing.T) {
+		bundleLock, err := lockconfig.NewBundleLockFromBytes([]byte(fmt.Sprintf(`
+apiVersion: imgpkg.carvel.dev/v1alpha1
+kind: BundleLock
+bundle:
+  image: %s
+`, bundleWithNestedBundle.RefDigest)))
+		require.NoError(t, err)
+
+		opts := v1.CopyOpts{BundleLock: &bundleLock, ToRepos: []string{destRepo}}
+
+		result, err := v1.Copy(context.Background(), opts, reg, nil)
+		require.NoError(t, err)
+
+		require.Len(t, result.Repos, 1)
+		require.NoError(t, result.Repos[0].Err)
+		assert.ElementsMatch(t, expectedImages, result.Repos[0].Images)
+	})
+
+	t.Run("When an images lock is provided, it returns an error", func(t *testing.T) {
+		imagesLock, err := lockconfig.NewImagesLockFromBytes([]byte(fmt.Sprintf(`
+apiVersion: imgpkg.carvel.dev/v1alpha1
+kind: ImagesLock
+images:
+- image: %s
+`, bundleWithNestedBundle.RefDigest)))
+		require.NoError(t, err)
+
+		opts := v1.CopyOpts{ImagesLock: &imagesLock, ToRepos: []string{destRepo}}
+
+		_, err = v1.Copy(context.Background(), opts, reg, nil)
+		require.Error(t, err)
+		assert.EqualError(t, err, "Unable to copy bundles using an Images Lock file (hint: Create a bundle with these images)")
+	})
+
+	t.Run("When the bundle is a plain image, it returns ErrIsNotBundle", func(t *testing.T) {
+		opts := v1.CopyOpts{BundleRef: randomImage.RefDigest, ToRepos: []string{destRepo}}
+
+		_, err := v1.Copy(context.Background(), opts, reg, nil)
+		require.Error(t, err)
+		assert.True(t, v1.IsNotBundleError(err), "expected not bundle error, got: %s", err)
+	})
+}
+
+func TestCopyToRepoBundleContainingANestedBundleWithTagRefs(t *testing.T) {
+	fakeRegistry := helpers.NewFakeRegistry(t)
+	defer fakeRegistry.CleanUp()
+	image1 := fakeRegistry.WithImageFromPath("library/image1", "test_assets/image_with_config", map[string]string{})
+	image2 := fakeRegistry.WithRandomImage("library/image2")
+
+	innerBundle := fakeRegistry.WithBundleFromPath("library/inner-bundle", createBundleDir(t, []lockconfig.ImageRef{
+		{Image: fakeRegistry.ReferenceOnTestServer("library/image1:latest")},
+	}))
+	outerBundle := fakeRegistry.WithBundleFromPath("library/outer-bundle", createBundleDir(t, []lockconfig.ImageRef{
+		{Image: innerBundle.RefDigest},
+		{Image: image2.RefDigest},
+	}))
+	reg := fakeRegistry.Build()
+
+	t.Run("it pins the nested bundle and the bundle referencing it to images copied to the repo", func(t *testing.T) {
+		destRepo := fakeRegistry.ReferenceOnTestServer("library/copied-nested")
+		opts := v1.CopyOpts{BundleRef: outerBundle.RefDigest, ToRepos: []string{destRepo}}
+
+		result, err := v1.Copy(context.Background(), opts, reg, nil)
+		require.NoError(t, err)
+		require.NoError(t, result.Repos[0].Err)
+
+		copiedImages := map[string]v1.CopiedImage{}
+		for _, copiedImage := range result.Repos[0].Images {
+			copiedImages[copiedImage.SourceDigestRef] = copiedImage
+		}
+		require.Len(t, copiedImages, 4)
+
+		copiedInnerBundle := copiedImages[innerBundle.RefDigest]
+		assert.True(t, copiedInnerBundle.IsBundle)
+		assert.NotEqual(t, destRepo+"@"+innerBundle.Digest, copiedInnerBundle.DigestRef, "expected nested bundle to be rewritten")
+
+		copiedOuterBundle := copiedImages[outerBundle.RefDigest]
+		assert.True(t, copiedOuterBundle.IsBundle)
+		assert.NotEqual(t, destRepo+"@"+outerBundle.Digest, copiedOuterBundle.DigestRef, "expected bundle referencing the rewritten bundle to be rewritten")
+
+		outerImagesLock, hasTagRefs, err := bundle.NewBundle(copiedOuterBundle.DigestRef, reg).ResolvedImagesLock(context.Background())
+		require.NoError(t, err)
+		assert.False(t, hasTagRefs)
+		assert.Equal(t, []lockconfig.ImageRef{
+			{Image: copiedInnerBundle.DigestRef},
+			{Image: destRepo + "@" + image2.Digest},
+		}, outerImagesLock.Images)
+
+		innerImagesLock, hasTagRefs, err := bundle.NewBundle(copiedInnerBundle.DigestRef, reg).ResolvedImagesLock(context.Background())
+		require.NoError(t, err)
+		assert.False(t, hasTagRefs)
+		assert.Equal(t, []lockconfig.ImageRef{
+			{Image: destRepo + "@" + image1.Digest, Tag: "latest"},
+		}, innerImagesLock.Images)
+
+		for _, imageRef := range append(outerImagesLock.Images, innerImagesLock.Images...) {
+			_, err := reg.Digest(context.Background(), mustParseReference(t, imageRef.Image))
+			assert.NoError(t, err, "expected '%s' to be copied", imageRef.Image)
+		}
+	})
+
+	t.Run("when copying to a tar, it errors", func(t *testing.T) {
+		opts := v1.CopyOpts{BundleRef: outerBundle.RefDigest, ToTar: filepath.Join(t.TempDir(), "bundle.tar")}
+
+		_, err := v1.Copy(context.Background(), opts, reg, nil)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), fmt.Sprintf("Expected images referenced by bundle '%s' to use digests when copying to a tar", innerBundle.RefDigest))
+		assert.NoFileExists(t, opts.ToTar)
+	})
+}
+
+func TestCopyToRepoBundleWithMultipleRegistries(t *testing.T) {
+	fakeDockerhubRegistry := helpers.NewFakeRegistry(t)
+	defer fakeDockerhubRegistry.CleanUp()
+	fakePrivateRegistry := helpers.NewFakeRegistry(t)
+	defer fakePrivateRegistry.CleanUp()
+
+	sourceBundleName := "library/bundle"
+	destinationBundleName := "library/copied-bundle"
+
+	randomImage1FromDockerhub := fakeDockerhubRegistry.WithRandomImage("random-image1")
+	fakePrivateRegistry.WithImage(sourceBundleName, randomImage1FromDockerhub.Image)
+
+	// test_assets/bundle contains images that live in dockerhub
+	bundleWithImageRefsToDockerhub := fakePrivateRegistry.WithBundleFromPath(sourceBundleName,
+		"test_assets/bundle_with_dockerhub_images").WithImageRefs([]lockconfig.ImageRef{
+		{Image: randomImage1FromDockerhub.RefDigest},
+	})
+
+	reg := fakePrivateRegistry.Build()
+	fakeDockerhubRegistry.Build()
+
+	assertCopiedFromPrivateRegistry := func(t *testing.T, result v1.CopyResult) {
+		require.Len(t, result.Repos, 1)
+		require.NoError(t, result.Repos[0].Err, "expected copy to succeed")
+		require.Len(t, result.Repos[0].Images, 2)
+		for _, copiedImage := range result.Repos[0].Images {
+			assert.Contains(t, copiedImage.SourceDigestRef, fakePrivateRegistry.ReferenceOnTestServer(sourceBundleName))
+		}
+	}
+
+	t.Run("Images are copied from fake-registry and not from the bundle's ImagesLockFile registry (index.docker.io)", func(t *testing.T) {
+		opts := v1.CopyOpts{
+			BundleRef: bundleWithImageRefsToDockerhub.RefDigest,
+			ToRepos:   []string{fakePrivateRegistry.ReferenceOnTestServer(destinationBundleName)},
+		}
+
+		result, err := v1.Copy(context.Background(), opts, reg, nil)
+		require.NoError(t, err)
+		assertCopiedFromPrivateRegistry(t, result)
+	})
+
+	t.Run("Using a BundleLock, Images are copied from fake-registry and not from the bundle's ImagesLockFile registry (index.docker.io)", func(t *testing.T) {
+		bundleLock, err := lockconfig.NewBundleLockFromBytes([]byte(fmt.Sprintf(`
+apiVersion: imgpkg.carvel.dev/v1alpha1
+kind: BundleLock
+bundle:
+  image: %s
+`, bundleWithImageRefsToDockerhub.RefDigest)))
+		require.NoError(t, err)
+
+		opts := v1.CopyOpts{
+			BundleLock: &bundleLock,
+			ToRepos:    []string{fakePrivateRegistry.ReferenceOnTestServer(destinationBundleName)},
+		}
+
+		result, err := v1.Copy(context.Background(), opts, reg, nil)
+		require.NoError(t, err)
+		assertCopiedFromPrivateRegistry(t, result)
+	})
+}
+
+func TestCopyToRepoBundleSkipsLayersAlreadyPresentInDestination(t *testing.T) {
+	fakeRegistry := helpers.NewFakeRegistry(t)
+	defer fakeRegistry.CleanUp()
+	image1 := fakeRegistry.WithRandomImage("library/image1")
+	image2 := fakeRegistry.WithRandomImage("library/image2")
+	bundle := fakeRegistry.WithBundleFromPath("library/bundle", "test_assets/bundle").
+		WithImageRefs([]lockconfig.ImageRef{{Image: image1.RefDigest}, {Image: image2.RefDigest}})
+
+	// Destination is a different registry, so that blobs are not shared with the source
+	var uploadedBlobs []string
+	var uploadedBlobsLock sync.Mutex
+	destinationHost := newRecordingRegistryServer(t, func(request *http.Request) {
+		if request.Method == http.MethodPut && request.URL.Query().Get("digest") != "" {
+			uploadedBlobsLock.Lock()
+			uploadedBlobs = append(uploadedBlobs, request.URL.Query().Get("digest"))
+			uploadedBlobsLock.Unlock()
+		}
+	})
+	destinationRepo := destinationHost + "/library/copied-bundle"
+
+	image1Layers, err := image1.Image.Layers()
+	require.NoError(t, err)
+	var image1LayersDigests []string
+	for _, layer := range image1Layers {
+		repo, err := name.NewRepository(destinationRepo)
+		require.NoError(t, err)
+		require.NoError(t, regremote.WriteLayer(repo, layer))
+
+		digest, err := layer.Digest()
+		require.NoError(t, err)
+		image1LayersDigests = append(image1LayersDigests, digest.String())
+	}
+	uploadedBlobs = nil
+
+	opts := v1.CopyOpts{BundleRef: bundle.RefDigest, ToRepos: []string{destinationRepo}}
+	reg := fakeRegistry.Build()
+
+	t.Run("layers already present in the destination are not uploaded again", func(t *testing.T) {
+		logger := &recordingLogger{}
+
+		result, err := v1.Copy(context.Background(), opts, reg, logger)
+		require.NoError(t, err)
+		require.NoError(t, result.Repos[0].Err)
+
+		// 3 layers from each image, the bundle layer is imported after the images
+		assert.Contains(t, logger.String(), "3 of 6 layers already present")
+		assert.Contains(t, logger.String(), "0 of 1 layers already present")
+		assert.NotEmpty(t, uploadedBlobs, "expected the remaining layers to be uploaded")
+		for _, digest := range image1LayersDigests {
+			assert.NotContains(t, uploadedBlobs, digest)
+		}
+	})
+
+	t.Run("when every image was already copied, it skips them", func(t *testing.T) {
+		logger := &recordingLogger{}
+		uploadedBlobs = nil
+
+		result, err := v1.Copy(context.Background(), opts, reg, logger)
+		require.NoError(t, err)
+		require.NoError(t, result.Repos[0].Err)
+
+		assert.Contains(t, logger.String(), "6 of 6 layers already present")
+		assert.Contains(t, logger.String(), "1 of 1 layers already present")
+		assert.Contains(t, logger.String(), "skipping "+bundle.RefDigest)
+		assert.Empty(t, uploadedBlobs)
+	})
+}
+
+func TestCopyToMultipleRepos(t *testing.T) {
+	var sourceBlobsFetched []string
+	var sourceBlobsFetchedLock sync.Mutex
+	sourceHost := newRecordingRegistryServer(t, func(request *http.Request) {
+		if request.Method == http.MethodGet && strings.Contains(request.URL.Path, "/blobs/") {
+			sourceBlobsFetchedLock.Lock()
+			sourceBlobsFetched = append(sourceBlobsFetched, request.URL.Path)
+			sourceBlobsFetchedLock.Unlock()
+		}
+	})
+
+	img, err := random.Image(500, 3)
+	require.NoError(t, err)
+	imgDigest, err := img.Digest()
+	require.NoError(t, err)
+
+	srcRef, err := name.NewDigest(sourceHost + "/library/image@" + imgDigest.String())
+	require.NoError(t, err)
+	require.NoError(t, regremote.Write(srcRef, img))
+
+	reg, err := registry.NewRegistry(registry.Opts{})
+	require.NoError(t, err)
+
+	destinationRepos := []string{
+		newRecordingRegistryServer(t, func(*http.Request) {}) + "/library/copied-image",
+		newRecordingRegistryServer(t, func(*http.Request) {}) + "/library/copied-image",
+	}
+
+	t.Run("copies the image into every destination fetching the source layers once", func(t *testing.T) {
+		sourceBlobsFetched = nil
+
+		result, err := v1.Copy(context.Background(), v1.CopyOpts{ImageRef: srcRef.Name(), ToRepos: destinationRepos}, reg, nil)
+		require.NoError(t, err)
+		require.Len(t, result.Repos, 2)
+
+		for i, repoResult := range result.Repos {
+			require.NoError(t, repoResult.Err)
+			assert.Equal(t, destinationRepos[i], repoResult.Repo)
+			assert.Equal(t, []v1.CopiedImage{{
+				SourceDigestRef: srcRef.Name(),
+				DigestRef:       destinationRepos[i] + "@" + imgDigest.String(),
+			}}, repoResult.Images)
+
+			destinationDigest, err := reg.Digest(context.Background(), mustParseReference(t, destinationRepos[i]+"@"+imgDigest.String()))
+			require.NoError(t, err)
+			assert.Equal(t, imgDigest, destinationDigest)
+		}
+
+		layers, err := img.Layers()
+		require.NoError(t, err)
+		for _, layer := range layers {
+			layerDigest, err := layer.Digest()
+			require.NoError(t, err)
+
+			var fetched int
+			for _, path := range sourceBlobsFetched {
+				if strings.HasSuffix(path, "/blobs/"+layerDigest.String()) {
+					fetched++
+				}
+			}
+			assert.Equal(t, 1, fetched, "expected layer %s to be fetched from source once", layerDigest)
+		}
+	})
+
+	t.Run("when copying to one destination fails, it still copies to the others", func(t *testing.T) {
+		result, err := v1.Copy(context.Background(), v1.CopyOpts{ImageRef: srcRef.Name(), ToRepos: []string{"Invalid Repo", destinationRepos[0]}}, reg, nil)
+		require.NoError(t, err)
+		require.Len(t, result.Repos, 2)
+
+		require.Error(t, result.Repos[0].Err)
+		assert.Contains(t, result.Repos[0].Err.Error(), "Building import repository ref")
+
+		require.NoError(t, result.Repos[1].Err)
+		require.Len(t, result.Repos[1].Images, 1)
+	})
+}
+
+func TestCopyToRepoImage(t *testing.T) {
+	imageName := "library/image"
+	fakeRegistry := helpers.NewFakeRegistry(t)
+	image1 := fakeRegistry.WithImageFromPath(imageName, "test_assets/image_with_config", map[string]string{})
+	defer fakeRegistry.CleanUp()
+
+	t.Run("When IncludeNonDistributable is set and there are no non-distributable layers, none are returned", func(t *testing.T) {
+		reg := fakeRegistry.Build()
+		opts := v1.CopyOpts{
+			ImageRef:                fakeRegistry.ReferenceOnTestServer(imageName),
+			ToRepos:                 []string{fakeRegistry.ReferenceOnTestServer("fakeregistry/some-repo")},
+			IncludeNonDistributable: true,
+		}
+
+		result, err := v1.Copy(context.Background(), opts, reg, nil)
+		require.NoError(t, err)
+		require.NoError(t, result.Repos[0].Err)
+		assert.Empty(t, result.NonDistributableLayers)
+	})
+
+	t.Run("When an ImagesLock is provided it should copy every image from the lock", func(t *testing.T) {
+		destinationRepo := fakeRegistry.ReferenceOnTestServer("library/copied-img")
+
+		image2RefDigest := fakeRegistry.WithRandomImage("library/image-2").RefDigest
+		imagesLock, err := lockconfig.NewImagesLockFromBytes([]byte(fmt.Sprintf(`apiVersion: imgpkg.carvel.dev/v1alpha1
+kind: ImagesLock
+images:
+- image: %s
+  annotations:
+    my-annotation: first-image
+- image: %s
+  annotations:
+    my-annotation: second-image
+`, image1.RefDigest, image2RefDigest)))
+		require.NoError(t, err)
+
+		reg := fakeRegistry.Build()
+
+		result, err := v1.Copy(context.Background(), v1.CopyOpts{ImagesLock: &imagesLock, ToRepos: []string{destinationRepo}}, reg, nil)
+		require.NoError(t, err)
+		require.NoError(t, result.Repos[0].Err)
+
+		require.Len(t, result.Repos[0].Images, 2)
+		assert.Equal(t, image1.RefDigest, result.Repos[0].Images[1].SourceDigestRef)
+		assert.Equal(t, image2RefDigest, result.Repos[0].Images[0].SourceDigestRef)
+	})
+
+	t.Run("When PreserveTags is set it applies every source tag pointing to the image to the destination", func(t *testing.T) {
+		reg := fakeRegistry.Build()
+
+		srcImage, err := regremote.Image(mustParseReference(t, image1.RefDigest))
+		require.NoError(t, err)
+		require.NoError(t, regremote.Tag(mustParseTag(t, fakeRegistry.ReferenceOnTestServer(imageName+":v1")), srcImage))
+
+		otherImage, err := random.Image(500, 1)
+		require.NoError(t, err)
+		require.NoError(t, regremote.Write(mustParseTag(t, fakeRegistry.ReferenceOnTestServer(imageName+":other")), otherImage))
+
+		destinationRepo := fakeRegistry.ReferenceOnTestServer("library/preserved-tags")
+		opts := v1.CopyOpts{ImageRef: image1.RefDigest, ToRepos: []string{destinationRepo}, PreserveTags: true}
+
+		result, err := v1.Copy(context.Background(), opts, reg, nil)
+		require.NoError(t, err)
+		require.NoError(t, result.Repos[0].Err)
+
+		for _, tag := range []string{"latest", "v1"} {
+			digest, err := reg.Digest(context.Background(), mustParseTag(t, destinationRepo+":"+tag))
+			require.NoError(t, err)
+			assert.Equal(t, image1.Digest, digest.String(), "expected tag '%s' to point to the copied image", tag)
+		}
+
+		_, err = reg.Digest(context.Background(), mustParseTag(t, destinationRepo+":other"))
+		assert.Error(t, err, "expected tag pointing to a different image to not be preserved")
+	})
+
+	t.Run("When PreserveTags is set without an image or bundle, it returns an error", func(t *testing.T) {
+		reg := fakeRegistry.Build()
+		opts := v1.CopyOpts{TarPath: "image.tar", ToRepos: []string{fakeRegistry.ReferenceOnTestServer("library/preserved-tags")}, PreserveTags: true}
+
+		_, err := v1.Copy(context.Background(), opts, reg, nil)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "Expected tags to only be preserved when copying an image or a bundle")
+	})
+}
+
+func TestCopyToRepoImageContainingNonDistributableLayers(t *testing.T) {
+	fakeRegistry := helpers.NewFakeRegistry(t)
+	defer fakeRegistry.CleanUp()
+	imageWithNonDistributableLayer := fakeRegistry.WithRandomImage("library/image").WithNonDistributableLayer()
+	layerDigest := nonDistributableLayerDigest(t, imageWithNonDistributableLayer)
+
+	// Blobs in the fake registry are shared across repositories, so a second registry is needed
+	// to tell whether the non-distributable layer was uploaded to the destination
+	destinationRegistry := helpers.NewFakeRegistry(t)
+	defer destinationRegistry.CleanUp()
+	destinationRegistry.Build()
+
+	t.Run("When IncludeNonDistributable is not set, the non-distributable layer is not copied", func(t *testing.T) {
+		reg := fakeRegistry.Build()
+
+		destRepo := destinationRegistry.ReferenceOnTestServer("library/without-non-distributable")
+		opts := v1.CopyOpts{ImageRef: imageWithNonDistributableLayer.RefDigest, ToRepos: []string{destRepo}}
+
+		result, err := v1.Copy(context.Background(), opts, reg, nil)
+		require.NoError(t, err)
+		require.NoError(t, result.Repos[0].Err)
+
+		assert.False(t, doesLayerExistInRepo(t, destRepo, layerDigest), "expected non-distributable layer to not be copied")
+		assert.Equal(t, []string{layerDigest}, result.NonDistributableLayers)
+	})
+
+	t.Run("When IncludeNonDistributable is set, the non-distributable layer is copied", func(t *testing.T) {
+		fakeRegistry.Build()
+		reg, err := registry.NewRegistry(registry.Opts{IncludeNonDistributableLayers: true})
+		require.NoError(t, err)
+
+		destRepo := destinationRegistry.ReferenceOnTestServer("library/with-non-distributable")
+		opts := v1.CopyOpts{ImageRef: imageWithNonDistributableLayer.RefDigest, ToRepos: []string{destRepo}, IncludeNonDistributable: true}
+
+		result, err := v1.Copy(context.Background(), opts, reg, nil)
+		require.NoError(t, err)
+		require.NoError(t, result.Repos[0].Err)
+
+		assert.True(t, doesLayerExistInRepo(t, destRepo, layerDigest), "expected non-distributable layer to be copied")
+		assert.Equal(t, []string{layerDigest}, result.NonDistributableLayers)
+	})
+}
+
+func assertTarballContainsEveryLayer(t *testing.T, imageTarPath string) {
+	path := imagetar.NewTarReader(imageTarPath)
+	imageOrIndex, err := path.Read()
+	require.NoError(t, err)
+
+	for _, imageInManifest := range imageOrIndex {
+		layers, err := (*imageInManifest.Image).Layers()
+		require.NoError(t, err)
+
+		for _, layer := range layers {
+			digest, err := layer.Digest()
+			require.NoError(t, err)
+
+			assert.Truef(t, doesLayerExistInTarball(t, imageTarPath, digest), "did not find the expected layer [%s]",
+				digest)
+		}
+	}
+}
+
+func assertTarballContainsOnlyDistributableLayers(imageTarPath string, t *testing.T) {
+	path := imagetar.NewTarReader(imageTarPath)
+	imageOrIndex, err := path.Read()
+	if err != nil {
+		t.Fatalf("Expected to read the image tar: %s", err)
+	}
+
+	for _, imageInManifest := range imageOrIndex {
+		layers, err := (*imageInManifest.Image).Layers()
+		if err != nil {
+			t.Fatalf("Expected image tar to contain layers: %s", err)
+		}
+
+		for _, layer := range layers {
+			mediaType, err := layer.MediaType()
+			if err != nil {
+				t.Fatalf(err.Error())
+			}
+
+			digest, err := layer.Digest()
+			if err != nil {
+				t.Fatalf("Expected generating a digest from a layer to succeed got: %s", err)
+			}
+
+			if doesLayerExistInTarball(t, imageTarPath, digest) && !mediaType.IsDistributable() {
+				t.Fatalf("Expected to fail. The foreign layer was found in the tarball when we expected it not to")
+			}
+		}
+	}
+
+}
+
+func doesLayerExistInTarball(t *testing.T, path string, digest regv1.Hash) bool {
+	filePathInTar := digest.Algorithm + "-" + digest.Hex + ".tar.gz"
+	file, err := os.Open(path)
+	require.NoError(t, err)
+	defer file.Close()
+
+	tf := tar.NewReader(file)
+	for {
+		hdr, err := tf.Next()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		if hdr.Name == filePathInTar {
+			return true
+		}
+	}
+	return false
+}
+
+func doesLayerExistInRepo(t *testing.T, repo string, digest string) bool {
+	layerRef, err := name.NewDigest(repo + "@" + digest)
+	require.NoError(t, err)
+
+	layer, err := regremote.Layer(layerRef)
+	require.NoError(t, err)
+
+	layerStream, err := layer.Compressed()
+	if err != nil {
+		return false
+	}
+	defer layerStream.Close()
+
+	_, err = io.Copy(ioutil.Discard, layerStream)
+	return err == nil
+}
+
+func nonDistributableLayerDigest(t *testing.T, img *helpers.ImageOrImageIndexWithTarPath) string {
+	layers, err := img.Image.Layers()
+	require.NoError(t, err)
+
+	for _, layer := range layers {
+		mediaType, err := layer.MediaType()
+		require.NoError(t, err)
+
+		if !mediaType.IsDistributable() {
+			digest, err := layer.Digest()
+			require.NoError(t, err)
+			return digest.String()
+		}
+	}
+
+	t.Fatalf("Expected image to contain a non-distributable layer")
+	return ""
+}
+
+// createBundleDir returns a bundle directory whose Images Lock lists imageRefs (tag refs included)
+func createBundleDir(t *testing.T, imageRefs []lockconfig.ImageRef) string {
+	bundleDir := t.TempDir()
+	imagesLock := lockconfig.ImagesLock{
+		LockVersion: lockconfig.LockVersion{APIVersion: lockconfig.ImagesLockAPIVersion, Kind: lockconfig.ImagesLockKind},
+		Images:      imageRefs,
+	}
+
+	imagesLockBytes, err := yaml.Marshal(imagesLock)
+	require.NoError(t, err)
+
+	require.NoError(t, os.MkdirAll(filepath.Join(bundleDir, ".imgpkg"), 0700))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(bundleDir, ".imgpkg", "images.yml"), imagesLockBytes, 0600))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(bundleDir, "config.yml"), []byte("key: value\n"), 0600))
+	return bundleDir
+}
+
+func mustParseReference(t *testing.T, ref string) name.Reference {
+	parsedRef, err := name.ParseReference(ref)
+	require.NoError(t, err)
+	return parsedRef
+}
+
+func newRecordingRegistryServer(t *testing.T, record func(*http.Request)) string {
+	handler := regregistry.New(regregistry.Logger(log.New(io.Discard, "", 0)))
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		record(request)
+		handler.ServeHTTP(writer, request)
+	}))
+	t.Cleanup(server.Close)
+
+	serverURL, err := url.Parse(server.URL)
+	require.NoError(t, err)
+	return serverURL.Host
+}
@@ -0,0 +1,30 @@
+// Copyright 2021 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package v1
+
+// ErrIsBundle is returned when an image operation is performed on a bundle
+type ErrIsBundle struct{}
+
+func (e ErrIsBundle) Error() string {
+	return "Expected image but found bundle"
+}
+
+// ErrIsNotBundle is returned when a bundle operation is performed on a plain image
+type ErrIsNotBundle struct{}
+
+func (e ErrIsNotBundle) Error() string {
+	return "Expected bundle but found plain image"
+}
+
+// IsBundleError returns true when err was caused by providing a bundle to an image operation
+func IsBundleError(err error) bool {
+	_, ok := err.(ErrIsBundle)
+	return ok
+}
+
+// IsNotBundleError returns true when err was caused by providing a plain image to a bundle operation
+func IsNotBundleError(err error) bool {
+	_, ok := err.(ErrIsNotBundle)
+	return ok
+}
@@ -0,0 +1,50 @@
+// Copyright 2021 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package v1
+
+import (
+	"io/ioutil"
+
+	goui "github.com/cppforlife/go-cli-ui/ui"
+	ctlimg "github.com/k14s/imgpkg/pkg/imgpkg/image"
+)
+
+// Logger receives the progress messages produced by the operations in this package
+type Logger interface {
+	Logf(msg string, args ...interface{})
+}
+
+// loggerUI adapts a Logger to the ui.UI used internally,
+// any other interaction with the user is discarded
+type loggerUI struct {
+	goui.UI
+	logger Logger
+}
+
+func newLoggerUI(logger Logger) goui.UI {
+	if logger == nil {
+		return goui.NewNoopUI()
+	}
+	return loggerUI{UI: goui.NewNoopUI(), logger: logger}
+}
+
+func (l loggerUI) BeginLinef(msg string, args ...interface{}) { l.logger.Logf(msg, args...) }
+func (l loggerUI) PrintLinef(msg string, args ...interface{}) { l.logger.Logf(msg+"\n", args...) }
+
+// loggerWriter forwards what is written by the loggers used internally to a Logger
+type loggerWriter struct {
+	logger Logger
+}
+
+func (w loggerWriter) Write(data []byte) (int, error) {
+	w.logger.Logf("%s", data)
+	return len(data), nil
+}
+
+func newPrefixedLogger(logger Logger) *ctlimg.LoggerPrefixWriter {
+	if logger == nil {
+		return ctlimg.NewLogger(ioutil.Discard).NewPrefixedWriter("")
+	}
+	return ctlimg.NewLogger(loggerWriter{logger}).NewPrefixedWriter("")
+}
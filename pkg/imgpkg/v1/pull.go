@@ -0,0 +1,93 @@
+// Copyright 2021 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package v1
+
+import (
+	"context"
+	"path/filepath"
+
+	"github.com/k14s/imgpkg/pkg/imgpkg/bundle"
+	"github.com/k14s/imgpkg/pkg/imgpkg/lockconfig"
+	"github.com/k14s/imgpkg/pkg/imgpkg/plainimage"
+	"github.com/k14s/imgpkg/pkg/imgpkg/registry"
+)
+
+// PullOpts contains the options used when pulling a bundle
+type PullOpts struct {
+	// Recursive also pulls every bundle referenced by the bundle
+	Recursive bool
+	// OutputChecksums writes the sha256 of every extracted file
+	// into .imgpkg/checksums.txt (see VerifyChecksums)
+	OutputChecksums bool
+}
+
+// PullResult describes the pulled image or bundle
+type PullResult struct {
+	// DigestRef is the full reference to the pulled image, e.g. repo@sha256:...
+	DigestRef string
+	Tag       string
+	// Images referenced by the bundle's .imgpkg/images.yml as written
+	// into the output directory (bundles only)
+	Images []string
+}
+
+// PullImage extracts the contents of the plain image ref into outputPath,
+// registry requests are aborted once ctx is done
+func PullImage(ctx context.Context, ref string, outputPath string, reg registry.Registry, logger Logger) (PullResult, error) {
+	plainImg := plainimage.NewPlainImage(ref, reg)
+
+	isBundle, err := bundle.NewBundleFromPlainImage(plainImg, reg).IsBundle(ctx)
+	if err != nil {
+		return PullResult{}, err
+	}
+	if isBundle {
+		return PullResult{}, ErrIsBundle{}
+	}
+
+	err = plainImg.Pull(ctx, outputPath, newLoggerUI(logger))
+	if err != nil {
+		return PullResult{}, err
+	}
+
+	return PullResult{DigestRef: plainImg.DigestRef(), Tag: plainImg.Tag()}, nil
+}
+
+// PullBundle extracts the contents of the bundle ref into outputPath,
+// registry requests are aborted once ctx is done
+func PullBundle(ctx context.Context, ref string, outputPath string, opts PullOpts, reg registry.Registry, logger Logger) (PullResult, error) {
+	pulledBundle := bundle.NewBundle(ref, reg)
+
+	err := pulledBundle.Pull(ctx, outputPath, newLoggerUI(logger), opts.Recursive)
+	if err != nil {
+		if bundle.IsNotBundleError(err) {
+			return PullResult{}, ErrIsNotBundle{}
+		}
+		return PullResult{}, err
+	}
+
+	if opts.OutputChecksums {
+		err = bundle.WriteChecksums(ctx, outputPath)
+		if err != nil {
+			return PullResult{}, err
+		}
+	}
+
+	imagesLock, err := lockconfig.NewImagesLockFromPath(filepath.Join(outputPath, bundle.ImgpkgDir, bundle.ImagesLockFile))
+	if err != nil {
+		return PullResult{}, err
+	}
+
+	result := PullResult{DigestRef: pulledBundle.DigestRef(), Tag: pulledBundle.Tag(), Images: []string{}}
+	for _, img := range imagesLock.Images {
+		result.Images = append(result.Images, img.Image)
+	}
+
+	return result, nil
+}
+
+// VerifyChecksums compares the files of a directory pulled with
+// OutputChecksums with the checksums recorded at that time, it stops once ctx is done
+func VerifyChecksums(ctx context.Context, outputPath string) (bundle.ChecksumsDiff, error) {
+	return bundle.VerifyChecksums(ctx, outputPath)
+}
@@ -0,0 +1,99 @@
+// Copyright 2021 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package v1_test
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	v1 "github.com/k14s/imgpkg/pkg/imgpkg/v1"
+	"github.com/k14s/imgpkg/test/helpers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPullImage(t *testing.T) {
+	fakeRegistry := helpers.NewFakeRegistry(t)
+	defer fakeRegistry.CleanUp()
+	reg := fakeRegistry.Build()
+
+	assetsDir := createAssetsDir(t, map[string]string{"config.yml": "key: value\n"})
+	image, err := v1.PushImage(context.Background(), fakeRegistry.ReferenceOnTestServer("repo/image"), v1.PushOpts{Paths: []string{assetsDir}}, reg, nil)
+	require.NoError(t, err)
+
+	bundleDir := createAssetsDir(t, map[string]string{".imgpkg/images.yml": emptyImagesYaml})
+	bundle, err := v1.PushBundle(context.Background(), fakeRegistry.ReferenceOnTestServer("repo/bundle"), v1.PushOpts{Paths: []string{bundleDir}}, reg, nil)
+	require.NoError(t, err)
+
+	t.Run("extracts the image contents into the output directory", func(t *testing.T) {
+		outputDir := createOutputDir(t)
+		logger := &recordingLogger{}
+
+		result, err := v1.PullImage(context.Background(), image.DigestRef, outputDir, reg, logger)
+		require.NoError(t, err)
+		assert.Equal(t, image.DigestRef, result.DigestRef)
+		assert.Empty(t, result.Images)
+
+		contents, err := ioutil.ReadFile(filepath.Join(outputDir, "config.yml"))
+		require.NoError(t, err)
+		assert.Equal(t, "key: value\n", string(contents))
+		assert.Contains(t, logger.String(), "Pulling image '"+image.DigestRef+"'")
+	})
+
+	t.Run("when the reference is a bundle, it returns ErrIsBundle", func(t *testing.T) {
+		_, err := v1.PullImage(context.Background(), bundle.DigestRef, createOutputDir(t), reg, nil)
+		require.Error(t, err)
+		assert.True(t, v1.IsBundleError(err), "expected bundle error, got: %s", err)
+	})
+}
+
+func TestPullBundle(t *testing.T) {
+	fakeRegistry := helpers.NewFakeRegistry(t)
+	defer fakeRegistry.CleanUp()
+	reg := fakeRegistry.Build()
+
+	assetsDir := createAssetsDir(t, map[string]string{"config.yml": "key: value\n"})
+	image, err := v1.PushImage(context.Background(), fakeRegistry.ReferenceOnTestServer("repo/image"), v1.PushOpts{Paths: []string{assetsDir}}, reg, nil)
+	require.NoError(t, err)
+
+	bundleDir := createAssetsDir(t, map[string]string{
+		".imgpkg/images.yml": fmt.Sprintf("%simages:\n- image: %s\n", emptyImagesYaml, image.DigestRef),
+		"config.yml":         "key: value\n",
+	})
+	bundle, err := v1.PushBundle(context.Background(), fakeRegistry.ReferenceOnTestServer("repo/bundle"), v1.PushOpts{Paths: []string{bundleDir}}, reg, nil)
+	require.NoError(t, err)
+
+	t.Run("extracts the bundle contents into the output directory", func(t *testing.T) {
+		outputDir := createOutputDir(t)
+		logger := &recordingLogger{}
+
+		result, err := v1.PullBundle(context.Background(), bundle.DigestRef, outputDir, v1.PullOpts{}, reg, logger)
+		require.NoError(t, err)
+		assert.Equal(t, bundle.DigestRef, result.DigestRef)
+		assert.Equal(t, []string{image.DigestRef}, result.Images)
+
+		assert.FileExists(t, filepath.Join(outputDir, ".imgpkg", "images.yml"))
+		contents, err := ioutil.ReadFile(filepath.Join(outputDir, "config.yml"))
+		require.NoError(t, err)
+		assert.Equal(t, "key: value\n", string(contents))
+		assert.Contains(t, logger.String(), "Pulling bundle '"+bundle.DigestRef+"'")
+	})
+
+	t.Run("when the reference is a plain image, it returns ErrIsNotBundle", func(t *testing.T) {
+		_, err := v1.PullBundle(context.Background(), image.DigestRef, createOutputDir(t), v1.PullOpts{}, reg, nil)
+		require.Error(t, err)
+		assert.True(t, v1.IsNotBundleError(err), "expected not bundle error, got: %s", err)
+	})
+}
+
+func createOutputDir(t *testing.T) string {
+	outputDir, err := ioutil.TempDir("", "imgpkg-v1-output")
+	require.NoError(t, err)
+	t.Cleanup(func() { os.RemoveAll(outputDir) })
+	return outputDir
+}
@@ -0,0 +1,206 @@
+// Copyright 2021 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package v1
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	regname "github.com/google/go-containerregistry/pkg/name"
+	"github.com/k14s/imgpkg/pkg/imgpkg/bundle"
+	ctlimg "github.com/k14s/imgpkg/pkg/imgpkg/image"
+	"github.com/k14s/imgpkg/pkg/imgpkg/plainimage"
+	"github.com/k14s/imgpkg/pkg/imgpkg/registry"
+)
+
+// PushOpts contains the files that will be pushed and how to push them
+type PushOpts struct {
+	Paths         []string
+	ExcludedPaths []string
+
+	// FileTar is a tar used as is as the only layer of the image
+	// instead of Paths (images only)
+	FileTar io.Reader
+
+	// ValidateImages checks that every image in the bundle's
+	// .imgpkg/images.yml exists before pushing (bundles only)
+	ValidateImages bool
+
+	// Compression of the pushed layer: gzip (default) or zstd
+	Compression string
+
+	// AdditionalTags are applied to the pushed image or bundle
+	// in addition to the tag of the provided ref
+	AdditionalTags []string
+
+	// MaxSize (in bytes) and MaxFiles abort the push when the files
+	// found in Paths exceed them, zero means that there is no limit
+	MaxSize  int64
+	MaxFiles int
+}
+
+// PushResult describes the pushed image or bundle
+type PushResult struct {
+	// DigestRef is the full reference to the pushed image, e.g. repo@sha256:...
+	DigestRef string
+	Tag       string
+	// Images referenced by the bundle's .imgpkg/images.yml (bundles only)
+	Images []string
+}
+
+// PushImage pushes the provided files as a plain image to ref,
+// registry requests are aborted once ctx is done
+func PushImage(ctx context.Context, ref string, opts PushOpts, reg registry.Registry, logger Logger) (PushResult, error) {
+	if opts.ValidateImages {
+		return PushResult{}, fmt.Errorf("Images validation is not compatible with image, use bundle for images validation")
+	}
+
+	uploadRef, err := regname.NewTag(ref, regname.WeakValidation)
+	if err != nil {
+		return PushResult{}, fmt.Errorf("Parsing '%s': %s", ref, err)
+	}
+
+	additionalTagRefs, err := newAdditionalTagRefs(uploadRef, opts.AdditionalTags)
+	if err != nil {
+		return PushResult{}, err
+	}
+
+	compression, err := ctlimg.NewCompression(opts.Compression)
+	if err != nil {
+		return PushResult{}, err
+	}
+
+	var contents plainimage.Contents
+
+	if opts.FileTar != nil {
+		if len(opts.Paths) > 0 {
+			return PushResult{}, fmt.Errorf("Expected either paths or a tar to push, but got both")
+		}
+		if opts.contentsLimits().IsSet() {
+			return PushResult{}, fmt.Errorf("Expected size and files limits to be used with paths, but got a tar")
+		}
+		contents = plainimage.NewContentsFromTar(opts.FileTar, compression)
+	} else {
+		isBundle, err := bundle.NewContents(opts.Paths, opts.ExcludedPaths, compression).PresentsAsBundle()
+		if err != nil {
+			return PushResult{}, err
+		}
+		if isBundle {
+			return PushResult{}, ErrIsBundle{}
+		}
+		contents = plainimage.NewContents(opts.Paths, opts.ExcludedPaths, compression).WithLimits(opts.contentsLimits())
+	}
+
+	digestRef, err := contents.Push(ctx, uploadRef, nil, reg, newLoggerUI(logger))
+	if err != nil {
+		return PushResult{}, err
+	}
+
+	err = writeAdditionalTags(ctx, digestRef, additionalTagRefs, reg)
+	if err != nil {
+		return PushResult{}, err
+	}
+
+	return PushResult{DigestRef: digestRef, Tag: uploadRef.TagStr()}, nil
+}
+
+// PushBundle pushes the provided files as a bundle to ref,
+// registry requests are aborted once ctx is done
+func PushBundle(ctx context.Context, ref string, opts PushOpts, reg registry.Registry, logger Logger) (PushResult, error) {
+	if opts.FileTar != nil {
+		return PushResult{}, fmt.Errorf("Tar input is not compatible with bundle, use a directory containing '.imgpkg' for bundles")
+	}
+
+	uploadRef, err := regname.NewTag(ref, regname.WeakValidation)
+	if err != nil {
+		return PushResult{}, fmt.Errorf("Parsing '%s': %s", ref, err)
+	}
+
+	additionalTagRefs, err := newAdditionalTagRefs(uploadRef, opts.AdditionalTags)
+	if err != nil {
+		return PushResult{}, err
+	}
+
+	compression, err := ctlimg.NewCompression(opts.Compression)
+	if err != nil {
+		return PushResult{}, err
+	}
+
+	bundleContents := bundle.NewContents(opts.Paths, opts.ExcludedPaths, compression).WithLimits(opts.contentsLimits())
+
+	if opts.ValidateImages {
+		err := bundleContents.ValidateImagesExist(ctx, reg)
+		if err != nil {
+			return PushResult{}, err
+		}
+	}
+
+	digestRef, err := bundleContents.Push(ctx, uploadRef, reg, newLoggerUI(logger))
+	if err != nil {
+		return PushResult{}, err
+	}
+
+	err = writeAdditionalTags(ctx, digestRef, additionalTagRefs, reg)
+	if err != nil {
+		return PushResult{}, err
+	}
+
+	imagesLock, err := bundleContents.ImagesLock()
+	if err != nil {
+		return PushResult{}, err
+	}
+
+	result := PushResult{DigestRef: digestRef, Tag: uploadRef.TagStr(), Images: []string{}}
+	for _, img := range imagesLock.Images {
+		result.Images = append(result.Images, img.Image)
+	}
+
+	return result, nil
+}
+
+func (o PushOpts) contentsLimits() plainimage.ContentsLimits {
+	return plainimage.ContentsLimits{MaxSize: o.MaxSize, MaxFiles: o.MaxFiles}
+}
+
+// newAdditionalTagRefs parses every additional tag before anything is pushed
+func newAdditionalTagRefs(uploadRef regname.Tag, tags []string) ([]regname.Tag, error) {
+	var tagRefs []regname.Tag
+	for _, tag := range tags {
+		tagRef, err := regname.NewTag(uploadRef.Context().Name()+":"+tag, regname.WeakValidation)
+		if err == nil && tagRef.TagStr() != tag {
+			err = fmt.Errorf("tag must only contain letters, digits, '_', '.' and '-'")
+		}
+		if err != nil {
+			return nil, fmt.Errorf("Parsing tag '%s': %s", tag, err)
+		}
+		tagRefs = append(tagRefs, tagRef)
+	}
+	return tagRefs, nil
+}
+
+// writeAdditionalTags points every tag to the already pushed digestRef
+func writeAdditionalTags(ctx context.Context, digestRef string, tagRefs []regname.Tag, reg registry.Registry) error {
+	if len(tagRefs) == 0 {
+		return nil
+	}
+
+	ref, err := regname.NewDigest(digestRef)
+	if err != nil {
+		return err
+	}
+
+	desc, err := reg.Get(ctx, ref)
+	if err != nil {
+		return err
+	}
+
+	for _, tagRef := range tagRefs {
+		err := reg.WriteTag(ctx, tagRef, desc)
+		if err != nil {
+			return fmt.Errorf("Writing tag '%s': %s", tagRef.TagStr(), err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,346 @@
+// Copyright 2021 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package v1_test
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/v1/types"
+	"github.com/k14s/imgpkg/pkg/imgpkg/registry"
+	v1 "github.com/k14s/imgpkg/pkg/imgpkg/v1"
+	"github.com/k14s/imgpkg/test/helpers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const emptyImagesYaml = `apiVersion: imgpkg.carvel.dev/v1alpha1
+kind: ImagesLock
+`
+
+func TestPushImage(t *testing.T) {
+	fakeRegistry := helpers.NewFakeRegistry(t)
+	defer fakeRegistry.CleanUp()
+	reg := fakeRegistry.Build()
+
+	t.Run("pushes the files as an image and returns its digest", func(t *testing.T) {
+		assetsDir := createAssetsDir(t, map[string]string{"config.yml": "key: value\n"})
+		logger := &recordingLogger{}
+
+		result, err := v1.PushImage(context.Background(), fakeRegistry.ReferenceOnTestServer("repo/image:some-tag"), v1.PushOpts{Paths: []string{assetsDir}}, reg, logger)
+		require.NoError(t, err)
+
+		assert.Regexp(t, "^"+fakeRegistry.ReferenceOnTestServer("repo/image")+"@sha256:[a-f0-9]{64}$", result.DigestRef)
+		assert.Equal(t, "some-tag", result.Tag)
+		assert.Contains(t, logger.String(), "file: config.yml")
+	})
+
+	t.Run("when the files contain a .imgpkg directory, it returns ErrIsBundle", func(t *testing.T) {
+		assetsDir := createAssetsDir(t, map[string]string{".imgpkg/images.yml": emptyImagesYaml})
+
+		_, err := v1.PushImage(context.Background(), fakeRegistry.ReferenceOnTestServer("repo/image"), v1.PushOpts{Paths: []string{assetsDir}}, reg, nil)
+		require.Error(t, err)
+		assert.True(t, v1.IsBundleError(err), "expected bundle error, got: %s", err)
+	})
+
+	t.Run("when images validation is requested, it errors", func(t *testing.T) {
+		assetsDir := createAssetsDir(t, map[string]string{"config.yml": "key: value\n"})
+
+		_, err := v1.PushImage(context.Background(), fakeRegistry.ReferenceOnTestServer("repo/image"), v1.PushOpts{Paths: []string{assetsDir}, ValidateImages: true}, reg, nil)
+		require.EqualError(t, err, "Images validation is not compatible with image, use bundle for images validation")
+	})
+}
+
+func TestPushImageFromTar(t *testing.T) {
+	fakeRegistry := helpers.NewFakeRegistry(t)
+	defer fakeRegistry.CleanUp()
+	reg := fakeRegistry.Build()
+
+	t.Run("pushes the tar as the image layer and pulls the same contents", func(t *testing.T) {
+		tarBytes := createTar(t, map[string]string{"config.yml": "key: value\n", "nested/values.yml": "other: value\n"})
+		logger := &recordingLogger{}
+
+		result, err := v1.PushImage(context.Background(), fakeRegistry.ReferenceOnTestServer("repo/image"), v1.PushOpts{FileTar: bytes.NewReader(tarBytes)}, reg, logger)
+		require.NoError(t, err)
+		assert.Contains(t, logger.String(), "file: nested/values.yml")
+
+		digestRef, err := name.NewDigest(result.DigestRef)
+		require.NoError(t, err)
+		img, err := reg.Image(context.Background(), digestRef)
+		require.NoError(t, err)
+		layers, err := img.Layers()
+		require.NoError(t, err)
+		require.Len(t, layers, 1)
+
+		layerContents, err := layers[0].Uncompressed()
+		require.NoError(t, err)
+		defer layerContents.Close()
+		pushedTarBytes, err := ioutil.ReadAll(layerContents)
+		require.NoError(t, err)
+		assert.Equal(t, tarBytes, pushedTarBytes, "expected the layer to be the provided tar as is")
+
+		outputDir := createOutputDir(t)
+		_, err = v1.PullImage(context.Background(), result.DigestRef, outputDir, reg, nil)
+		require.NoError(t, err)
+
+		for path, expectedContents := range map[string]string{"config.yml": "key: value\n", "nested/values.yml": "other: value\n"} {
+			contents, err := ioutil.ReadFile(filepath.Join(outputDir, path))
+			require.NoError(t, err)
+			assert.Equal(t, expectedContents, string(contents))
+		}
+	})
+
+	t.Run("when the input is not a tar, it errors", func(t *testing.T) {
+		for _, input := range []string{"", "not a tar", strings.Repeat("not a tar", 100)} {
+			_, err := v1.PushImage(context.Background(), fakeRegistry.ReferenceOnTestServer("repo/image"), v1.PushOpts{FileTar: strings.NewReader(input)}, reg, nil)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), "Expected input to be a tar")
+		}
+	})
+
+	t.Run("when paths are provided as well, it errors", func(t *testing.T) {
+		assetsDir := createAssetsDir(t, map[string]string{"config.yml": "key: value\n"})
+		tarBytes := createTar(t, map[string]string{"config.yml": "key: value\n"})
+
+		_, err := v1.PushImage(context.Background(), fakeRegistry.ReferenceOnTestServer("repo/image"), v1.PushOpts{Paths: []string{assetsDir}, FileTar: bytes.NewReader(tarBytes)}, reg, nil)
+		require.EqualError(t, err, "Expected either paths or a tar to push, but got both")
+	})
+
+	t.Run("when pushing a bundle, it errors", func(t *testing.T) {
+		tarBytes := createTar(t, map[string]string{".imgpkg/images.yml": emptyImagesYaml})
+
+		_, err := v1.PushBundle(context.Background(), fakeRegistry.ReferenceOnTestServer("repo/bundle"), v1.PushOpts{FileTar: bytes.NewReader(tarBytes)}, reg, nil)
+		require.EqualError(t, err, "Tar input is not compatible with bundle, use a directory containing '.imgpkg' for bundles")
+	})
+}
+
+func TestPushBundle(t *testing.T) {
+	fakeRegistry := helpers.NewFakeRegistry(t)
+	defer fakeRegistry.CleanUp()
+	reg := fakeRegistry.Build()
+
+	t.Run("pushes the files as a bundle and returns its digest", func(t *testing.T) {
+		assetsDir := createAssetsDir(t, map[string]string{
+			".imgpkg/images.yml": emptyImagesYaml,
+			"config.yml":         "key: value\n",
+		})
+
+		result, err := v1.PushBundle(context.Background(), fakeRegistry.ReferenceOnTestServer("repo/bundle"), v1.PushOpts{Paths: []string{assetsDir}}, reg, nil)
+		require.NoError(t, err)
+
+		assert.Regexp(t, "^"+fakeRegistry.ReferenceOnTestServer("repo/bundle")+"@sha256:[a-f0-9]{64}$", result.DigestRef)
+		assert.Equal(t, "latest", result.Tag)
+		assert.Equal(t, []string{}, result.Images)
+	})
+
+	t.Run("returns the images referenced by the bundle", func(t *testing.T) {
+		image := fakeRegistry.ReferenceOnTestServer("repo/image@sha256:" + strings.Repeat("a", 64))
+		assetsDir := createAssetsDir(t, map[string]string{
+			".imgpkg/images.yml": fmt.Sprintf("%simages:\n- image: %s\n", emptyImagesYaml, image),
+		})
+
+		result, err := v1.PushBundle(context.Background(), fakeRegistry.ReferenceOnTestServer("repo/bundle"), v1.PushOpts{Paths: []string{assetsDir}}, reg, nil)
+		require.NoError(t, err)
+		assert.Equal(t, []string{image}, result.Images)
+	})
+
+	t.Run("when images validation is requested and an image does not exist, it errors", func(t *testing.T) {
+		missingImage := fakeRegistry.ReferenceOnTestServer("repo/missing@sha256:" + strings.Repeat("a", 64))
+		assetsDir := createAssetsDir(t, map[string]string{
+			".imgpkg/images.yml": fmt.Sprintf("%simages:\n- image: %s\n", emptyImagesYaml, missingImage),
+		})
+
+		_, err := v1.PushBundle(context.Background(), fakeRegistry.ReferenceOnTestServer("repo/bundle"), v1.PushOpts{Paths: []string{assetsDir}, ValidateImages: true}, reg, nil)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), missingImage)
+	})
+}
+
+func TestPushWithCompression(t *testing.T) {
+	fakeRegistry := helpers.NewFakeRegistry(t)
+	defer fakeRegistry.CleanUp()
+	reg := fakeRegistry.Build()
+
+	testCases := []struct {
+		compression       string
+		expectedMediaType types.MediaType
+	}{
+		{compression: "", expectedMediaType: types.DockerLayer},
+		{compression: "gzip", expectedMediaType: types.DockerLayer},
+		{compression: "zstd", expectedMediaType: "application/vnd.oci.image.layer.v1.tar+zstd"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(fmt.Sprintf("when compression is '%s', pushed image round trips", tc.compression), func(t *testing.T) {
+			assetsDir := createAssetsDir(t, map[string]string{"config.yml": "key: value\n"})
+
+			result, err := v1.PushImage(context.Background(), fakeRegistry.ReferenceOnTestServer("repo/image"), v1.PushOpts{Paths: []string{assetsDir}, Compression: tc.compression}, reg, nil)
+			require.NoError(t, err)
+			assertLayerMediaType(t, reg, result.DigestRef, tc.expectedMediaType)
+
+			outputDir := createOutputDir(t)
+			_, err = v1.PullImage(context.Background(), result.DigestRef, outputDir, reg, nil)
+			require.NoError(t, err)
+
+			contents, err := ioutil.ReadFile(filepath.Join(outputDir, "config.yml"))
+			require.NoError(t, err)
+			assert.Equal(t, "key: value\n", string(contents))
+		})
+
+		t.Run(fmt.Sprintf("when compression is '%s', pushed bundle round trips", tc.compression), func(t *testing.T) {
+			assetsDir := createAssetsDir(t, map[string]string{
+				".imgpkg/images.yml": emptyImagesYaml,
+				"config.yml":         "key: value\n",
+			})
+
+			result, err := v1.PushBundle(context.Background(), fakeRegistry.ReferenceOnTestServer("repo/bundle"), v1.PushOpts{Paths: []string{assetsDir}, Compression: tc.compression}, reg, nil)
+			require.NoError(t, err)
+			assertLayerMediaType(t, reg, result.DigestRef, tc.expectedMediaType)
+
+			outputDir := createOutputDir(t)
+			_, err = v1.PullBundle(context.Background(), result.DigestRef, outputDir, v1.PullOpts{Recursive: true}, reg, nil)
+			require.NoError(t, err)
+
+			contents, err := ioutil.ReadFile(filepath.Join(outputDir, "config.yml"))
+			require.NoError(t, err)
+			assert.Equal(t, "key: value\n", string(contents))
+		})
+	}
+
+	t.Run("when compression is not supported, it errors", func(t *testing.T) {
+		assetsDir := createAssetsDir(t, map[string]string{"config.yml": "key: value\n"})
+
+		_, err := v1.PushImage(context.Background(), fakeRegistry.ReferenceOnTestServer("repo/image"), v1.PushOpts{Paths: []string{assetsDir}, Compression: "lz4"}, reg, nil)
+		require.EqualError(t, err, "Expected compression to be one of [gzip zstd], but was 'lz4'")
+	})
+}
+
+func TestPushWithLimits(t *testing.T) {
+	fakeRegistry := helpers.NewFakeRegistry(t)
+	defer fakeRegistry.CleanUp()
+	reg := fakeRegistry.Build()
+
+	assetsDir := createAssetsDir(t, map[string]string{
+		".imgpkg/images.yml":             emptyImagesYaml,
+		"config.yml":                     "key: value\n",
+		"node_modules/left-pad/index.js": strings.Repeat("a", 2048),
+		"node_modules/left-pad/LICENSE":  strings.Repeat("b", 512),
+		"node_modules/is-odd/index.js":   strings.Repeat("c", 1024),
+		".git/objects/big":               strings.Repeat("d", 8192),
+	})
+	excludedPaths := []string{".git"}
+
+	t.Run("when the files total more than the max size, it errors naming the largest paths", func(t *testing.T) {
+		opts := v1.PushOpts{Paths: []string{assetsDir}, ExcludedPaths: excludedPaths, MaxSize: 1024}
+
+		_, err := v1.PushBundle(context.Background(), fakeRegistry.ReferenceOnTestServer("repo/too-large-bundle"), opts, reg, nil)
+		require.Error(t, err)
+		assert.Equal(t, fmt.Sprintf(`Expected files to total at most 1.0 KiB, but they total %s. Largest paths:
+- %s (3.5 KiB)
+- %s (%d B)
+- %s (11 B)`, "3.6 KiB", filepath.Join(assetsDir, "node_modules"), filepath.Join(assetsDir, ".imgpkg"), len(emptyImagesYaml), filepath.Join(assetsDir, "config.yml")), err.Error())
+
+		_, err = reg.Digest(context.Background(), mustParseTag(t, fakeRegistry.ReferenceOnTestServer("repo/too-large-bundle")))
+		assert.Error(t, err, "expected bundle to not be pushed")
+	})
+
+	t.Run("when there are more files than the max files, it errors naming the paths with the most files", func(t *testing.T) {
+		opts := v1.PushOpts{Paths: []string{filepath.Join(assetsDir, "node_modules"), filepath.Join(assetsDir, "config.yml")}, MaxFiles: 3}
+
+		_, err := v1.PushImage(context.Background(), fakeRegistry.ReferenceOnTestServer("repo/too-many-files-image"), opts, reg, nil)
+		require.Error(t, err)
+		assert.Equal(t, fmt.Sprintf(`Expected at most 3 files, but found 4. Paths with the most files:
+- %s (2 files)
+- %s (1 file)
+- %s (1 file)`, filepath.Join(assetsDir, "node_modules", "left-pad"), filepath.Join(assetsDir, "node_modules", "is-odd"), filepath.Join(assetsDir, "config.yml")), err.Error())
+
+		_, err = reg.Digest(context.Background(), mustParseTag(t, fakeRegistry.ReferenceOnTestServer("repo/too-many-files-image")))
+		assert.Error(t, err, "expected image to not be pushed")
+	})
+
+	t.Run("when the files are within the limits, it pushes without counting excluded paths", func(t *testing.T) {
+		opts := v1.PushOpts{Paths: []string{assetsDir}, ExcludedPaths: excludedPaths, MaxSize: 4096, MaxFiles: 5}
+
+		result, err := v1.PushBundle(context.Background(), fakeRegistry.ReferenceOnTestServer("repo/bundle"), opts, reg, nil)
+		require.NoError(t, err)
+		assert.Regexp(t, "^"+fakeRegistry.ReferenceOnTestServer("repo/bundle")+"@sha256:[a-f0-9]{64}$", result.DigestRef)
+	})
+
+	t.Run("when a tar is pushed, it errors", func(t *testing.T) {
+		opts := v1.PushOpts{FileTar: bytes.NewReader(createTar(t, map[string]string{"config.yml": "key: value\n"})), MaxFiles: 5}
+
+		_, err := v1.PushImage(context.Background(), fakeRegistry.ReferenceOnTestServer("repo/image"), opts, reg, nil)
+		require.EqualError(t, err, "Expected size and files limits to be used with paths, but got a tar")
+	})
+}
+
+func assertLayerMediaType(t *testing.T, reg registry.Registry, ref string, expectedMediaType types.MediaType) {
+	digestRef, err := name.NewDigest(ref)
+	require.NoError(t, err)
+
+	img, err := reg.Image(context.Background(), digestRef)
+	require.NoError(t, err)
+
+	layers, err := img.Layers()
+	require.NoError(t, err)
+	require.Len(t, layers, 1)
+
+	mediaType, err := layers[0].MediaType()
+	require.NoError(t, err)
+	assert.Equal(t, expectedMediaType, mediaType)
+}
+
+type recordingLogger struct {
+	strings.Builder
+}
+
+func (l *recordingLogger) Logf(msg string, args ...interface{}) {
+	l.WriteString(fmt.Sprintf(msg, args...))
+}
+
+func createTar(t *testing.T, files map[string]string) []byte {
+	var paths []string
+	for path := range files {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	tarBytes := &bytes.Buffer{}
+	tarWriter := tar.NewWriter(tarBytes)
+	for _, path := range paths {
+		require.NoError(t, tarWriter.WriteHeader(&tar.Header{Name: path, Typeflag: tar.TypeReg, Mode: 0600, Size: int64(len(files[path]))}))
+		_, err := tarWriter.Write([]byte(files[path]))
+		require.NoError(t, err)
+	}
+	require.NoError(t, tarWriter.Close())
+	return tarBytes.Bytes()
+}
+
+func mustParseTag(t *testing.T, ref string) name.Tag {
+	tag, err := name.NewTag(ref)
+	require.NoError(t, err)
+	return tag
+}
+
+func createAssetsDir(t *testing.T, files map[string]string) string {
+	assetsDir, err := ioutil.TempDir("", "imgpkg-v1-assets")
+	require.NoError(t, err)
+	t.Cleanup(func() { os.RemoveAll(assetsDir) })
+
+	for path, contents := range files {
+		fullPath := filepath.Join(assetsDir, path)
+		require.NoError(t, os.MkdirAll(filepath.Dir(fullPath), 0700))
+		require.NoError(t, ioutil.WriteFile(fullPath, []byte(contents), 0600))
+	}
+	return assetsDir
+}
@@ -47,6 +47,58 @@ func TestCopyImageToRepoDestinationAndOutputImageLockFileAndPreserverImageTag(t
 	require.Error(t, env.Assert.ValidateImagesPresenceInRegistry([]string{fmt.Sprintf("%s:%v", env.RelocationRepo, tag)}))
 }
 
+func TestCopyImageToRepoDestinationPreservingTags(t *testing.T) {
+	env := helpers.BuildEnv(t)
+	imgpkg := helpers.Imgpkg{T: t, L: helpers.Logger{}, ImgpkgPath: env.ImgpkgPath}
+	defer env.Cleanup()
+
+	imageDigest := env.ImageFactory.PushSimpleAppImageWithRandomFile(imgpkg, env.Image+":latest")
+	srcRef, err := name.ParseReference(env.Image + imageDigest)
+	require.NoError(t, err)
+	srcImage, err := remote.Image(srcRef, remote.WithAuthFromKeychain(authn.DefaultKeychain))
+	require.NoError(t, err)
+	v1Tag, err := name.NewTag(env.Image + ":v1")
+	require.NoError(t, err)
+	require.NoError(t, remote.Tag(v1Tag, srcImage, remote.WithAuthFromKeychain(authn.DefaultKeychain)))
+
+	imgpkg.Run([]string{"copy", "-i", env.Image + ":latest", "--to-repo", env.RelocationRepo, "--preserve-tags"})
+
+	for _, tag := range []string{"latest", "v1"} {
+		tagRef, err := name.NewTag(env.RelocationRepo + ":" + tag)
+		require.NoError(t, err)
+
+		desc, err := remote.Head(tagRef, remote.WithAuthFromKeychain(authn.DefaultKeychain))
+		require.NoError(t, err)
+		assert.Equal(t, imageDigest, "@"+desc.Digest.String(), "expected tag '%s' to point to the copied image", tag)
+	}
+}
+
+func TestCopyImageToMultipleRepoDestinations(t *testing.T) {
+	env := helpers.BuildEnv(t)
+	imgpkg := helpers.Imgpkg{T: t, L: helpers.Logger{}, ImgpkgPath: env.ImgpkgPath}
+	defer env.Cleanup()
+
+	imageDigest := env.ImageFactory.PushSimpleAppImageWithRandomFile(imgpkg, env.Image)
+	destinationRepos := []string{env.RelocationRepo, env.RelocationRepo + "-second"}
+
+	var stderr bytes.Buffer
+	imgpkg.RunWithOpts([]string{"copy", "-i", env.Image + imageDigest, "--to-repo", destinationRepos[0], "--to-repo", destinationRepos[1]},
+		helpers.RunOpts{StderrWriter: &stderr})
+
+	assert.Contains(t, stderr.String(), "copy summary for 2 destinations")
+
+	for _, repo := range destinationRepos {
+		assert.Contains(t, stderr.String(), repo+": succeeded")
+
+		digestRef, err := name.NewDigest(repo + imageDigest)
+		require.NoError(t, err)
+
+		desc, err := remote.Head(digestRef, remote.WithAuthFromKeychain(authn.DefaultKeychain))
+		require.NoError(t, err)
+		assert.Equal(t, imageDigest, "@"+desc.Digest.String(), "expected image to be copied to '%s'", repo)
+	}
+}
+
 func TestCopyAnImageFromATarToARepoThatDoesNotContainNonDistributableLayersButTheFlagWasIncluded(t *testing.T) {
 	t.Run("environment with internet", func(t *testing.T) {
 		env := helpers.BuildEnv(t)
@@ -0,0 +1,65 @@
+// Copyright 2021 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package e2e
+
+import (
+	"testing"
+
+	"github.com/k14s/imgpkg/test/helpers"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeleteImage(t *testing.T) {
+	env := helpers.BuildEnv(t)
+	imgpkg := helpers.Imgpkg{T: t, L: helpers.Logger{}, ImgpkgPath: env.ImgpkgPath}
+	defer env.Cleanup()
+
+	imageDigest := env.ImageFactory.PushSimpleAppImageWithRandomFile(imgpkg, env.Image)
+	imageDigestRef := env.Image + imageDigest
+
+	out := imgpkg.Run([]string{"delete", "--tty", "-i", imageDigestRef})
+	require.Contains(t, out, "Deleted '"+imageDigestRef+"'")
+
+	pullDir := env.Assets.CreateTempFolder("pull-deleted-image")
+	_, err := imgpkg.RunWithOpts([]string{"pull", "-i", imageDigestRef, "-o", pullDir}, helpers.RunOpts{AllowError: true})
+	require.Error(t, err)
+}
+
+func TestDeleteImageByTag(t *testing.T) {
+	env := helpers.BuildEnv(t)
+	imgpkg := helpers.Imgpkg{T: t, L: helpers.Logger{}, ImgpkgPath: env.ImgpkgPath}
+	defer env.Cleanup()
+
+	imageTagRef := env.Image + ":delete-by-tag"
+	imageDigest := env.ImageFactory.PushSimpleAppImageWithRandomFile(imgpkg, imageTagRef)
+	imageDigestRef := env.Image + imageDigest
+
+	out := imgpkg.Run([]string{"delete", "--tty", "-i", imageTagRef})
+	require.Contains(t, out, "also deletes every other tag pointing to it")
+	require.Contains(t, out, "Deleted '"+imageDigestRef+"'")
+
+	pullDir := env.Assets.CreateTempFolder("pull-deleted-image-by-tag")
+	_, err := imgpkg.RunWithOpts([]string{"pull", "-i", imageDigestRef, "-o", pullDir}, helpers.RunOpts{AllowError: true})
+	require.Error(t, err)
+}
+
+func TestDeleteBundle(t *testing.T) {
+	env := helpers.BuildEnv(t)
+	imgpkg := helpers.Imgpkg{T: t, L: helpers.Logger{}, ImgpkgPath: env.ImgpkgPath}
+	defer env.Cleanup()
+
+	bundleDir := env.BundleFactory.CreateBundleDir(helpers.BundleYAML, helpers.ImagesYAML)
+	out := imgpkg.Run([]string{"push", "--tty", "-b", env.Image, "-f", bundleDir})
+	bundleDigestRef := env.Image + "@" + helpers.ExtractDigest(t, out)
+
+	_, err := imgpkg.RunWithOpts([]string{"delete", "-i", bundleDigestRef}, helpers.RunOpts{AllowError: true})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "Use --force to delete bundles")
+
+	imgpkg.Run([]string{"delete", "-i", bundleDigestRef, "--force"})
+
+	pullDir := env.Assets.CreateTempFolder("pull-deleted-bundle")
+	_, err = imgpkg.RunWithOpts([]string{"pull", "-b", bundleDigestRef, "-o", pullDir}, helpers.RunOpts{AllowError: true})
+	require.Error(t, err)
+}
@@ -4,10 +4,14 @@
 package e2e
 
 import (
+	"io/ioutil"
+	"os"
 	"path/filepath"
 	"testing"
 
 	"github.com/k14s/imgpkg/test/helpers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestPushPull(t *testing.T) {
@@ -28,6 +32,40 @@ func TestPushPull(t *testing.T) {
 	})
 }
 
+func TestPushPullPreservesExecutableBitAndSymlinks(t *testing.T) {
+	env := helpers.BuildEnv(t)
+	imgpkg := helpers.Imgpkg{T: t, L: helpers.Logger{}, ImgpkgPath: env.ImgpkgPath}
+	defer env.Cleanup()
+
+	assetsDir := env.Assets.CreateTempFolder("imgpkg-test-modes-assets")
+	require.NoError(t, os.MkdirAll(filepath.Join(assetsDir, "bin"), 0700))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(assetsDir, "bin", "run.sh"), []byte("#!/bin/sh\necho hi\n"), 0755))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(assetsDir, "config.yml"), []byte("key: value\n"), 0644))
+	require.NoError(t, os.Symlink("bin/run.sh", filepath.Join(assetsDir, "run")))
+
+	imgpkg.Run([]string{"push", "-i", env.Image, "-f", assetsDir})
+
+	testDir := env.Assets.CreateTempFolder("imgpkg-test-modes")
+	imgpkg.Run([]string{"pull", "-i", env.Image, "-o", testDir})
+
+	info, err := os.Stat(filepath.Join(testDir, "bin", "run.sh"))
+	require.NoError(t, err)
+	assert.NotZero(t, info.Mode()&0100, "expected executable bit to be preserved")
+
+	info, err = os.Stat(filepath.Join(testDir, "config.yml"))
+	require.NoError(t, err)
+	assert.Zero(t, info.Mode()&0111, "expected file to not be executable")
+
+	info, err = os.Lstat(filepath.Join(testDir, "run"))
+	require.NoError(t, err)
+	require.NotZero(t, info.Mode()&os.ModeSymlink, "expected symlink to be preserved")
+
+	linkTarget, err := os.Readlink(filepath.Join(testDir, "run"))
+	require.NoError(t, err)
+	assert.Equal(t, "bin/run.sh", linkTarget)
+	helpers.CompareFiles(t, filepath.Join(assetsDir, "bin", "run.sh"), filepath.Join(testDir, "run"))
+}
+
 func TestPushMultipleFiles(t *testing.T) {
 	env := helpers.BuildEnv(t)
 	imgpkg := helpers.Imgpkg{T: t, L: helpers.Logger{}, ImgpkgPath: env.ImgpkgPath}
@@ -0,0 +1,69 @@
+// Copyright 2021 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package e2e
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/k14s/imgpkg/test/helpers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOutputColorAndQuiet(t *testing.T) {
+	env := helpers.BuildEnv(t)
+	imgpkg := helpers.Imgpkg{T: t, L: helpers.Logger{}, ImgpkgPath: env.ImgpkgPath}
+	defer env.Cleanup()
+
+	imageDigest := env.ImageFactory.PushSimpleAppImageWithRandomFile(imgpkg, env.Image)
+
+	copyArgs := []string{"copy", "--tty", "-i", env.Image + imageDigest, "--to-repo", env.RelocationRepo}
+
+	t.Run("when output is not a terminal, it does not contain ANSI codes", func(t *testing.T) {
+		var stderr bytes.Buffer
+		out, err := imgpkg.RunWithOpts(append(copyArgs, "--debug"), helpers.RunOpts{StderrWriter: &stderr})
+		require.NoError(t, err)
+
+		assert.Contains(t, stderr.String(), "copy | ")
+		assert.Contains(t, stderr.String(), "registry | --> ")
+		assert.NotContains(t, stderr.String(), "\x1b[")
+		assert.NotContains(t, out, "\x1b[")
+	})
+
+	t.Run("when NO_COLOR is set, it does not contain ANSI codes", func(t *testing.T) {
+		var stderr bytes.Buffer
+		out, err := imgpkg.RunWithOpts(append(copyArgs, "--color=auto"), helpers.RunOpts{StderrWriter: &stderr, EnvVars: []string{"NO_COLOR=1"}})
+		require.NoError(t, err)
+
+		assert.NotContains(t, stderr.String(), "\x1b[")
+		assert.NotContains(t, out, "\x1b[")
+	})
+
+	t.Run("when color is always, prefixes are colorized", func(t *testing.T) {
+		var stderr bytes.Buffer
+		_, err := imgpkg.RunWithOpts(append(copyArgs, "--color=always"), helpers.RunOpts{StderrWriter: &stderr})
+		require.NoError(t, err)
+
+		assert.Contains(t, stderr.String(), "\x1b[36mcopy | \x1b[0m")
+	})
+
+	t.Run("when quiet, only the result is printed", func(t *testing.T) {
+		var stderr bytes.Buffer
+		out, err := imgpkg.RunWithOpts(append(copyArgs, "--quiet"), helpers.RunOpts{StderrWriter: &stderr})
+		require.NoError(t, err)
+
+		assert.Empty(t, stderr.String())
+		assert.Contains(t, out, "Succeeded")
+	})
+
+	t.Run("when quiet and copying to multiple destinations, the summary is printed", func(t *testing.T) {
+		var stderr bytes.Buffer
+		_, err := imgpkg.RunWithOpts(append(copyArgs, "--to-repo", env.RelocationRepo+"-second", "--quiet"), helpers.RunOpts{StderrWriter: &stderr})
+		require.NoError(t, err)
+
+		assert.Equal(t, fmt.Sprintf("copy | copy summary for 2 destinations:\ncopy |   %s: succeeded\ncopy |   %s-second: succeeded\n", env.RelocationRepo, env.RelocationRepo), stderr.String())
+	})
+}
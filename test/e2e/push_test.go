@@ -4,11 +4,15 @@
 package e2e
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
 	"path/filepath"
 	"testing"
 
 	"github.com/k14s/imgpkg/test/helpers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestPushBundleOfBundles(t *testing.T) {
@@ -36,3 +40,24 @@ images:
 		imgpkg.Run([]string{"push", "-b", env.Image, "-f", bundleDir})
 	})
 }
+
+func TestPushWithOutputFormatJSON(t *testing.T) {
+	env := helpers.BuildEnv(t)
+	imgpkg := helpers.Imgpkg{T: t, L: helpers.Logger{}, ImgpkgPath: env.ImgpkgPath}
+	defer env.Cleanup()
+
+	bundleDir := env.BundleFactory.CreateBundleDir(helpers.BundleYAML, helpers.ImagesYAML)
+
+	// --tty makes sure that human readable lines would be printed to stdout
+	var stderr bytes.Buffer
+	out, err := imgpkg.RunWithOpts([]string{"push", "--tty", "-b", env.Image, "-f", bundleDir, "--output-format", "json"},
+		helpers.RunOpts{StderrWriter: &stderr})
+	require.NoError(t, err)
+
+	var output map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(out), &output), "expected stdout to only contain JSON, got: %s", out)
+
+	assert.Regexp(t, "^sha256:[a-f0-9]{64}$", output["digest"])
+	assert.NotContains(t, out, "Succeeded")
+	assert.Contains(t, stderr.String(), "file: .imgpkg/images.yml")
+}
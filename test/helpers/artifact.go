@@ -0,0 +1,93 @@
+// Copyright 2021 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package helpers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/partial"
+	"github.com/google/go-containerregistry/pkg/v1/types"
+	"github.com/stretchr/testify/require"
+)
+
+// WithArtifact adds an OCI artifact (e.g. a Helm chart) whose config and layer
+// are not the ones of a container image; the manifest bytes are kept as is
+func (r *FakeTestRegistryBuilder) WithArtifact(artifactName string, configMediaType, layerMediaType types.MediaType) *ImageOrImageIndexWithTarPath {
+	// Artifact configs are not necessarily JSON, unlike image configs
+	config := []byte("name: chart\nversion: 1.0.0\n")
+	content := []byte("not a tarball, but artifacts do not care")
+
+	configDesc := artifactDescriptor(config, configMediaType)
+	contentDesc := artifactDescriptor(content, layerMediaType)
+
+	manifest, err := json.Marshal(map[string]interface{}{
+		"schemaVersion": 2,
+		"mediaType":     types.OCIManifestSchema1,
+		"config":        configDesc,
+		"layers":        []v1.Descriptor{contentDesc},
+		"annotations":   map[string]string{"org.opencontainers.artifact.description": "test artifact"},
+	})
+	require.NoError(r.t, err)
+
+	img, err := partial.CompressedToImage(&rawArtifact{
+		manifest: manifest,
+		config:   config,
+		blobs:    map[v1.Hash]artifactBlob{contentDesc.Digest: {content, layerMediaType}},
+	})
+	require.NoError(r.t, err)
+
+	return r.updateState(artifactName, img, nil, "")
+}
+
+func artifactDescriptor(content []byte, mediaType types.MediaType) v1.Descriptor {
+	digest, size, err := v1.SHA256(bytes.NewReader(content))
+	if err != nil {
+		panic(fmt.Sprintf("Unable to compute digest: %s", err))
+	}
+	return v1.Descriptor{MediaType: mediaType, Size: size, Digest: digest}
+}
+
+type rawArtifact struct {
+	manifest []byte
+	config   []byte
+	blobs    map[v1.Hash]artifactBlob
+}
+
+var _ partial.CompressedImageCore = &rawArtifact{}
+
+func (a *rawArtifact) RawConfigFile() ([]byte, error) { return a.config, nil }
+func (a *rawArtifact) MediaType() (types.MediaType, error) {
+	return types.OCIManifestSchema1, nil
+}
+func (a *rawArtifact) RawManifest() ([]byte, error) { return a.manifest, nil }
+
+func (a *rawArtifact) LayerByDigest(digest v1.Hash) (partial.CompressedLayer, error) {
+	blob, found := a.blobs[digest]
+	if !found {
+		return nil, fmt.Errorf("Unknown blob %s", digest)
+	}
+	return &artifactLayer{digest: digest, artifactBlob: blob}, nil
+}
+
+type artifactBlob struct {
+	content   []byte
+	mediaType types.MediaType
+}
+
+type artifactLayer struct {
+	artifactBlob
+	digest v1.Hash
+}
+
+func (l *artifactLayer) Digest() (v1.Hash, error) { return l.digest, nil }
+func (l *artifactLayer) Compressed() (io.ReadCloser, error) {
+	return ioutil.NopCloser(bytes.NewReader(l.content)), nil
+}
+func (l *artifactLayer) Size() (int64, error)                { return int64(len(l.content)), nil }
+func (l *artifactLayer) MediaType() (types.MediaType, error) { return l.mediaType, nil }
@@ -0,0 +1,121 @@
+// Copyright 2021 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package helpers
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// BlobUploadsTracker records how blobs reach each repository of the fake registry.
+// The underlying fake registry stores blobs globally, so the tracker scopes them
+// per repository to be able to observe cross-repository mounts.
+type BlobUploadsTracker struct {
+	mountSupported bool
+
+	lock      sync.Mutex
+	repoBlobs map[string]map[string]struct{}
+	mounted   []string
+	uploaded  []string
+}
+
+// WithBlobUploadsTracking needs to be called before Build so that blobs of the
+// images created by the builder are tracked as well
+func (r *FakeTestRegistryBuilder) WithBlobUploadsTracking(mountSupported bool) *BlobUploadsTracker {
+	tracker := &BlobUploadsTracker{mountSupported: mountSupported, repoBlobs: map[string]map[string]struct{}{}}
+
+	parentHandler := r.server.Config.Handler
+	r.server.Config.Handler = http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		if tracker.handle(writer, request) {
+			return
+		}
+		parentHandler.ServeHTTP(writer, request)
+	})
+
+	return tracker
+}
+
+// Reset forgets about the mounts and uploads seen so far, but not about the blobs present
+func (b *BlobUploadsTracker) Reset() {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.mounted = nil
+	b.uploaded = nil
+}
+
+// Mounted returns the digests of the blobs mounted from another repository
+func (b *BlobUploadsTracker) Mounted() []string {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return append([]string{}, b.mounted...)
+}
+
+// Uploaded returns the digests of the blobs whose content was uploaded
+func (b *BlobUploadsTracker) Uploaded() []string {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return append([]string{}, b.uploaded...)
+}
+
+// handle returns true when it took care of responding to the request
+func (b *BlobUploadsTracker) handle(writer http.ResponseWriter, request *http.Request) bool {
+	repo, target, isBlobRequest := parseBlobPath(request.URL.Path)
+	if !isBlobRequest {
+		return false
+	}
+
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	query := request.URL.Query()
+
+	switch {
+	case request.Method == http.MethodHead:
+		if _, found := b.repoBlobs[repo][target]; !found {
+			writer.WriteHeader(http.StatusNotFound)
+			return true
+		}
+
+	case request.Method == http.MethodPost && query.Get("mount") != "":
+		digest := query.Get("mount")
+		if _, found := b.repoBlobs[query.Get("from")][digest]; b.mountSupported && found {
+			b.addBlob(repo, digest)
+			b.mounted = append(b.mounted, digest)
+
+			writer.Header().Set("Location", fmt.Sprintf("/v2/%s/blobs/%s", repo, digest))
+			writer.Header().Set("Docker-Content-Digest", digest)
+			writer.WriteHeader(http.StatusCreated)
+			return true
+		}
+
+	case (request.Method == http.MethodPut || request.Method == http.MethodPost) && query.Get("digest") != "":
+		b.addBlob(repo, query.Get("digest"))
+		b.uploaded = append(b.uploaded, query.Get("digest"))
+	}
+
+	return false
+}
+
+func (b *BlobUploadsTracker) addBlob(repo, digest string) {
+	if _, found := b.repoBlobs[repo]; !found {
+		b.repoBlobs[repo] = map[string]struct{}{}
+	}
+	b.repoBlobs[repo][digest] = struct{}{}
+}
+
+// parseBlobPath splits /v2/<repo>/blobs/<digest> and /v2/<repo>/blobs/uploads/<id>
+func parseBlobPath(path string) (string, string, bool) {
+	elems := strings.Split(strings.TrimSuffix(strings.TrimPrefix(path, "/v2/"), "/"), "/")
+	for i := len(elems) - 1; i > 0; i-- {
+		if elems[i] == "blobs" {
+			if i+1 >= len(elems) {
+				return "", "", false
+			}
+			return strings.Join(elems[:i], "/"), elems[i+1], true
+		}
+	}
+	return "", "", false
+}
@@ -15,6 +15,7 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 	"testing"
 
 	"github.com/google/go-containerregistry/pkg/authn"
@@ -147,6 +148,81 @@ func (r *FakeTestRegistryBuilder) WithIdentityToken(idToken string) {
 	r.server.Config.Handler = oauth2HandlerFunc
 }
 
+// WithExpiringIdentityTokens behaves like WithIdentityToken, but every access token obtained by
+// exchanging an identity token is rejected (with a challenge, as registries do) once it was used
+// for requestsPerToken requests. Identity tokens can only be exchanged once, the same way short-lived
+// credentials issued by credential helpers (e.g. for GCR or ECR) expire along with the access token.
+// It returns the number of token exchanges that happened so far.
+func (r *FakeTestRegistryBuilder) WithExpiringIdentityTokens(requestsPerToken int) func() int {
+	// Used by the builder to populate the registry, it never expires
+	const builderToken string = "builder_access_token"
+	r.auth = &authn.Bearer{Token: builderToken}
+
+	var lock sync.Mutex
+	var exchanges int
+	exchangedIDTokens := map[string]struct{}{}
+	tokenUses := map[string]int{}
+
+	challenge := `Bearer service="fakeRegistry",realm="` + r.server.URL + `/id_token_auth"`
+	parentHandler := r.server.Config.Handler
+
+	r.server.Config.Handler = http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		if strings.HasSuffix(request.URL.String(), "/v2/") {
+			writer.Header().Add("WWW-Authenticate", challenge)
+			writer.WriteHeader(401)
+			return
+		}
+
+		if strings.HasSuffix(request.URL.String(), "/id_token_auth") {
+			lock.Lock()
+			defer lock.Unlock()
+
+			requestBody, err := ioutil.ReadAll(request.Body)
+			assert.NoError(r.t, err)
+			form, err := url.ParseQuery(string(requestBody))
+			assert.NoError(r.t, err)
+
+			idToken := form.Get("refresh_token")
+			if _, exchanged := exchangedIDTokens[idToken]; exchanged || idToken == "" {
+				writer.WriteHeader(401)
+				return
+			}
+			exchangedIDTokens[idToken] = struct{}{}
+
+			exchanges++
+			accessToken := fmt.Sprintf("access_token_%d", exchanges)
+			tokenUses[accessToken] = 0
+
+			_, _ = writer.Write([]byte(fmt.Sprintf(`{"access_token": "%s", "token_type": "bearer", "expires_in": 3600}`, accessToken)))
+			return
+		}
+
+		accessToken := strings.TrimPrefix(request.Header.Get("Authorization"), "Bearer ")
+		if accessToken != builderToken {
+			lock.Lock()
+			uses, found := tokenUses[accessToken]
+			if found {
+				tokenUses[accessToken]++
+			}
+			lock.Unlock()
+
+			if !found || uses >= requestsPerToken {
+				writer.Header().Add("WWW-Authenticate", challenge)
+				writer.WriteHeader(401)
+				return
+			}
+		}
+
+		parentHandler.ServeHTTP(writer, request)
+	})
+
+	return func() int {
+		lock.Lock()
+		defer lock.Unlock()
+		return exchanges
+	}
+}
+
 func (r *FakeTestRegistryBuilder) WithRegistryToken(regToken string) {
 	r.auth = &authn.Bearer{Token: regToken}
 
@@ -173,12 +249,38 @@ func (r *FakeTestRegistryBuilder) WithRegistryToken(regToken string) {
 	r.server.Config.Handler = authHandlerFunc
 }
 
+// WithStalledUploads makes blob and manifest uploads hang until the client gives up on them,
+// the returned channel is closed once the first upload started.
+// It must be called after Build, otherwise the images cannot be pushed.
+func (r *FakeTestRegistryBuilder) WithStalledUploads() <-chan struct{} {
+	parentHandler := r.server.Config.Handler
+	uploadStarted := make(chan struct{})
+	var once sync.Once
+
+	stalledHandlerFunc := http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		isManifestUpload := request.Method == http.MethodPut && strings.Contains(request.URL.Path, "/manifests/")
+		if isManifestUpload || strings.Contains(request.URL.Path, "/blobs/uploads/") {
+			once.Do(func() { close(uploadStarted) })
+			// Reading the whole body lets the server notice when the client disconnects
+			_, _ = io.Copy(io.Discard, request.Body)
+			<-request.Context().Done()
+			return
+		}
+
+		parentHandler.ServeHTTP(writer, request)
+	})
+
+	r.server.Config.Handler = stalledHandlerFunc
+
+	return uploadStarted
+}
+
 func (r *FakeTestRegistryBuilder) WithBundleFromPath(bundleName string, path string) BundleInfo {
 	tarballLayer, err := compress(path)
 	require.NoError(r.t, err)
-	label := map[string]string{"dev.carvel.imgpkg.bundle": ""}
+	label := map[string]string{bundle.BundleConfigLabel: ""}
 
-	bundle, err := image.NewFileImage(tarballLayer.Name(), label)
+	bundle, err := image.NewFileImage(tarballLayer.Name(), label, image.CompressionGzip)
 	require.NoError(r.t, err)
 
 	r.updateState(bundleName, bundle, nil, path)
@@ -189,29 +291,29 @@ func (r *FakeTestRegistryBuilder) WithBundleFromPath(bundleName string, path str
 }
 
 func (r *FakeTestRegistryBuilder) WithRandomBundle(bundleName string) BundleInfo {
-	bundle, err := random.Image(500, 5)
+	bundleImg, err := random.Image(500, 5)
 	require.NoError(r.t, err)
 
-	bundle, err = mutate.ConfigFile(bundle, &v1.ConfigFile{
+	bundleImg, err = mutate.ConfigFile(bundleImg, &v1.ConfigFile{
 		Config: v1.Config{
-			Labels: map[string]string{"dev.carvel.imgpkg.bundle": "true"},
+			Labels: map[string]string{bundle.BundleConfigLabel: "true"},
 		},
 	})
 	require.NoError(r.t, err, "create image from tar")
 
-	r.updateState(bundleName, bundle, nil, "")
+	r.updateState(bundleName, bundleImg, nil, "")
 
-	digest, err := bundle.Digest()
+	digest, err := bundleImg.Digest()
 	assert.NoError(r.t, err)
 
-	return BundleInfo{r, bundle, bundleName, "", digest.String(), r.ReferenceOnTestServer(bundleName + "@" + digest.String())}
+	return BundleInfo{r, bundleImg, bundleName, "", digest.String(), r.ReferenceOnTestServer(bundleName + "@" + digest.String())}
 }
 
 func (r *FakeTestRegistryBuilder) WithImageFromPath(imageNameFromTest string, path string, labels map[string]string) *ImageOrImageIndexWithTarPath {
 	tarballLayer, err := compress(path)
 	require.NoError(r.t, err)
 
-	fileImage, err := image.NewFileImage(tarballLayer.Name(), labels)
+	fileImage, err := image.NewFileImage(tarballLayer.Name(), labels, image.CompressionGzip)
 	require.NoError(r.t, err)
 
 	return r.updateState(imageNameFromTest, fileImage, nil, path)